@@ -0,0 +1,205 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAdminQueries limits the number of distinct statements Admin
+// aggregates stats for, further statements are counted as "other"
+const maxAdminQueries = 1000
+
+// Admin is an http.Handler exposing the internals of a DB as JSON,
+// for ops dashboards and debugging. Create it using db.Admin and
+// mount it on an internal mux, it has no authentication:
+//
+//	admin := db.Admin(migrations...)
+//	db.OnQueryStats = admin.Record
+//	mux.Handle("/debug/sqlpro/", http.StripPrefix("/debug/sqlpro", admin))
+//
+// GET returns an AdminStatus. POST to ".../cancel?id=<id>" cancels a
+// running statement, see Cancel.
+type Admin struct {
+	db         *DB
+	migrations []*Migration
+
+	mtx     sync.Mutex
+	queries map[string]*AdminQueryStats
+}
+
+// AdminStatus is the document served by Admin.
+type AdminStatus struct {
+	Driver     dbDriver
+	Time       time.Time
+	Health     string       // "ok" or the error of the ping
+	Pool       *sql.DBStats // nil if db was not opened by sqlpro
+	Running    []RunningQuery
+	Queries    []AdminQueryStats // sorted by total duration, slowest first
+	Migrations *AdminMigrations  // nil unless migrations were passed to Admin
+}
+
+// AdminQueryStats aggregates the QueryStats recorded for one statement.
+type AdminQueryStats struct {
+	Query         string
+	Count         int64
+	Rows          int64
+	Bytes         int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// AdminMigrations reports the migration status.
+type AdminMigrations struct {
+	Applied []AppliedMigration
+	Pending []PlannedMigration
+	Error   string `json:",omitempty"`
+}
+
+// AppliedMigration is a migration recorded in MigrationTable.
+type AppliedMigration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Admin returns the admin handler for db. migrations are the
+// migrations of the application, passed to report their status.
+func (db *DB) Admin(migrations ...*Migration) *Admin {
+	return &Admin{
+		db:         db,
+		migrations: migrations,
+		queries:    map[string]*AdminQueryStats{},
+	}
+}
+
+// Record adds stats to the aggregated query stats, assign it to
+// OnQueryStats. Statements are aggregated by their SQL with
+// whitespace collapsed.
+func (a *Admin) Record(stats QueryStats) {
+	query := strings.Join(strings.Fields(stats.Query), " ")
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	qs, ok := a.queries[query]
+	if !ok {
+		if len(a.queries) >= maxAdminQueries {
+			query = "other"
+			qs, ok = a.queries[query]
+		}
+		if !ok {
+			qs = &AdminQueryStats{Query: query}
+			a.queries[query] = qs
+		}
+	}
+	qs.Count++
+	qs.Rows += stats.Rows
+	qs.Bytes += stats.Bytes
+	qs.TotalDuration += stats.Duration
+	if stats.Duration > qs.MaxDuration {
+		qs.MaxDuration = stats.Duration
+	}
+}
+
+// Status returns the current status of the DB, as served by GET.
+func (a *Admin) Status(ctx context.Context) AdminStatus {
+	status := AdminStatus{
+		Driver:  a.db.Driver,
+		Time:    time.Now(),
+		Health:  "ok",
+		Running: a.db.RunningQueries(),
+		Queries: a.queryStats(),
+	}
+
+	if a.db.sqlDB != nil {
+		stats := a.db.sqlDB.Stats()
+		status.Pool = &stats
+		err := a.db.sqlDB.PingContext(ctx)
+		if err != nil {
+			status.Health = err.Error()
+		}
+	}
+
+	if len(a.migrations) > 0 {
+		status.Migrations = a.migrationStatus()
+	}
+
+	return status
+}
+
+// queryStats returns a copy of the aggregated query stats
+func (a *Admin) queryStats() []AdminQueryStats {
+	a.mtx.Lock()
+	queries := make([]AdminQueryStats, 0, len(a.queries))
+	for _, qs := range a.queries {
+		queries = append(queries, *qs)
+	}
+	a.mtx.Unlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].TotalDuration > queries[j].TotalDuration
+	})
+	return queries
+}
+
+// migrationStatus reads the applied migrations, without creating
+// MigrationTable
+func (a *Admin) migrationStatus() *AdminMigrations {
+	ms := &AdminMigrations{
+		Applied: []AppliedMigration{},
+		Pending: []PlannedMigration{},
+	}
+
+	err := a.db.Query(&ms.Applied, "SELECT version, name, applied_at FROM @ ORDER BY version", MigrationTable)
+	if err != nil {
+		ms.Error = err.Error()
+		return ms
+	}
+
+	done := make(map[int64]bool, len(ms.Applied))
+	for _, rec := range ms.Applied {
+		done[rec.Version] = true
+	}
+	for _, m := range a.migrations {
+		if !done[m.Version] {
+			ms.Pending = append(ms.Pending, PlannedMigration{Version: m.Version, Name: m.Name, SQL: m.SQL})
+		}
+	}
+	sort.Slice(ms.Pending, func(i, j int) bool {
+		return ms.Pending[i].Version < ms.Pending[j].Version
+	})
+	return ms
+}
+
+// ServeHTTP implements http.Handler.
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet:
+		a.writeJSON(w, http.StatusOK, a.Status(r.Context()))
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/cancel"):
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			a.writeJSON(w, http.StatusBadRequest, map[string]string{"Error": "Parameter id needs to be an integer."})
+			return
+		}
+		a.writeJSON(w, http.StatusOK, map[string]bool{"Canceled": a.db.Cancel(id)})
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		a.writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"Error": "Method not allowed."})
+	}
+}
+
+func (a *Admin) writeJSON(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
@@ -0,0 +1,75 @@
+package sqlpro
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	migrations := []*Migration{
+		{Version: 1, Name: "create", SQL: "CREATE TABLE admin_test(a TEXT)"},
+		{Version: 2, Name: "drop", SQL: "DROP TABLE admin_test"},
+	}
+	err := tdb.Migrate(context.Background(), migrations[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := tdb.Admin(migrations...)
+	tdb.OnQueryStats = admin.Record
+
+	var n int64
+	for i := 0; i < 2; i++ {
+		err = tdb.Query(&n, "SELECT COUNT(*)\n FROM admin_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var status AdminStatus
+	err = json.Unmarshal(rec.Body.Bytes(), &status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Health != "ok" || status.Pool == nil {
+		t.Errorf("Unexpected health: %s %v", status.Health, status.Pool)
+	}
+	found := false
+	for _, qs := range status.Queries {
+		if qs.Query == "SELECT COUNT(*) FROM admin_test" && qs.Count == 2 && qs.Rows == 2 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Query stats not found: %v", status.Queries)
+	}
+	if status.Migrations == nil || len(status.Migrations.Applied) != 1 ||
+		len(status.Migrations.Pending) != 1 || status.Migrations.Pending[0].Version != 2 {
+		t.Errorf("Unexpected migrations: %+v", status.Migrations)
+	}
+
+	rec = httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest("POST", "/cancel?id=12345", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"Canceled": false`) {
+		t.Errorf("Unexpected cancel response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest("POST", "/cancel?id=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected bad request, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package sqlpro
+
+// afterCommit buffers the hooks and events of a transaction. It is
+// shared by all copies of the DB inside the transaction.
+type afterCommit struct {
+	hooks  []func()
+	events []interface{}
+}
+
+type afterCommitMark struct {
+	hooks  int
+	events int
+}
+
+// mark returns the current position, ac is nil for transactions
+// passed to NewFromTx
+func (ac *afterCommit) mark() afterCommitMark {
+	if ac == nil {
+		return afterCommitMark{}
+	}
+	return afterCommitMark{hooks: len(ac.hooks), events: len(ac.events)}
+}
+
+// reset discards all hooks and events added after m
+func (ac *afterCommit) reset(m afterCommitMark) {
+	if ac == nil {
+		return
+	}
+	ac.hooks = ac.hooks[:m.hooks]
+	ac.events = ac.events[:m.events]
+}
+
+// AfterCommit registers fn to run after the transaction of db has
+// been committed successfully. If the transaction is rolled back, fn
+// is discarded. Hooks registered inside a nested transaction are
+// discarded if the nested transaction is rolled back.
+//
+// Outside of a transaction fn runs immediately, as each statement is
+// committed on its own.
+func (db *DB) AfterCommit(fn func()) {
+	if db.afterCommit == nil {
+		fn()
+		return
+	}
+	db.afterCommit.hooks = append(db.afterCommit.hooks, fn)
+}
+
+// Emit buffers events until the transaction of db is committed. After
+// the commit, all events emitted during the transaction are passed to
+// OnCommit in one call, in the order they were emitted. Use this to
+// publish domain events from write paths, e.g. to a message bus,
+// without publishing changes which were rolled back.
+//
+// Outside of a transaction the events are passed to OnCommit
+// immediately. Without OnCommit, events are dropped.
+func (db *DB) Emit(events ...interface{}) {
+	if db.afterCommit == nil {
+		if db.OnCommit != nil && len(events) > 0 {
+			db.OnCommit(events)
+		}
+		return
+	}
+	db.afterCommit.events = append(db.afterCommit.events, events...)
+}
+
+// runAfterCommit delivers the buffered events and runs the hooks
+func (db *DB) runAfterCommit() {
+	ac := db.afterCommit
+	if db.OnCommit != nil && len(ac.events) > 0 {
+		db.OnCommit(ac.events)
+	}
+	for _, fn := range ac.hooks {
+		fn()
+	}
+	ac.reset(afterCommitMark{})
+}
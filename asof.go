@@ -0,0 +1,97 @@
+package sqlpro
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// HistoryTables configures the history table convention used by
+// AsOf for databases without system versioned tables. Each table has
+// a column with the start of validity of its rows. Its history table
+// holds the previous versions of the rows with the same columns, plus
+// a column with the end of their validity.
+type HistoryTables struct {
+	Suffix    string // appended to the table name, "_history" if empty
+	ValidFrom string // start of validity, "valid_from" if empty
+	ValidTo   string // end of validity in the history table, "valid_to" if empty
+}
+
+func (ht *HistoryTables) suffix() string {
+	if ht.Suffix == "" {
+		return "_history"
+	}
+	return ht.Suffix
+}
+
+func (ht *HistoryTables) validFrom() string {
+	if ht.ValidFrom == "" {
+		return "valid_from"
+	}
+	return ht.ValidFrom
+}
+
+func (ht *HistoryTables) validTo() string {
+	if ht.ValidTo == "" {
+		return "valid_to"
+	}
+	return ht.ValidTo
+}
+
+// AsOf returns a copy which reads the rows as they were at time t in
+// Get, First, Last, FindInBatches and Pluck. For MSSQL and MariaDB
+// this uses "FOR SYSTEM_TIME AS OF", for Oracle "AS OF TIMESTAMP". If
+// History is set, the history table convention is used instead, which
+// is needed for Postgres and SQLite.
+//
+//	err := db.AsOf(lastMonth).Get(&prices, "price", "product_id = ?", id)
+func (db *DB) AsOf(t time.Time) *DB {
+	newDB := *db
+	newDB.asOf = &t
+	return &newDB
+}
+
+// fromTable returns the table to select from, which is table at the
+// time set by AsOf, if set. For the history table convention, all
+// columns of table are selected from table and its history table, so
+// that conditions can use columns not mapped by the target.
+func (db *DB) fromTable(table string) (SQLFragment, error) {
+	if db.asOf == nil {
+		return Fragment(db.EscTable(table)), nil
+	}
+	at := db.storeTime(*db.asOf)
+
+	if db.History == nil {
+		switch db.Driver {
+		case MSSQL:
+			return Fragment(db.EscTable(table)+" FOR SYSTEM_TIME AS OF ?", at), nil
+		case ORACLE:
+			return Fragment(db.EscTable(table)+" AS OF TIMESTAMP ?", at), nil
+		case POSTGRES, SQLITE3:
+			return SQLFragment{}, fmt.Errorf("sqlpro.AsOf: Driver '%s' needs History to be set.", db.Driver)
+		default:
+			return Fragment(db.EscTable(table)+" FOR SYSTEM_TIME AS OF TIMESTAMP ?", at), nil
+		}
+	}
+
+	columns, err := db.tableColumns(table)
+	if err != nil {
+		return SQLFragment{}, xerrors.Errorf("sqlpro.AsOf: Unable to read the columns of %q: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return SQLFragment{}, fmt.Errorf("sqlpro.AsOf: Table %q not found.", table)
+	}
+
+	escCols := make([]string, 0, len(columns))
+	for _, col := range columns {
+		escCols = append(escCols, db.Esc(col.Name))
+	}
+	cols := strings.Join(escCols, ", ")
+	from := db.Esc(db.History.validFrom())
+
+	return Fragment("(SELECT "+cols+" FROM "+db.EscTable(table)+" WHERE "+from+" <= ?"+
+		" UNION ALL SELECT "+cols+" FROM "+db.EscTable(table+db.History.suffix())+" WHERE "+from+" <= ? AND "+db.Esc(db.History.validTo())+" > ?"+
+		") "+db.Esc(unqualified(table)), at, at, at), nil
+}
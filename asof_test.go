@@ -0,0 +1,93 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRowAsOf struct {
+	ID        int64     `db:"id,pk"`
+	Price     int64     `db:"price"`
+	ValidFrom time.Time `db:"valid_from"`
+}
+
+func TestAsOf(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_as_of(id INTEGER PRIMARY KEY, price INTEGER, valid_from DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("CREATE TABLE test_as_of_history(id INTEGER, price INTEGER, valid_from DATETIME, valid_to DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(24 * time.Hour)
+	t2 := t1.Add(24 * time.Hour)
+
+	err = db.Insert("test_as_of", &testRowAsOf{ID: 1, Price: 30, ValidFrom: t2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_as_of_history(id, price, valid_from, valid_to) VALUES (1, 10, ?, ?), (1, 20, ?, ?)", t0, t1, t1, t2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite := *sqliteDB
+	var row testRowAsOf
+	err = sqlite.AsOf(t1).Get(&row, "test_as_of", "id = ?", 1)
+	if err == nil {
+		t.Errorf("Expected error for sqlite without History")
+	}
+
+	sqlite.History = &HistoryTables{}
+	for _, tc := range []struct {
+		at    time.Time
+		price int64
+	}{
+		{t0, 10},
+		{t0.Add(time.Hour), 10},
+		{t1, 20},
+		{t2, 30},
+		{t2.Add(time.Hour), 30},
+	} {
+		err = sqlite.AsOf(tc.at).First(&row, "test_as_of", "id = ?", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Price != tc.price {
+			t.Errorf("Expected price %d at %s, got: %d", tc.price, tc.at, row.Price)
+		}
+	}
+
+	// conditions and columns not mapped by the target
+	var prices []int64
+	err = sqlite.AsOf(t1).Pluck(&prices, "test_as_of", "price", "id = ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(prices, []int64{20}) {
+		t.Errorf("Expected price 20 at t1, got: %v", prices)
+	}
+
+	prices = nil
+	err = sqlite.AsOf(t0.Add(-time.Hour)).Pluck(&prices, "test_as_of", "price", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 0 {
+		t.Errorf("Expected no rows before the first version, got: %v", prices)
+	}
+
+	mssql := *db
+	mssql.Driver = MSSQL
+	from, err := mssql.AsOf(t0).fromTable("test_as_of")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.SQL != `[test_as_of] FOR SYSTEM_TIME AS OF ?` {
+		t.Errorf("Unexpected MSSQL source: %s", from.SQL)
+	}
+}
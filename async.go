@@ -0,0 +1,217 @@
+package sqlpro
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrAsyncClosed is returned by AsyncInsert after CloseAsync.
+var ErrAsyncClosed = errors.New("sqlpro: Async writer is closed.")
+
+// AsyncOptions configures the async writer started by StartAsync.
+type AsyncOptions struct {
+	MaxRows    int           // flush a table after this many rows, default 1000
+	Interval   time.Duration // flush all tables after this interval, default 1s
+	MaxPending int           // AsyncInsert blocks if this many rows wait to be buffered, default 10 * MaxRows
+	// OnError is called for failed flushes. The rows of a failed flush
+	// are dropped. Without OnError, errors are logged.
+	OnError func(table string, err error)
+}
+
+type asyncRow struct {
+	table string
+	row   interface{}
+}
+
+// asyncBuffer holds the rows of one table and row type
+type asyncBuffer struct {
+	table string
+	rows  reflect.Value
+}
+
+type asyncWriter struct {
+	db      *DB
+	opts    AsyncOptions
+	rows    chan asyncRow
+	flush   chan chan error
+	done    chan error
+	mtx     sync.RWMutex
+	closed  bool
+	buffers map[string]*asyncBuffer
+}
+
+// StartAsync returns a copy with an async writer, which buffers the
+// rows passed to AsyncInsert and writes them using InsertBulk, once
+// MaxRows rows of a table are buffered or Interval passed. Use this
+// for high volume tables like counters or telemetry, where the
+// latency of one INSERT per row is not acceptable.
+//
+//	adb := db.StartAsync(AsyncOptions{MaxRows: 500, Interval: time.Second})
+//	defer adb.CloseAsync()
+//	err := adb.AsyncInsert("event", &Event{Name: "login"})
+//
+// Rows are written in the order they were passed, grouped by table.
+// CloseAsync needs to be called on shutdown to write the remaining
+// rows.
+func (db *DB) StartAsync(opts AsyncOptions) *DB {
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = 1000
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 10 * opts.MaxRows
+	}
+
+	newDB := *db
+	w := &asyncWriter{
+		db:      db,
+		opts:    opts,
+		rows:    make(chan asyncRow, opts.MaxPending),
+		flush:   make(chan chan error),
+		done:    make(chan error, 1),
+		buffers: make(map[string]*asyncBuffer, 0),
+	}
+	newDB.async = w
+	go w.run()
+
+	return &newDB
+}
+
+// AsyncInsert buffers row to be inserted into table by the async
+// writer started with StartAsync. AsyncInsert blocks if MaxPending
+// rows are waiting, so that writers cannot outrun the database. row
+// needs to be a struct or a pointer to a struct, which must not be
+// changed after the call.
+func (db *DB) AsyncInsert(table string, row interface{}) error {
+	if db.async == nil {
+		return fmt.Errorf("sqlpro.AsyncInsert: No async writer, use StartAsync.")
+	}
+	if reflect.Indirect(reflect.ValueOf(row)).Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.AsyncInsert: Row needs to be a struct, have: %T", row)
+	}
+
+	// the read lock guards against sending on the closed channel
+	db.async.mtx.RLock()
+	defer db.async.mtx.RUnlock()
+
+	if db.async.closed {
+		return ErrAsyncClosed
+	}
+	db.async.rows <- asyncRow{table: table, row: row}
+	return nil
+}
+
+// FlushAsync writes all rows buffered by AsyncInsert and returns the
+// first error.
+func (db *DB) FlushAsync() error {
+	if db.async == nil {
+		return fmt.Errorf("sqlpro.FlushAsync: No async writer, use StartAsync.")
+	}
+
+	db.async.mtx.RLock()
+	if db.async.closed {
+		db.async.mtx.RUnlock()
+		return ErrAsyncClosed
+	}
+	reply := make(chan error)
+	db.async.flush <- reply
+	db.async.mtx.RUnlock()
+
+	return <-reply
+}
+
+// CloseAsync stops the async writer after writing all buffered rows
+// and returns the first error of the final flush.
+func (db *DB) CloseAsync() error {
+	if db.async == nil {
+		return fmt.Errorf("sqlpro.CloseAsync: No async writer, use StartAsync.")
+	}
+
+	db.async.mtx.Lock()
+	if db.async.closed {
+		db.async.mtx.Unlock()
+		return ErrAsyncClosed
+	}
+	db.async.closed = true
+	close(db.async.rows)
+	db.async.mtx.Unlock()
+
+	return <-db.async.done
+}
+
+func (w *asyncWriter) run() {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r, ok := <-w.rows:
+			if !ok {
+				w.done <- w.flushAll()
+				return
+			}
+			buf := w.add(r)
+			if buf.rows.Len() >= w.opts.MaxRows {
+				w.write(buf)
+			}
+		case reply := <-w.flush:
+			// take the rows sent before the flush
+			for n := len(w.rows); n > 0; n-- {
+				w.add(<-w.rows)
+			}
+			reply <- w.flushAll()
+		case <-ticker.C:
+			w.flushAll()
+		}
+	}
+}
+
+// add appends r to the buffer of its table and type
+func (w *asyncWriter) add(r asyncRow) *asyncBuffer {
+	rowT := reflect.TypeOf(r.row)
+	key := r.table + "\x00" + rowT.String()
+
+	buf, ok := w.buffers[key]
+	if !ok {
+		buf = &asyncBuffer{table: r.table, rows: reflect.MakeSlice(reflect.SliceOf(rowT), 0, w.opts.MaxRows)}
+		w.buffers[key] = buf
+	}
+	buf.rows = reflect.Append(buf.rows, reflect.ValueOf(r.row))
+	return buf
+}
+
+// write inserts the rows of buf and resets it
+func (w *asyncWriter) write(buf *asyncBuffer) error {
+	if buf.rows.Len() == 0 {
+		return nil
+	}
+
+	err := w.db.InsertBulk(buf.table, buf.rows.Interface())
+	buf.rows = reflect.MakeSlice(buf.rows.Type(), 0, w.opts.MaxRows)
+	if err != nil {
+		if w.opts.OnError != nil {
+			w.opts.OnError(buf.table, err)
+		} else {
+			log.Printf("sqlpro.AsyncInsert: Unable to insert into %s: %s", buf.table, err)
+		}
+	}
+	return err
+}
+
+// flushAll writes all buffers and returns the first error
+func (w *asyncWriter) flushAll() error {
+	var first error
+	for _, buf := range w.buffers {
+		err := w.write(buf)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
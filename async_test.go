@@ -0,0 +1,70 @@
+package sqlpro
+
+import (
+	"testing"
+	"time"
+)
+
+type testRowAsync struct {
+	Name  string `db:"name"`
+	Value int64  `db:"value"`
+}
+
+func TestAsyncInsert(t *testing.T) {
+	var count int64
+
+	err := db.Exec("CREATE TABLE test_async(name TEXT, value INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AsyncInsert("test_async", testRowAsync{})
+	if err == nil {
+		t.Errorf("Expected error without async writer")
+	}
+
+	adb := db.StartAsync(AsyncOptions{MaxRows: 10, Interval: time.Hour, MaxPending: 5})
+	for i := 0; i < 25; i++ {
+		row := testRowAsync{Name: "a", Value: int64(i)}
+		if i%2 == 0 {
+			err = adb.AsyncInsert("test_async", &row)
+		} else {
+			err = adb.AsyncInsert("test_async", row)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = adb.FlushAsync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&count, "SELECT count(*) FROM test_async")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 25 {
+		t.Errorf("Expected 25 rows after flush, got: %d", count)
+	}
+
+	err = adb.AsyncInsert("test_async", testRowAsync{Name: "last"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = adb.CloseAsync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&count, "SELECT count(*) FROM test_async")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 26 {
+		t.Errorf("Expected 26 rows after close, got: %d", count)
+	}
+
+	if adb.AsyncInsert("test_async", testRowAsync{}) != ErrAsyncClosed {
+		t.Errorf("Expected ErrAsyncClosed")
+	}
+}
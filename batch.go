@@ -0,0 +1,100 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FindInBatches loads the rows of table matching condition in batches
+// of batchSize rows into target and calls fn after each batch. target
+// needs to be a pointer to a slice of structs with exactly one "pk"
+// field. An empty condition matches all rows. Soft deleted rows are
+// skipped, see Delete.
+//
+//	var batch []User
+//	err := db.FindInBatches(&batch, "user", 1000, func() error {
+//		for _, u := range batch { ... }
+//		return nil
+//	}, "active = ?", true)
+//
+// The batches are paged using the primary key (keyset pagination), so
+// rows inserted or deleted by fn do not shift the following batches.
+// FindInBatches stops and returns the error if fn returns an error.
+func (db *DB) FindInBatches(target interface{}, table string, batchSize int64, fn func() error, condition string, args ...interface{}) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqlpro.FindInBatches: Target needs to be a pointer to a slice, have: %T", target)
+	}
+	if batchSize < 1 {
+		return fmt.Errorf("sqlpro.FindInBatches: batchSize needs to be > 0, got: %d", batchSize)
+	}
+
+	elemT := targetV.Elem().Type().Elem()
+	if elemT.Kind() == reflect.Ptr {
+		elemT = elemT.Elem()
+	}
+	if elemT.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.FindInBatches: Target needs to be a slice of structs, have: %T", target)
+	}
+
+	pk := db.tableStructInfo(elemT, table).onlyPrimaryKey()
+	if pk == nil {
+		return fmt.Errorf("sqlpro.FindInBatches: %s needs exactly one 'pk' field.", elemT)
+	}
+
+	// the primary key is needed for the keyset
+	pkColumn := pk.dbName
+	if pk.mappedFrom != "" {
+		pkColumn = pk.mappedFrom
+	}
+	cols, err := db.selectList(elemT, table, pkColumn)
+	if err != nil {
+		return err
+	}
+
+	condition = db.scopeCondition(elemT, table, condition)
+	var where SQLFragment
+	if condition != "" {
+		where = Fragment("("+condition+")", args...)
+	}
+
+	from, err := db.fromTable(table)
+	if err != nil {
+		return err
+	}
+
+	var last interface{}
+	for {
+		query := Fragment("SELECT " + cols + " FROM").Append(from)
+		keyset := where
+		if last != nil {
+			keyset = JoinFragments(" AND ", where, Fragment(db.Esc(pk.dbName)+" > ?", last))
+		}
+		if !keyset.IsEmpty() {
+			query = query.Append(Fragment("WHERE"), keyset)
+		}
+		query = db.Paginate(query.Append(Fragment("ORDER BY "+db.Esc(pk.dbName))), batchSize, 0)
+
+		targetV.Elem().SetLen(0)
+		err := db.Query(target, query.SQL, query.Args...)
+		if err != nil {
+			return err
+		}
+
+		n := targetV.Elem().Len()
+		if n == 0 {
+			return nil
+		}
+
+		last = reflect.Indirect(targetV.Elem().Index(n - 1)).FieldByIndex(pk.structField.Index).Interface()
+
+		err = fn()
+		if err != nil {
+			return err
+		}
+
+		if int64(n) < batchSize {
+			return nil
+		}
+	}
+}
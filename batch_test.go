@@ -0,0 +1,40 @@
+package sqlpro
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindInBatches(t *testing.T) {
+	var (
+		batch   []*testRowUUID
+		sizes   []int
+		ids     = map[string]bool{}
+		errStop = errors.New("stop")
+	)
+
+	createUUIDTable(t, "test_batch")
+
+	err := db.FindInBatches(&batch, "test_batch", 2, func() error {
+		sizes = append(sizes, len(batch))
+		for _, row := range batch {
+			ids[row.ID] = true
+		}
+		return nil
+	}, "")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 1 || len(ids) != 3 {
+		t.Errorf("Unexpected batches: %v %v", sizes, ids)
+	}
+
+	sizes = nil
+	err = db.FindInBatches(&batch, "test_batch", 1, func() error {
+		sizes = append(sizes, len(batch))
+		return errStop
+	}, "name <> ?", "given")
+	if err != errStop || len(sizes) != 1 {
+		t.Errorf("Expected to stop after first batch, got: %v %v", err, sizes)
+	}
+}
@@ -0,0 +1,89 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var procNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+
+// Call calls the stored procedure proc with args, using the call
+// syntax of the driver: "CALL" for Postgres and MySQL, "EXEC" for
+// MSSQL and an anonymous block for Oracle. proc can be qualified
+// with a schema. OUT parameters are passed as sql.Out and are
+// supported by drivers implementing them (MSSQL and Oracle).
+//
+//	var total int64
+//	err := db.Call(ctx, "billing.close_month", 2020, 12, sql.Out{Dest: &total})
+//
+// SQLite has no stored procedures, Call returns an error.
+func (db *DB) Call(ctx context.Context, proc string, args ...interface{}) error {
+	stmt, err := db.callStatement(proc, false, args)
+	if err != nil {
+		return err
+	}
+	_, err = db.WithContext(ctx).exec(-1, stmt, args...)
+	return err
+}
+
+// CallInto works like Call, but scans the result set returned by proc
+// into target, which can be anything Query accepts. For Postgres proc
+// needs to be a set returning function, which is queried using
+// "SELECT * FROM proc(...)".
+//
+//	var orders []Order
+//	err := db.CallInto(ctx, &orders, "open_orders", customerID)
+func (db *DB) CallInto(ctx context.Context, target interface{}, proc string, args ...interface{}) error {
+	stmt, err := db.callStatement(proc, true, args)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Query(target, stmt, args...)
+}
+
+// callStatement returns the statement calling proc with one
+// placeholder per arg. With rows set, the statement returns the
+// result set of proc.
+func (db *DB) callStatement(proc string, rows bool, args []interface{}) (string, error) {
+	if !procNameRe.MatchString(proc) {
+		return "", fmt.Errorf("sqlpro.Call: Invalid procedure name %q.", proc)
+	}
+
+	params := make([]string, 0, len(args))
+	for _, arg := range args {
+		p := string(db.PlaceholderValue)
+		if db.Driver == MSSQL {
+			switch arg.(type) {
+			case sql.Out, *sql.Out:
+				p += " OUTPUT"
+			}
+		}
+		params = append(params, p)
+	}
+	list := strings.Join(params, ", ")
+
+	switch db.Driver {
+	case SQLITE3:
+		return "", fmt.Errorf("sqlpro.Call: Stored procedures are not supported for driver '%s'.", db.Driver)
+	case MSSQL:
+		if list == "" {
+			return "EXEC " + proc, nil
+		}
+		return "EXEC " + proc + " " + list, nil
+	case ORACLE:
+		if rows {
+			return "SELECT * FROM TABLE(" + proc + "(" + list + "))", nil
+		}
+		return "BEGIN " + proc + "(" + list + "); END;", nil
+	case POSTGRES:
+		if rows {
+			return "SELECT * FROM " + proc + "(" + list + ")", nil
+		}
+		return "CALL " + proc + "(" + list + ")", nil
+	default:
+		return "CALL " + proc + "(" + list + ")", nil
+	}
+}
@@ -0,0 +1,47 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestCallStatement(t *testing.T) {
+	var out int64
+	args := []interface{}{1, "a", sql.Out{Dest: &out}}
+
+	expected := map[dbDriver][2]string{
+		POSTGRES: {"CALL close_month(?, ?, ?)", "SELECT * FROM close_month(?, ?, ?)"},
+		MSSQL:    {"EXEC close_month ?, ?, ? OUTPUT", "EXEC close_month ?, ?, ? OUTPUT"},
+		ORACLE:   {"BEGIN close_month(?, ?, ?); END;", "SELECT * FROM TABLE(close_month(?, ?, ?))"},
+		"mysql":  {"CALL close_month(?, ?, ?)", "CALL close_month(?, ?, ?)"},
+	}
+	for driver, stmts := range expected {
+		d := *db
+		d.Driver = driver
+		for i, rows := range []bool{false, true} {
+			stmt, err := d.callStatement("close_month", rows, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stmt != stmts[i] {
+				t.Errorf("%s: Expected %q, got: %q", driver, stmts[i], stmt)
+			}
+		}
+	}
+
+	_, err := db.callStatement("x; DROP TABLE test", false, nil)
+	if err == nil {
+		t.Errorf("Expected invalid procedure name to be rejected")
+	}
+	stmt, _ := db.callStatement("billing.noop", false, nil)
+	if stmt != "CALL billing.noop()" {
+		t.Errorf("Expected schema qualified call, got: %q", stmt)
+	}
+
+	sqlite := *sqliteDB
+	err = sqlite.Call(context.Background(), "noop")
+	if err == nil {
+		t.Errorf("Expected Call to fail for sqlite")
+	}
+}
@@ -0,0 +1,92 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// checkMutation returns an error for ClickHouse, unless AllowMutations
+// is set. ClickHouse has no row updates, Update, UpdateMap,
+// UpdateByKey and soft deletes run as "ALTER TABLE ... UPDATE"
+// mutation, which rewrites the affected data parts asynchronously.
+func (db *DB) checkMutation(caller string) error {
+	if db.Driver != CLICKHOUSE || db.AllowMutations {
+		return nil
+	}
+	return fmt.Errorf("sqlpro.%s: ClickHouse does not support updating rows, set AllowMutations to run ALTER TABLE ... UPDATE.", caller)
+}
+
+// updatePrefix returns the start of an UPDATE of table up to the
+// assignments, "ALTER TABLE ... UPDATE" for ClickHouse
+func (db *DB) updatePrefix(table string) string {
+	if db.Driver == CLICKHOUSE {
+		return "ALTER TABLE " + db.EscTable(table) + " UPDATE "
+	}
+	return "UPDATE " + db.EscTable(table) + " SET "
+}
+
+// insertBlock inserts the rows using ClickHouse's block insert: all
+// rows are executed on one prepared INSERT inside a transaction and
+// sent as one block on commit. Outside of a transaction, the wrapper
+// needs to be initialized using "Open".
+func (db *DB) insertBlock(table string, keys []string, key_map map[string]*fieldInfo, rows []map[string]interface{}) error {
+	var (
+		err error
+		txn *sql.Tx
+	)
+
+	if db.sqlTx != nil {
+		txn = db.sqlTx
+	} else {
+		if db.sqlDB == nil {
+			return fmt.Errorf("sqlpro.InsertBulk: The wrapper must be created using Open for ClickHouse block inserts.")
+		}
+		txn, err = db.sqlDB.Begin()
+		if err != nil {
+			return sqlError(err, "BEGIN TRANSACTION", []interface{}{})
+		}
+	}
+
+	rollback := func(err error) error {
+		if txn != db.sqlTx {
+			txn.Rollback()
+		}
+		return err
+	}
+
+	cols := make([]string, 0, len(keys))
+	vs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		cols = append(cols, db.Esc(key))
+		vs = append(vs, "?")
+	}
+	insert := "INSERT INTO " + db.EscTable(table) + " (" + strings.Join(cols, ",") + ") VALUES (" + strings.Join(vs, ",") + ")"
+
+	stmt, err := txn.Prepare(insert)
+	if err != nil {
+		return rollback(sqlError(err, insert, []interface{}{}))
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, db.argValue(row[key], key_map[key]))
+		}
+		_, err = stmt.Exec(values...)
+		if err != nil {
+			return rollback(sqlError(err, insert, db.debugArgs(values)))
+		}
+	}
+
+	if txn == db.sqlTx {
+		return nil
+	}
+
+	err = txn.Commit()
+	if err != nil {
+		return sqlError(err, "COMMIT", []interface{}{})
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClickHouse(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.Exec("CREATE TABLE test_clickhouse(id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := *tdb
+	ch.Driver = CLICKHOUSE
+
+	rows := []testRowReplace{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	err = ch.InsertBulk("test_clickhouse", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	err = ch.Query(&count, "SELECT COUNT(*) FROM test_clickhouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	err = ch.Update("test_clickhouse", &rows[0])
+	if err == nil || !strings.Contains(err.Error(), "AllowMutations") {
+		t.Errorf("Expected error for Update without AllowMutations, got: %v", err)
+	}
+
+	// all writes updating rows need AllowMutations
+	soft := testRowSoftDelete{ID: 1}
+	for name, write := range map[string]func() error{
+		"UpdateMap": func() error {
+			_, err := ch.UpdateMap("test_clickhouse", map[string]interface{}{"name": "x"}, "id = ?", 1)
+			return err
+		},
+		"UpdateByKey": func() error {
+			_, err := ch.UpdateByKey("test_clickhouse", rows, "id")
+			return err
+		},
+		"Delete": func() error {
+			return ch.Delete("test_clickhouse", &soft)
+		},
+		"DeleteByIDs": func() error {
+			_, err := ch.DeleteByIDs("test_clickhouse", &soft, []int64{1})
+			return err
+		},
+	} {
+		err = write()
+		if err == nil || !strings.Contains(err.Error(), "AllowMutations") {
+			t.Errorf("Expected error for %s without AllowMutations, got: %v", name, err)
+		}
+	}
+
+	ch.AllowMutations = true
+	update, _, err := ch.updateClauseFromRow("test_clickhouse", rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update != `ALTER TABLE "test_clickhouse" UPDATE "name"=? WHERE "id"=?` {
+		t.Errorf("Unexpected update: %s", update)
+	}
+
+	rec := &execRecorder{}
+	chRec := ch
+	chRec.DB = rec
+	chRec.sqlDB = nil // no transactions, all statements go to rec
+	_, err = chRec.UpdateMap("test_clickhouse", map[string]interface{}{"name": "x"}, "id = ?", 1)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = chRec.UpdateByKey("test_clickhouse", rows[:1], "id")
+	if err != nil {
+		t.Error(err)
+	}
+	err = chRec.Delete("test_clickhouse", &soft)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = chRec.DeleteByIDs("test_clickhouse", &soft, []int64{1})
+	if err != nil {
+		t.Error(err)
+	}
+	for _, stmt := range rec.statements {
+		if !strings.HasPrefix(stmt, `ALTER TABLE "test_clickhouse" UPDATE `) {
+			t.Errorf("Expected ALTER TABLE ... UPDATE, got: %s", stmt)
+		}
+	}
+	if len(rec.statements) != 4 {
+		t.Errorf("Expected 4 statements, got: %q", rec.statements)
+	}
+
+	plain := *db
+	plain.Driver = CLICKHOUSE
+	if plain.InsertBulk("test_clickhouse", rows) == nil {
+		t.Errorf("Expected error for block insert without Open")
+	}
+}
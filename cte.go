@@ -0,0 +1,69 @@
+package sqlpro
+
+import (
+	"strings"
+)
+
+// CTE is a named query for a "WITH" clause.
+type CTE struct {
+	Name    string
+	Columns []string // optional column names
+	Query   SQLFragment
+	// Recursive marks a query referencing its own name. For
+	// Postgres, SQLite and MySQL "WITH RECURSIVE" is used then.
+	Recursive bool
+}
+
+// With returns the "WITH" clause for the given named queries, to be
+// prepended to a query using Append. Later queries can reference
+// earlier ones.
+//
+//	active := CTE{Name: "active_user", Query: Fragment("SELECT * FROM user WHERE active = ?", true)}
+//	query := db.With(active).Append(Fragment("SELECT * FROM active_user WHERE org_id = ?", orgID))
+func (db *DB) With(ctes ...CTE) SQLFragment {
+	if len(ctes) == 0 {
+		return SQLFragment{}
+	}
+
+	keyword := "WITH "
+	switch db.Driver {
+	case MSSQL, ORACLE:
+		// recursion is implicit
+	default:
+		for _, cte := range ctes {
+			if cte.Recursive {
+				keyword = "WITH RECURSIVE "
+				break
+			}
+		}
+	}
+
+	frags := make([]SQLFragment, 0, len(ctes))
+	for _, cte := range ctes {
+		name := db.Esc(cte.Name)
+		if len(cte.Columns) > 0 {
+			cols := make([]string, 0, len(cte.Columns))
+			for _, col := range cte.Columns {
+				cols = append(cols, db.Esc(col))
+			}
+			name += " (" + strings.Join(cols, ", ") + ")"
+		}
+		frags = append(frags, JoinFragments("", Fragment(name+" AS ("), cte.Query, Fragment(")")))
+	}
+
+	return JoinFragments("", Fragment(keyword), JoinFragments(", ", frags...))
+}
+
+// QueryWith runs query with the "WITH" clause of ctes prepended and
+// scans the result into target like Query.
+//
+//	err := db.QueryWith(&ids, []CTE{{
+//		Name:      "tree",
+//		Columns:   []string{"id"},
+//		Query:     Fragment("SELECT id FROM node WHERE id = ? UNION ALL SELECT n.id FROM node n JOIN tree t ON n.parent_id = t.id", rootID),
+//		Recursive: true,
+//	}}, "SELECT id FROM tree")
+func (db *DB) QueryWith(target interface{}, ctes []CTE, query string, args ...interface{}) error {
+	q := db.With(ctes...).Append(Fragment(query, args...))
+	return db.Query(target, q.SQL, q.Args...)
+}
@@ -0,0 +1,54 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryWith(t *testing.T) {
+	var (
+		ids   []int64
+		names []string
+	)
+
+	for _, stmt := range []string{
+		"CREATE TABLE test_tree(id INTEGER PRIMARY KEY, parent_id INTEGER, name TEXT)",
+		"INSERT INTO test_tree VALUES(1, NULL, 'root'), (2, 1, 'a'), (3, 2, 'b'), (4, NULL, 'other'), (5, 4, 'c')",
+	} {
+		err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := db.QueryWith(&ids, []CTE{{
+		Name:      "tree",
+		Columns:   []string{"id"},
+		Query:     Fragment("SELECT id FROM test_tree WHERE id = ? UNION ALL SELECT n.id FROM test_tree n JOIN tree t ON n.parent_id = t.id", 1),
+		Recursive: true,
+	}}, "SELECT id FROM tree WHERE id > ? ORDER BY id", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int64{2, 3}) {
+		t.Errorf("Expected [2 3], got: %v", ids)
+	}
+
+	query := db.With(
+		CTE{Name: "roots", Query: Fragment("SELECT * FROM test_tree WHERE parent_id IS NULL AND name <> ?", "other")},
+		CTE{Name: "children", Query: Fragment("SELECT c.* FROM test_tree c JOIN roots r ON c.parent_id = r.id")},
+	).Append(Fragment("SELECT name FROM children"))
+
+	expSql := `WITH "roots" AS (SELECT * FROM test_tree WHERE parent_id IS NULL AND name <> ?), "children" AS (SELECT c.* FROM test_tree c JOIN roots r ON c.parent_id = r.id) SELECT name FROM children`
+	if query.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, query.SQL)
+	}
+
+	err = db.Query(&names, query.SQL, query.Args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"a"}) {
+		t.Errorf("Expected [a], got: %v", names)
+	}
+}
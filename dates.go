@@ -0,0 +1,63 @@
+package sqlpro
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DateAdd returns an SQL expression adding d to the timestamp column,
+// using the interval arithmetic of the db's driver. Use a negative d to
+// subtract. column is escaped using Esc.
+//
+//	query := "SELECT * FROM job WHERE " + db.DateAdd("started", time.Hour) + " < ?"
+func (db *DB) DateAdd(column string, d time.Duration) string {
+	col := db.Esc(column)
+	secs := strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+
+	switch db.Driver {
+	case POSTGRES:
+		return "(" + col + " + INTERVAL '" + secs + " seconds')"
+	case SQLITE3:
+		if d%time.Second != 0 {
+			return "strftime('%Y-%m-%d %H:%M:%f', " + col + ", '" + fmt.Sprintf("%+g", d.Seconds()) + " seconds')"
+		}
+		return "datetime(" + col + ", '" + fmt.Sprintf("%+d", int64(d/time.Second)) + " seconds')"
+	case MSSQL:
+		if d%time.Second != 0 {
+			return fmt.Sprintf("DATEADD(millisecond, %d, %s)", d.Milliseconds(), col)
+		}
+		return fmt.Sprintf("DATEADD(second, %d, %s)", int64(d/time.Second), col)
+	case ORACLE:
+		return "(" + col + " + NUMTODSINTERVAL(" + secs + ", 'SECOND'))"
+	default:
+		if d%time.Second != 0 {
+			return fmt.Sprintf("DATE_ADD(%s, INTERVAL %d MICROSECOND)", col, d.Microseconds())
+		}
+		return fmt.Sprintf("DATE_ADD(%s, INTERVAL %d SECOND)", col, int64(d/time.Second))
+	}
+}
+
+// DateDiff returns an SQL expression for the number of seconds from
+// the timestamp column start to the timestamp column end, using the
+// date functions of the db's driver. The result is negative if end
+// is before start. Depending on the driver, the result can have a
+// fractional part. Both columns are escaped using Esc.
+//
+//	query := "SELECT id, " + db.DateDiff("started", "finished") + " AS runtime FROM job"
+func (db *DB) DateDiff(start, end string) string {
+	s, e := db.Esc(start), db.Esc(end)
+
+	switch db.Driver {
+	case POSTGRES:
+		return "EXTRACT(EPOCH FROM (" + e + " - " + s + "))"
+	case SQLITE3:
+		return "((julianday(" + e + ") - julianday(" + s + ")) * 86400)"
+	case MSSQL:
+		return "DATEDIFF_BIG(second, " + s + ", " + e + ")"
+	case ORACLE:
+		return "((CAST(" + e + " AS DATE) - CAST(" + s + " AS DATE)) * 86400)"
+	default:
+		return "TIMESTAMPDIFF(SECOND, " + s + ", " + e + ")"
+	}
+}
@@ -0,0 +1,111 @@
+package sqlpro
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRowTimes struct {
+	ID   int64      `db:"id,pk,omitempty"`
+	At   time.Time  `db:"at"`
+	AtP  *time.Time `db:"at_p"`
+	AtNT NullTime   `db:"at_nt"`
+}
+
+func TestTimeNormalization(t *testing.T) {
+	var (
+		row testRowTimes
+		raw string
+	)
+
+	err := db.Exec("CREATE TABLE test_times(id INTEGER PRIMARY KEY AUTOINCREMENT, at DATETIME, at_p DATETIME, at_nt DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	berlin := time.FixedZone("Berlin", 2*60*60)
+	tokyo := time.FixedZone("Tokyo", 9*60*60)
+	at := time.Date(2020, 5, 1, 12, 0, 0, 0, berlin)
+
+	db2 := *db
+	db2.StoreTimesUTC = true
+	db2.TimeLocation = tokyo
+
+	err = db2.Insert("test_times", &testRowTimes{At: at, AtP: &at, AtNT: NullTime{Time: &at, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&raw, "SELECT at FROM test_times")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, "2020-05-01T10:00:00") && !strings.HasPrefix(raw, "2020-05-01 10:00:00") {
+		t.Errorf("Expected time to be stored in UTC, got: %s", raw)
+	}
+
+	err = db2.Query(&row, "SELECT * FROM test_times")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tm := range []time.Time{row.At, *row.AtP, *row.AtNT.Time} {
+		if tm.Location() != tokyo || !tm.Equal(at) {
+			t.Errorf("Expected %s in Tokyo, got: %s", at, tm)
+		}
+	}
+}
+
+func TestDateAdd(t *testing.T) {
+	var (
+		ts   string
+		diff float64
+	)
+
+	sqlite := *sqliteDB
+
+	err := sqlite.Exec("CREATE TABLE test_dates(a TEXT, b TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sqlite.Exec("INSERT INTO test_dates VALUES('2020-01-31 23:00:00', '2020-02-01 01:30:00')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sqlite.Query(&ts, "SELECT "+sqlite.DateAdd("a", 90*time.Minute)+" FROM test_dates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "2020-02-01 00:30:00" {
+		t.Errorf("Unexpected DateAdd result: %s", ts)
+	}
+
+	err = sqlite.Query(&ts, "SELECT "+sqlite.DateAdd("a", -1500*time.Millisecond)+" FROM test_dates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts != "2020-01-31 22:59:58.500" {
+		t.Errorf("Unexpected DateAdd result: %s", ts)
+	}
+
+	err = sqlite.Query(&diff, "SELECT "+sqlite.DateDiff("a", "b")+" FROM test_dates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Round(diff) != 9000 {
+		t.Errorf("Expected diff of 9000 seconds, got: %f", diff)
+	}
+
+	pg := *db
+	pg.Driver = POSTGRES
+	if expr := pg.DateAdd("a", time.Hour); expr != `("a" + INTERVAL '3600 seconds')` {
+		t.Errorf("Unexpected postgres expression: %s", expr)
+	}
+	ms := *db
+	ms.Driver = MSSQL
+	if expr := ms.DateAdd("a", -time.Second); expr != `DATEADD(second, -1, [a])` {
+		t.Errorf("Unexpected MSSQL expression: %s", expr)
+	}
+}
@@ -0,0 +1,30 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+	"strings"
+)
+
+// Decimal is an arbitrary-precision decimal number, like
+// github.com/shopspring/decimal. Decimal fields are written using
+// their Value and read using their Scan method, so NUMERIC values
+// are not converted through float64. The pointer to the type
+// needs to implement sql.Scanner.
+//
+// Empty decimals are detected using IsZero, so that "omitempty"
+// works for them.
+type Decimal interface {
+	driver.Valuer
+	String() string
+	IsZero() bool
+}
+
+// decimalLiteral returns the decimal as numeric SQL literal. If
+// String returns anything else than a number, it is quoted.
+func (db *DB) decimalLiteral(d Decimal) string {
+	s := d.String()
+	if s == "" || strings.Trim(s, "0123456789.-+eE") != "" {
+		return db.EscValue(s)
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testDecimal struct {
+	s string
+}
+
+func (d testDecimal) Value() (driver.Value, error) {
+	if d.s == "" {
+		return "0", nil
+	}
+	return d.s, nil
+}
+
+func (d *testDecimal) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		d.s = v
+	case []byte:
+		d.s = string(v)
+	default:
+		return fmt.Errorf("Unable to scan %T into testDecimal", value)
+	}
+	return nil
+}
+
+func (d testDecimal) String() string {
+	if d.s == "" {
+		return "0"
+	}
+	return d.s
+}
+
+func (d testDecimal) IsZero() bool {
+	return strings.Trim(d.s, "0.") == ""
+}
+
+type testRowDecimal struct {
+	ID     int64       `db:"id,pk,omitempty"`
+	Amount testDecimal `db:"amount,omitempty"`
+	Note   string      `db:"note"`
+}
+
+func TestDecimal(t *testing.T) {
+	var rows []testRowDecimal
+
+	var _ Decimal = testDecimal{}
+
+	err := db.Exec("CREATE TABLE test_decimal(id INTEGER PRIMARY KEY AUTOINCREMENT, amount TEXT DEFAULT 'default', note TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exact := "12345678901234567890.123456789"
+	err = db.Insert("test_decimal", []testRowDecimal{{Amount: testDecimal{exact}}, {Amount: testDecimal{"0.00"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_decimal ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Amount.s != exact || rows[1].Amount.s != "default" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	if db.EscValueForInsert(testDecimal{exact}, nil) != exact {
+		t.Errorf("Expected decimal literal %s, got: %s", exact, db.EscValueForInsert(testDecimal{exact}, nil))
+	}
+	if db.EscValueForInsert(testDecimal{"1'; DROP"}, nil) != `'1''; DROP'` {
+		t.Errorf("Expected non numeric decimal to be quoted.")
+	}
+}
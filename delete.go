@@ -0,0 +1,149 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// DeleteByIDs deletes the rows of table whose primary key is in ids.
+// The primary key column is taken from model, a struct or pointer
+// to struct with exactly one "pk" field. ids needs to be a slice.
+// The ids are deleted in chunks of MaxPlaceholder. DeleteByIDs returns
+// the total number of deleted rows. If model has a "softdelete" field,
+// its column is set to the current time instead, see Delete.
+//
+// n, err := db.DeleteByIDs("user", &User{}, []int64{1, 2, 3})
+func (db *DB) DeleteByIDs(table string, model interface{}, ids interface{}) (int64, error) {
+	var (
+		total int64
+	)
+
+	modelT := reflect.TypeOf(model)
+	for modelT.Kind() == reflect.Ptr {
+		modelT = modelT.Elem()
+	}
+	if modelT.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("sqlpro.DeleteByIDs: Model needs to be a struct, have: %s", modelT)
+	}
+
+	pk := db.tableStructInfo(modelT, table).onlyPrimaryKey()
+	if pk == nil {
+		return 0, fmt.Errorf("sqlpro.DeleteByIDs: Model needs a struct with exactly one 'pk' field.")
+	}
+
+	idsV := reflect.ValueOf(ids)
+	if idsV.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("sqlpro.DeleteByIDs: ids need to be a slice, have: %T", ids)
+	}
+
+	chunkSize := db.MaxPlaceholder
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	for start := 0; start < idsV.Len(); start += chunkSize {
+		end := start + chunkSize
+		if end > idsV.Len() {
+			end = idsV.Len()
+		}
+		var n int64
+
+		where := " WHERE @ IN ?"
+		args := []interface{}{pk.dbName, idsV.Slice(start, end).Interface()}
+		sd := db.softDelete(modelT, table)
+		if sd != nil {
+			err := db.checkMutation("DeleteByIDs")
+			if err != nil {
+				return total, err
+			}
+			where += " AND @ IS NULL"
+			args = append(args, sd.dbName)
+		}
+
+		err := db.withHistoryWhere(table, where, args, func(db *DB) (err error) {
+			if sd != nil {
+				n, err = db.exec(-1, db.updatePrefix(table)+"@ = ?"+where, append([]interface{}{sd.dbName, db.storeTime(db.now())}, args...)...)
+			} else {
+				n, err = db.exec(-1, "DELETE FROM "+db.EscTable(table)+where, args...)
+			}
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// Delete deletes the given struct or slice of structs.
+// The WHERE clause is put together from the "pk" columns.
+// If not all "pk" columns have non empty values, Delete returns
+// an error.
+//
+// If the struct has a field tagged "softdelete", e.g.
+//
+//	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+//
+// the column is set to the current time instead of removing the row.
+// Get, First, Last, GetOrCreate and FindInBatches skip soft deleted
+// rows. Use Unscoped to remove the row or to load soft deleted rows.
+//
+// Structs implementing BeforeDeleter or AfterDeleter have their hooks
+// called before and after each row is deleted.
+func (db *DB) Delete(table string, data interface{}) error {
+	rv, structMode, err := checkData(data)
+	if err != nil {
+		return err
+	}
+
+	if structMode {
+		return db.deleteRow(table, rv)
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		err = db.deleteRow(table, reflect.Indirect(rv.Index(i)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) deleteRow(table string, row reflect.Value) error {
+	err := db.runHook(row, beforeDelete)
+	if err != nil {
+		return err
+	}
+
+	err = db.withHistory(table, row, func(db *DB) error {
+		if fi := db.softDelete(row.Type(), table); fi != nil {
+			return db.softDeleteRow(table, row, fi)
+		}
+		return db.removeRow(table, row)
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.runHook(row, afterDelete)
+}
+
+func (db *DB) removeRow(table string, row reflect.Value) error {
+	values, info, err := db.tableValuesFromStruct(table, row.Interface())
+	if err != nil {
+		return err
+	}
+
+	where, args, err := db.pkWhere(values, info)
+	if err != nil {
+		return xerrors.Errorf("Unable to build DELETE clause: %w", err)
+	}
+
+	_, err = db.exec(1, "DELETE FROM "+db.EscTable(table)+where, args...)
+	return err
+}
@@ -0,0 +1,40 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestDeleteByIDs(t *testing.T) {
+	createTestTable(t, "test_delete")
+
+	rows := make([]*testRow, 0)
+	for i := 0; i < 5; i++ {
+		rows = append(rows, &testRow{B: "delete me"})
+	}
+	err := db.Insert("test_delete", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]int64, 0)
+	for _, row := range rows {
+		ids = append(ids, row.A)
+	}
+	ids = append(ids, -1)
+
+	db2 := *db
+	db2.MaxPlaceholder = 2
+
+	n, err := db2.DeleteByIDs("test_delete", &testRow{}, ids)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 deleted rows, got: %d", n)
+	}
+
+	_, err = db.DeleteByIDs("test_delete", &testRowPtr{}, ids)
+	if err == nil {
+		t.Errorf("Expected error for model without pk.")
+	}
+}
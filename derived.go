@@ -0,0 +1,212 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// SyncResult reports the rows changed by SyncDerivedTable.
+type SyncResult struct {
+	Inserted int64
+	Updated  int64
+	Deleted  int64
+}
+
+// SyncDerivedTable makes targetTable mirror the rows of selectSQL.
+// Rows are matched by keyCols, rows missing in targetTable are
+// inserted, changed rows updated and rows no longer returned by
+// selectSQL deleted. Unchanged rows are not touched, so that
+// SyncDerivedTable can be used to maintain denormalized read models.
+//
+//	res, err := db.SyncDerivedTable(ctx, "user_stats",
+//		"SELECT user_id, count(*) AS posts FROM post GROUP BY user_id", "user_id")
+//
+// The columns of selectSQL need to exist in targetTable. If not
+// already in a transaction and the wrapper was initialized using
+// "Open", the changes are done in one transaction.
+func (db *DB) SyncDerivedTable(ctx context.Context, targetTable string, selectSQL string, keyCols ...string) (SyncResult, error) {
+	var (
+		res SyncResult
+		err error
+	)
+
+	if len(keyCols) == 0 {
+		return res, fmt.Errorf("sqlpro.SyncDerivedTable: Need at least one key column.")
+	}
+
+	cols, sourceRows, err := db.queryMaps(selectSQL)
+	if err != nil {
+		return res, xerrors.Errorf("sqlpro.SyncDerivedTable: Unable to query source: %w", err)
+	}
+
+	colSet := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		colSet[col] = true
+	}
+	for _, key := range keyCols {
+		if !colSet[key] {
+			return res, fmt.Errorf("sqlpro.SyncDerivedTable: Key column %q not returned by the select.", key)
+		}
+	}
+
+	res, err = db.syncRows(ctx, targetTable, cols, sourceRows, keyCols, true)
+	if err != nil {
+		return res, xerrors.Errorf("sqlpro.SyncDerivedTable: %w", err)
+	}
+	return res, nil
+}
+
+// syncRows makes table contain sourceRows, matched by keyCols. Only
+// the columns in cols are compared and written, columns missing in
+// a source row are left alone. If deleteMissing is set, rows of table
+// not in sourceRows are deleted.
+func (db *DB) syncRows(ctx context.Context, table string, cols []string, sourceRows []map[string]interface{}, keyCols []string, deleteMissing bool) (SyncResult, error) {
+	var (
+		res SyncResult
+		err error
+	)
+
+	escCols := make([]string, 0, len(cols))
+	for _, col := range cols {
+		escCols = append(escCols, db.Esc(col))
+	}
+	_, targetRows, err := db.queryMaps("SELECT " + strings.Join(escCols, ", ") + " FROM " + db.EscTable(table))
+	if err != nil {
+		return res, xerrors.Errorf("Unable to query target: %w", err)
+	}
+
+	target := make(map[string]map[string]interface{}, len(targetRows))
+	for _, row := range targetRows {
+		target[syncKey(row, keyCols)] = row
+	}
+
+	execDB := db
+	var tx *Tx
+	if db.sqlTx == nil && db.sqlDB != nil {
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			return res, err
+		}
+		execDB = tx.DB
+	}
+
+	err = func() error {
+		for _, row := range sourceRows {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+
+			rowCols := make([]string, 0, len(cols))
+			for _, col := range cols {
+				if _, ok := row[col]; ok {
+					rowCols = append(rowCols, col)
+				}
+			}
+
+			key := syncKey(row, keyCols)
+			existing, ok := target[key]
+			if !ok {
+				err = execDB.insertRowMap(table, rowCols, row)
+				if err != nil {
+					return err
+				}
+				res.Inserted++
+				continue
+			}
+			delete(target, key)
+
+			changed := make(map[string]interface{}, 0)
+			for _, col := range rowCols {
+				if syncValue(existing[col]) != syncValue(row[col]) {
+					changed[col] = row[col]
+				}
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			where := execDB.syncWhere(row, keyCols)
+			_, err = execDB.UpdateMap(table, changed, where.SQL, where.Args...)
+			if err != nil {
+				return err
+			}
+			res.Updated++
+		}
+
+		if !deleteMissing {
+			return nil
+		}
+
+		// rows not in the source
+		for _, row := range target {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+			where := execDB.syncWhere(row, keyCols)
+			err = execDB.withHistoryWhere(table, " WHERE "+where.SQL, where.Args, func(db *DB) error {
+				n, err := db.exec(-1, "DELETE FROM "+db.EscTable(table)+" WHERE "+where.SQL, where.Args...)
+				res.Deleted += n
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	if err != nil {
+		if tx != nil {
+			tx.Rollback()
+		}
+		return SyncResult{}, err
+	}
+
+	if tx != nil {
+		err = tx.Commit()
+		if err != nil {
+			return SyncResult{}, err
+		}
+	}
+
+	return res, nil
+}
+
+// syncWhere returns the condition matching the key columns of row
+func (db *DB) syncWhere(row map[string]interface{}, keyCols []string) SQLFragment {
+	frags := make([]SQLFragment, 0, len(keyCols))
+	for _, key := range keyCols {
+		if row[key] == nil {
+			frags = append(frags, Fragment(db.Esc(key)+" IS NULL"))
+			continue
+		}
+		frags = append(frags, Fragment(db.Esc(key)+" = ?", row[key]))
+	}
+	return JoinFragments(" AND ", frags...)
+}
+
+// syncKey returns the key columns of row as string
+func syncKey(row map[string]interface{}, keyCols []string) string {
+	parts := make([]string, 0, len(keyCols))
+	for _, key := range keyCols {
+		parts = append(parts, syncValue(row[key]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// syncValue returns a comparable representation of a scanned value,
+// drivers return text either as string or []byte
+func syncValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,61 @@
+package sqlpro
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSyncDerivedTable(t *testing.T) {
+	var total int64
+
+	err := db.Exec("CREATE TABLE test_posts(id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("CREATE TABLE test_post_stats(user_id INTEGER PRIMARY KEY, posts INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Exec("INSERT INTO test_posts(user_id) VALUES (1), (1), (2), (3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selectSQL := "SELECT user_id, count(*) AS posts FROM test_posts GROUP BY user_id"
+
+	res, err := db.SyncDerivedTable(context.Background(), "test_post_stats", selectSQL, "user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (SyncResult{Inserted: 3}) {
+		t.Errorf("Unexpected first sync: %+v", res)
+	}
+
+	err = db.Exec("DELETE FROM test_posts WHERE user_id = 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_posts(user_id) VALUES (2), (4)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = db.SyncDerivedTable(context.Background(), "test_post_stats", selectSQL, "user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (SyncResult{Inserted: 1, Updated: 1, Deleted: 1}) {
+		t.Errorf("Unexpected second sync: %+v", res)
+	}
+
+	err = db.Query(&total, "SELECT sum(posts) FROM test_post_stats")
+	if err != nil || total != 5 {
+		t.Errorf("Expected 5 posts, got: %d %v", total, err)
+	}
+
+	_, err = db.SyncDerivedTable(context.Background(), "test_post_stats", selectSQL, "unknown")
+	if err == nil {
+		t.Errorf("Expected error for unknown key column.")
+	}
+}
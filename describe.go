@@ -0,0 +1,209 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// StructPlan describes how sqlpro maps a struct to columns, as
+// returned by DescribePlan.
+type StructPlan struct {
+	Type    string
+	Table   string         // the table of a TableNamer, used for MapStruct mappings
+	Fields  []FieldPlan    // the mapped fields, in struct order
+	Skipped []SkippedField // the fields which are not mapped, in struct order
+}
+
+// FieldPlan describes a mapped field.
+type FieldPlan struct {
+	Field       string // the Go field, with the path for embedded and nested structs
+	Column      string
+	Type        string
+	Flags       []string // the parsed tag options, e.g. "pk", "omitempty", "seq=users_seq"
+	EmptyValue  string
+	MappedFrom  string   `json:",omitempty"` // the column of the "db" tag, if renamed by MapStruct
+	Normalizers []string `json:",omitempty"`
+	Enum        []string `json:",omitempty"`
+}
+
+// SkippedField is a field which is not mapped to a column.
+type SkippedField struct {
+	Field  string
+	Reason string
+}
+
+// DescribePlan returns how model, a struct or pointer to a struct,
+// is mapped to columns: the column, type, flags and empty value of
+// each field, and the reason for each field which is skipped. Use
+// it to debug why a field is ignored, treated as primary key or
+// written as NULL. Print the plan for a readable table:
+//
+//	plan, err := db.DescribePlan(&User{})
+//	fmt.Println(plan)
+func (db *DB) DescribePlan(model interface{}) (*StructPlan, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlpro.DescribePlan: Model needs to be a struct, have: %T", model)
+	}
+
+	plan := &StructPlan{
+		Type:    t.String(),
+		Fields:  []FieldPlan{},
+		Skipped: []SkippedField{},
+	}
+
+	info := db.structInfo(t)
+	if tn, ok := reflect.New(t).Interface().(TableNamer); ok {
+		plan.Table = tn.TableName()
+		info = db.tableStructInfo(t, plan.Table)
+	}
+
+	fields := make([]*fieldInfo, 0, len(info))
+	mapped := make(map[string]bool, len(info))
+	for _, fi := range info {
+		fields = append(fields, fi)
+		mapped[fmt.Sprint(fi.structField.Index)] = true
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].before(fields[j])
+	})
+
+	for _, fi := range fields {
+		plan.Fields = append(plan.Fields, FieldPlan{
+			Field:       fieldPath(t, fi.structField.Index),
+			Column:      fi.dbName,
+			Type:        fi.structField.Type.String(),
+			Flags:       fi.flags(),
+			EmptyValue:  fi.emptyValue,
+			MappedFrom:  fi.mappedFrom,
+			Normalizers: fi.normalizers,
+			Enum:        fi.enum,
+		})
+	}
+
+	db.skippedFields(plan, t, t, nil, mapped)
+
+	return plan, nil
+}
+
+// skippedFields adds the fields of t which are not mapped to plan,
+// index is the path of t in model
+func (db *DB) skippedFields(plan *StructPlan, model, t reflect.Type, index []int, mapped map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		idx := append(append([]int{}, index...), i)
+
+		dbTag, tagged := field.Tag.Lookup("db")
+		if field.Type.Kind() == reflect.Struct &&
+			((!tagged && field.Anonymous) || (!strings.Contains(dbTag, ",") && (strings.HasSuffix(dbTag, "_") || strings.HasSuffix(dbTag, ".")))) {
+			// the fields of embedded and prefixed structs are merged
+			db.skippedFields(plan, model, field.Type, idx, mapped)
+			continue
+		}
+		if mapped[fmt.Sprint(idx)] {
+			continue
+		}
+
+		var reason string
+		switch {
+		case strings.Split(dbTag, ",")[0] == "-":
+			reason = `tagged "-"`
+		case field.PkgPath != "":
+			reason = "unexported"
+		case dbTag == "" && db.MapUntaggedFields:
+			reason = "no db tag and type is not mapped automatically"
+		case dbTag == "":
+			reason = "no db tag"
+		default:
+			reason = "column is mapped by another field"
+		}
+		plan.Skipped = append(plan.Skipped, SkippedField{
+			Field:  fieldPath(model, idx),
+			Reason: reason,
+		})
+	}
+}
+
+// fieldPath returns the Go path of the field at index
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, 0, len(index))
+	for _, i := range index {
+		field := t.Field(i)
+		names = append(names, field.Name)
+		t = field.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// flags returns the tag options of fi, in a fixed order
+func (fi *fieldInfo) flags() []string {
+	flags := []string{}
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{fi.primaryKey, "pk"},
+		{fi.omitEmpty, "omitempty"},
+		{fi.null, "null"},
+		{fi.notNull, "notnull"},
+		{fi.ptr, "ptr"},
+		{fi.isJson, "json"},
+		{fi.readOnly, "readonly"},
+		{fi.uuid, "uuid"},
+		{fi.lazy, "lazy"},
+		{fi.isHstore, "hstore"},
+		{fi.unique, "unique"},
+		{fi.softDelete, "softdelete"},
+		{fi.secret, "secret"},
+		{fi.autoCreate, "autocreate"},
+		{fi.autoUpdate, "autoupdate"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	if fi.sequence != "" {
+		flags = append(flags, "seq="+fi.sequence)
+	}
+	return flags
+}
+
+// String returns the plan as a table.
+func (plan *StructPlan) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(plan.Type)
+	if plan.Table != "" {
+		sb.WriteString(" (table " + plan.Table + ")")
+	}
+	sb.WriteString("\n")
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tCOLUMN\tTYPE\tFLAGS\tEMPTY")
+	for _, f := range plan.Fields {
+		column := f.Column
+		if f.MappedFrom != "" {
+			column += " (from " + f.MappedFrom + ")"
+		}
+		flags := append([]string{}, f.Flags...)
+		if len(f.Normalizers) > 0 {
+			flags = append(flags, "normalize="+strings.Join(f.Normalizers, "|"))
+		}
+		if len(f.Enum) > 0 {
+			flags = append(flags, "enum="+strings.Join(f.Enum, "|"))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Field, column, f.Type, strings.Join(flags, ","), f.EmptyValue)
+	}
+	w.Flush()
+
+	for _, s := range plan.Skipped {
+		fmt.Fprintf(&sb, "skipped %s: %s\n", s.Field, s.Reason)
+	}
+	return sb.String()
+}
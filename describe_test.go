@@ -0,0 +1,79 @@
+package sqlpro
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPlanBase struct {
+	ID      int64 `db:"id,pk,omitempty"`
+	Created time.Time
+}
+
+type testPlanRow struct {
+	testPlanBase
+	Name     string  `db:"name,notnull,trim"`
+	Email    *string `db:"email,null"`
+	Nick     string  `db:"name"`
+	Hidden   string  `db:"-"`
+	Untagged string
+	Status   string `db:"status,enum=new|done"`
+	secret   string
+}
+
+func (testPlanRow) TableName() string {
+	return "test_plan"
+}
+
+func TestDescribePlan(t *testing.T) {
+	plan, err := db.DescribePlan(&testPlanRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Table != "test_plan" || len(plan.Fields) != 4 {
+		t.Fatalf("Unexpected plan:\n%s", plan)
+	}
+	if f := plan.Fields[0]; f.Field != "testPlanBase.ID" || f.Column != "id" || !reflect.DeepEqual(f.Flags, []string{"pk", "omitempty"}) {
+		t.Errorf("Unexpected first field: %+v", f)
+	}
+	if f := plan.Fields[1]; f.Column != "email" || !reflect.DeepEqual(f.Flags, []string{"null", "ptr"}) || f.Type != "*string" {
+		t.Errorf("Unexpected email field: %+v", f)
+	}
+
+	skipped := map[string]string{}
+	for _, s := range plan.Skipped {
+		skipped[s.Field] = s.Reason
+	}
+	exp := map[string]string{
+		"testPlanBase.Created": "no db tag",
+		"Name":                 "column is mapped by another field",
+		"Hidden":               `tagged "-"`,
+		"Untagged":             "no db tag",
+		"secret":               "unexported",
+	}
+	if !reflect.DeepEqual(skipped, exp) {
+		t.Errorf("Unexpected skipped fields: %v", skipped)
+	}
+
+	if s := plan.String(); !strings.Contains(s, "enum=new|done") || !strings.Contains(s, "skipped Hidden") {
+		t.Errorf("Unexpected plan string:\n%s", s)
+	}
+
+	mdb := *db
+	mdb.MapUntaggedFields = true
+	plan, err = mdb.DescribePlan(testPlanRow{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Fields) != 6 {
+		t.Errorf("Expected untagged fields to be mapped:\n%s", plan)
+	}
+
+	_, err = db.DescribePlan(1)
+	if err == nil {
+		t.Errorf("Expected error for non-struct model")
+	}
+}
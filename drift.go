@@ -0,0 +1,301 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DriftKind is the kind of a difference found by DriftReport.
+type DriftKind string
+
+const (
+	DriftMissingTable  DriftKind = "missing table"
+	DriftMissingColumn DriftKind = "missing column"
+	DriftUnmapped      DriftKind = "unmapped required column"
+	DriftNullable      DriftKind = "null not allowed"
+	DriftType          DriftKind = "type mismatch"
+	DriftPrimaryKey    DriftKind = "primary key mismatch"
+)
+
+// SchemaDrift is a difference between a model and the live schema.
+type SchemaDrift struct {
+	Table   string
+	Column  string
+	Field   string // the struct field, empty for unmapped columns
+	Kind    DriftKind
+	Message string
+}
+
+func (d SchemaDrift) String() string {
+	return fmt.Sprintf("%s.%s: %s: %s", d.Table, d.Column, d.Kind, d.Message)
+}
+
+// Drift is the list of differences returned by DriftReport.
+type Drift []SchemaDrift
+
+// Err returns an error listing all differences, or nil if there are
+// none. Use this to fail a deploy or a CI run.
+func (d Drift) Err() error {
+	if len(d) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(d))
+	for _, sd := range d {
+		lines = append(lines, sd.String())
+	}
+	return fmt.Errorf("sqlpro: Schema drift found:\n%s", strings.Join(lines, "\n"))
+}
+
+// DriftModel pairs a model struct with its table for DriftReport.
+type DriftModel struct {
+	Table string
+	Model interface{}
+}
+
+// DriftReport compares the given models with the live schema and
+// returns the differences which will lead to errors at runtime:
+// missing tables and columns, NOT NULL columns without default which
+// are not mapped (inserts fail), fields writing NULL into NOT NULL
+// columns, incompatible types and differing primary keys.
+//
+// Each model is either a DriftModel or a struct (pointer) implementing
+// TableNamer.
+//
+//	drift, err := db.DriftReport(DriftModel{"user", User{}}, DriftModel{"org", Org{}})
+//	if err == nil {
+//		err = drift.Err()
+//	}
+//
+// Reading the schema is supported for SQLite, Postgres and MSSQL.
+func (db *DB) DriftReport(models ...interface{}) (Drift, error) {
+	drift := make(Drift, 0)
+
+	for _, m := range models {
+		var (
+			table string
+			model interface{}
+		)
+		switch v := m.(type) {
+		case DriftModel:
+			table, model = v.Table, v.Model
+		case TableNamer:
+			table, model = v.TableName(), v
+		default:
+			return nil, fmt.Errorf("sqlpro.DriftReport: Unable to get table of %T, use DriftModel or implement TableName().", m)
+		}
+
+		t := reflect.TypeOf(model)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("sqlpro.DriftReport: Model needs to be a struct, have: %T", model)
+		}
+
+		cols, err := db.tableColumns(table)
+		if err != nil {
+			return nil, xerrors.Errorf("sqlpro.DriftReport: Unable to read columns of %s: %w", table, err)
+		}
+		drift = append(drift, db.compareTable(table, db.tableStructInfo(t, table), cols)...)
+	}
+
+	return drift, nil
+}
+
+// compareTable returns the differences of info and cols
+func (db *DB) compareTable(table string, info structInfo, cols []tableColumn) Drift {
+	drift := make(Drift, 0)
+
+	if len(cols) == 0 {
+		return append(drift, SchemaDrift{Table: table, Kind: DriftMissingTable, Message: "table does not exist"})
+	}
+
+	byName := make(map[string]tableColumn, len(cols))
+	for _, col := range cols {
+		byName[col.Name] = col
+	}
+
+	fis := make([]*fieldInfo, 0, len(info))
+	for _, fi := range info {
+		fis = append(fis, fi)
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].before(fis[j])
+	})
+
+	for _, fi := range fis {
+		col, ok := byName[fi.dbName]
+		if !ok {
+			drift = append(drift, SchemaDrift{Table: table, Column: fi.dbName, Field: fi.name, Kind: DriftMissingColumn,
+				Message: fmt.Sprintf("field %s has no column", fi.name)})
+			continue
+		}
+		if col.NotNull && !col.PrimaryKey && fi.allowNull() && !fi.readOnly {
+			drift = append(drift, SchemaDrift{Table: table, Column: col.Name, Field: fi.name, Kind: DriftNullable,
+				Message: fmt.Sprintf("field %s can write NULL", fi.name)})
+		}
+		if !db.compatibleType(fi, col.Type) {
+			drift = append(drift, SchemaDrift{Table: table, Column: col.Name, Field: fi.name, Kind: DriftType,
+				Message: fmt.Sprintf("field %s of type %s is incompatible with %s", fi.name, fi.structField.Type, col.Type)})
+		}
+		if fi.primaryKey != col.PrimaryKey {
+			drift = append(drift, SchemaDrift{Table: table, Column: col.Name, Field: fi.name, Kind: DriftPrimaryKey,
+				Message: fmt.Sprintf("field pk: %t, column pk: %t", fi.primaryKey, col.PrimaryKey)})
+		}
+	}
+
+	for _, col := range cols {
+		if _, ok := info[col.Name]; ok {
+			continue
+		}
+		if col.NotNull && !col.HasDefault && !col.PrimaryKey {
+			drift = append(drift, SchemaDrift{Table: table, Column: col.Name, Kind: DriftUnmapped,
+				Message: "column is NOT NULL without default, but not mapped"})
+		}
+	}
+
+	return drift
+}
+
+type typeFamily int
+
+const (
+	familyUnknown typeFamily = iota
+	familyInt
+	familyFloat
+	familyBool
+	familyText
+	familyTime
+	familyBinary
+)
+
+// columnFamily returns the family of an SQL column type
+func columnFamily(colType string) typeFamily {
+	t := strings.ToUpper(colType)
+	switch {
+	case strings.Contains(t, "INT"), strings.Contains(t, "SERIAL"):
+		return familyInt
+	case strings.Contains(t, "BOOL"), t == "BIT":
+		return familyBool
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "TEXT"), strings.Contains(t, "CLOB"):
+		return familyText
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"),
+		strings.Contains(t, "NUMERIC"), strings.Contains(t, "DECIMAL"), strings.Contains(t, "NUMBER"):
+		return familyFloat
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return familyTime
+	case strings.Contains(t, "BLOB"), strings.Contains(t, "BYTEA"), strings.Contains(t, "BINARY"):
+		return familyBinary
+	}
+	return familyUnknown
+}
+
+// compatibleType returns false if the field cannot be stored in a
+// column of colType. Unknown types are compatible.
+func (db *DB) compatibleType(fi *fieldInfo, colType string) bool {
+	if fi.isJson || fi.isHstore || len(fi.enum) > 0 {
+		return true
+	}
+
+	colFamily := columnFamily(colType)
+	if colFamily == familyUnknown {
+		return true
+	}
+
+	t := fi.structField.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		// SQLite stores times as text
+		return colFamily == familyTime || (db.Driver == SQLITE3 && colFamily == familyText)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return colFamily == familyBinary || colFamily == familyText
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return colFamily == familyInt || colFamily == familyFloat || colFamily == familyBool
+	case reflect.Float32, reflect.Float64:
+		return colFamily == familyInt || colFamily == familyFloat
+	case reflect.Bool:
+		return colFamily == familyBool || colFamily == familyInt
+	}
+	return true
+}
+
+// tableColumn is a column as read from the live schema
+type tableColumn struct {
+	Name       string `db:"name"`
+	Type       string `db:"type"`
+	NotNull    bool   `db:"notnull"`
+	HasDefault bool   `db:"has_default"`
+	PrimaryKey bool   `db:"pk"`
+}
+
+// tableColumns returns the columns of table, or none if the table
+// does not exist. A table without schema is looked up in
+// DefaultSchema, or the current schema of the connection.
+func (db *DB) tableColumns(table string) ([]tableColumn, error) {
+	var (
+		cols []tableColumn
+		err  error
+	)
+
+	schema := db.DefaultSchema
+	parts := splitIdent(table)
+	name := parts[len(parts)-1]
+	if len(parts) > 1 {
+		schema = parts[len(parts)-2]
+	}
+
+	switch db.Driver {
+	case SQLITE3:
+		if schema == "" {
+			err = db.Query(&cols, `SELECT name, type, "notnull", dflt_value IS NOT NULL AS has_default, pk > 0 AS pk
+				FROM pragma_table_info(?) ORDER BY cid`, name)
+		} else {
+			err = db.Query(&cols, `SELECT name, type, "notnull", dflt_value IS NOT NULL AS has_default, pk > 0 AS pk
+				FROM pragma_table_info(?, ?) ORDER BY cid`, name, schema)
+		}
+	case POSTGRES, MSSQL:
+		args := []interface{}{name, schema}
+		schemaCond := "c.table_schema = ?"
+		if schema == "" {
+			args = args[:1]
+			schemaCond = "c.table_schema = current_schema()"
+			if db.Driver == MSSQL {
+				schemaCond = "c.table_schema = SCHEMA_NAME()"
+			}
+		}
+		err = db.Query(&cols, `
+			SELECT c.column_name AS name, c.data_type AS type,
+				CASE WHEN c.is_nullable = 'NO' THEN 1 ELSE 0 END AS notnull,
+				CASE WHEN c.column_default IS NULL THEN 0 ELSE 1 END AS has_default,
+				CASE WHEN EXISTS (
+					SELECT 1 FROM information_schema.table_constraints tc
+					JOIN information_schema.key_column_usage kcu
+						ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+					WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_name = c.table_name
+						AND tc.table_schema = c.table_schema AND kcu.column_name = c.column_name
+				) THEN 1 ELSE 0 END AS pk
+			FROM information_schema.columns c
+			WHERE c.table_name = ? AND `+schemaCond+`
+			ORDER BY c.ordinal_position`, args...)
+	default:
+		return nil, fmt.Errorf("sqlpro: Reading columns is not supported for driver '%s'.", db.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cols, nil
+}
@@ -0,0 +1,130 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRowDrift struct {
+	ID      int64     `db:"id,pk,omitempty"`
+	Name    *string   `db:"name"`
+	Count   int64     `db:"count"`
+	Created time.Time `db:"created"`
+	Missing string    `db:"missing"`
+}
+
+func (testRowDrift) TableName() string {
+	return "test_drift"
+}
+
+func TestDriftReport(t *testing.T) {
+	sqlite := *sqliteDB
+
+	err := sqlite.Exec(`CREATE TABLE test_drift(id INTEGER PRIMARY KEY, name TEXT NOT NULL, count TEXT, created DATETIME,
+		required TEXT NOT NULL, optional TEXT NOT NULL DEFAULT '')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err := sqlite.DriftReport(testRowDrift{}, DriftModel{Table: "test_drift_missing", Model: &testRowDrift{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make(map[string]DriftKind, 0)
+	for _, d := range drift {
+		kinds[d.Table+"."+d.Column] = d.Kind
+	}
+	exp := map[string]DriftKind{
+		"test_drift.name":     DriftNullable,
+		"test_drift.count":    DriftType,
+		"test_drift.missing":  DriftMissingColumn,
+		"test_drift.required": DriftUnmapped,
+		"test_drift_missing.": DriftMissingTable,
+	}
+	if !reflect.DeepEqual(kinds, exp) {
+		t.Errorf("Expected %v, got: %v", exp, drift)
+	}
+	if drift.Err() == nil {
+		t.Errorf("Expected error for drift")
+	}
+
+	_, err = sqlite.DriftReport(struct{}{})
+	if err == nil {
+		t.Errorf("Expected error for model without table")
+	}
+}
+
+// queryRecorder records the last query and its args
+type queryRecorder struct {
+	query string
+	args  []interface{}
+}
+
+func (qr *queryRecorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	qr.query, qr.args = query, args
+	return nil, fmt.Errorf("queryRecorder: Query not supported")
+}
+
+func (qr *queryRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return nil, fmt.Errorf("queryRecorder: Exec not supported")
+}
+
+func TestTableColumnsSchema(t *testing.T) {
+	sqlite := *sqliteDB
+
+	err := sqlite.Exec(`CREATE TABLE test_drift_schema(id INTEGER PRIMARY KEY, name TEXT)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"test_drift_schema", "main.test_drift_schema", `"main"."test_drift_schema"`} {
+		cols, err := sqlite.tableColumns(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cols) != 2 {
+			t.Errorf("%s: Expected 2 columns, got: %v", table, cols)
+		}
+	}
+
+	tests := []struct {
+		driver dbDriver
+		table  string
+		schema string
+		args   []interface{}
+	}{
+		{POSTGRES, "orders", "current_schema()", []interface{}{"orders"}},
+		{POSTGRES, "sales.orders", "$2", []interface{}{"orders", "sales"}},
+		{MSSQL, "orders", "SCHEMA_NAME()", []interface{}{"orders"}},
+		{MSSQL, "[sales].[orders]", "@p2", []interface{}{"orders", "sales"}},
+	}
+	for _, test := range tests {
+		rec := &queryRecorder{}
+		qdb := New(rec)
+		err := qdb.setDriver(test.driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = qdb.tableColumns(test.table)
+		if !strings.Contains(rec.query, "c.table_schema = "+test.schema) {
+			t.Errorf("%s: Expected schema filter %s, got: %s", test.table, test.schema, rec.query)
+		}
+		if !reflect.DeepEqual(rec.args, test.args) {
+			t.Errorf("%s: Expected args %v, got: %v", test.table, test.args, rec.args)
+		}
+	}
+
+	// DefaultSchema applies to tables without schema
+	rec := &queryRecorder{}
+	qdb := New(rec)
+	_ = qdb.setDriver(POSTGRES)
+	qdb.DefaultSchema = "sales"
+	_, _ = qdb.tableColumns("orders")
+	if !reflect.DeepEqual(rec.args, []interface{}{"orders", "sales"}) {
+		t.Errorf("Expected DefaultSchema in args, got: %v", rec.args)
+	}
+}
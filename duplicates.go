@@ -0,0 +1,149 @@
+package sqlpro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateMode configures how InsertBulk, InsertBulkPartial and
+// CopyFrom handle rows of the input which have the same primary key
+// or the same value in a column tagged "unique". Upsert takes single
+// rows, there is no bulk upsert to apply it to.
+type DuplicateMode int
+
+const (
+	// DuplicatesAllow passes all rows to the database
+	DuplicatesAllow DuplicateMode = iota
+	// DuplicatesError returns a *DuplicateRowsError without inserting
+	DuplicatesError
+	// DuplicatesKeepFirst inserts only the first of the duplicate rows
+	DuplicatesKeepFirst
+	// DuplicatesKeepLast inserts only the last of the duplicate rows
+	DuplicatesKeepLast
+)
+
+// DuplicateRowsError lists the rows of the input which duplicate
+// an earlier row, mapping their index to the index of that row.
+type DuplicateRowsError struct {
+	Duplicates map[int]int
+}
+
+func (de *DuplicateRowsError) Error() string {
+	idxs := make([]int, 0, len(de.Duplicates))
+	for idx := range de.Duplicates {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+
+	parts := make([]string, 0, len(idxs))
+	for _, idx := range idxs {
+		parts = append(parts, fmt.Sprintf("%d (of %d)", idx, de.Duplicates[idx]))
+	}
+	return fmt.Sprintf("sqlpro: %d duplicate rows: %s", len(idxs), strings.Join(parts, ", "))
+}
+
+// duplicateKeys returns the groups of columns which identify a row:
+// all primary keys together and each unique column by itself
+func duplicateKeys(info structInfo) [][]string {
+	groups := make([][]string, 0)
+
+	pks := info.primaryKeys()
+	if len(pks) > 0 {
+		group := make([]string, 0, len(pks))
+		for _, pk := range pks {
+			group = append(group, pk.dbName)
+		}
+		groups = append(groups, group)
+	}
+
+	uniques := make([]string, 0)
+	for _, fi := range info {
+		if fi.unique && !fi.primaryKey {
+			uniques = append(uniques, fi.dbName)
+		}
+	}
+	sort.Strings(uniques)
+	for _, col := range uniques {
+		groups = append(groups, []string{col})
+	}
+
+	return groups
+}
+
+// handleDuplicates applies db.BulkDuplicates to rows. Rows where a key
+// column is missing or empty are never considered duplicates, as
+// their value is set by the database.
+//
+// If rows are dropped, the index of each kept row in rows is
+// returned, otherwise the returned index is nil.
+func (db *DB) handleDuplicates(rows []map[string]interface{}, info structInfo) ([]map[string]interface{}, []int, error) {
+	if db.BulkDuplicates == DuplicatesAllow || len(rows) < 2 {
+		return rows, nil, nil
+	}
+
+	switch db.BulkDuplicates {
+	case DuplicatesError:
+		dups := findDuplicates(rows, duplicateKeys(info), false)
+		if len(dups) > 0 {
+			return nil, nil, &DuplicateRowsError{Duplicates: dups}
+		}
+		return rows, nil, nil
+	case DuplicatesKeepFirst, DuplicatesKeepLast:
+		dups := findDuplicates(rows, duplicateKeys(info), db.BulkDuplicates == DuplicatesKeepLast)
+		if len(dups) == 0 {
+			return rows, nil, nil
+		}
+		kept := make([]map[string]interface{}, 0, len(rows)-len(dups))
+		index := make([]int, 0, len(rows)-len(dups))
+		for idx, row := range rows {
+			if _, ok := dups[idx]; !ok {
+				kept = append(kept, row)
+				index = append(index, idx)
+			}
+		}
+		return kept, index, nil
+	default:
+		return nil, nil, fmt.Errorf("sqlpro: Unknown duplicate mode: %d", db.BulkDuplicates)
+	}
+}
+
+// findDuplicates returns the index of each row which duplicates an
+// earlier row, mapped to the index of that row. With reverse set,
+// the rows are checked from the end, so that the last row of
+// duplicates is the one not returned.
+func findDuplicates(rows []map[string]interface{}, groups [][]string, reverse bool) map[int]int {
+	dups := make(map[int]int, 0)
+
+	for _, group := range groups {
+		seen := make(map[string]int, len(rows))
+	ROWS:
+		for i := range rows {
+			idx := i
+			if reverse {
+				idx = len(rows) - 1 - i
+			}
+
+			parts := make([]string, 0, len(group))
+			for _, col := range group {
+				value, ok := rows[idx][col]
+				if !ok || isZero(value) {
+					continue ROWS
+				}
+				parts = append(parts, fmt.Sprintf("%T:%v", value, value))
+			}
+
+			key := strings.Join(parts, "\x00")
+			other, ok := seen[key]
+			if !ok {
+				seen[key] = idx
+				continue
+			}
+			if _, ok := dups[idx]; !ok {
+				dups[idx] = other
+			}
+		}
+	}
+
+	return dups
+}
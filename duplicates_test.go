@@ -0,0 +1,101 @@
+package sqlpro
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testRowDup struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Email string `db:"email,unique"`
+	Name  string `db:"name"`
+}
+
+func TestHandleDuplicates(t *testing.T) {
+	info := getStructInfo(reflect.TypeOf(testRowDup{}))
+	rows := []map[string]interface{}{
+		{"id": int64(1), "email": "a", "name": "0"},
+		{"email": "b", "name": "1"},
+		{"id": int64(1), "email": "c", "name": "2"}, // pk of 0
+		{"email": "b", "name": "3"},                 // email of 1
+		{"email": "d", "name": "4"},
+	}
+
+	names := func(rows []map[string]interface{}) string {
+		s := ""
+		for _, row := range rows {
+			s += row["name"].(string)
+		}
+		return s
+	}
+
+	d := *db
+	for mode, expNames := range map[DuplicateMode]string{
+		DuplicatesAllow:     "01234",
+		DuplicatesKeepFirst: "014",
+		DuplicatesKeepLast:  "234",
+	} {
+		d.BulkDuplicates = mode
+		kept, _, err := d.handleDuplicates(rows, info)
+		if err != nil {
+			t.Error(err)
+		}
+		if names(kept) != expNames {
+			t.Errorf("Mode %d: Expected rows %s, got: %s", mode, expNames, names(kept))
+		}
+	}
+
+	d.BulkDuplicates = DuplicatesError
+	_, _, err := d.handleDuplicates(rows, info)
+
+	var dupErr *DuplicateRowsError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("Expected DuplicateRowsError, got: %v", err)
+	}
+	if len(dupErr.Duplicates) != 2 || dupErr.Duplicates[2] != 0 || dupErr.Duplicates[3] != 1 {
+		t.Errorf("Unexpected duplicates: %v", dupErr.Duplicates)
+	}
+}
+
+func TestInsertBulkDuplicates(t *testing.T) {
+	var count int64
+
+	err := db.Exec("CREATE TABLE test_dup(id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []testRowDup{{Email: "a"}, {Email: "b"}, {Email: "a", Name: "again"}}
+
+	db2 := *db
+	db2.BulkDuplicates = DuplicatesError
+	err = db2.InsertBulk("test_dup", rows)
+	if _, ok := err.(*DuplicateRowsError); !ok {
+		t.Errorf("Expected DuplicateRowsError, got: %v", err)
+	}
+
+	db2.BulkDuplicates = DuplicatesKeepLast
+	err = db2.InsertBulk("test_dup", rows)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&count, "SELECT count(*) FROM test_dup WHERE name = ?", "again")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected last duplicate to be inserted, got: %d", count)
+	}
+
+	// the index of failing rows refers to rows, not to the kept rows
+	db2.BulkDuplicates = DuplicatesKeepFirst
+	report, err := db2.InsertBulkPartial("test_dup", []testRowDup{{Email: "c"}, {Email: "c"}, {Email: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].Index != 2 {
+		t.Errorf("Expected row 2 to fail, got: %v", report)
+	}
+}
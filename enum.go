@@ -0,0 +1,87 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// enumValue validates value against the "enum=a|b|c" values of fi and
+// returns the value to store. String fields are stored as they are,
+// integer fields are stored as the name at their index, so that a Go
+// iota enum can be stored as text.
+func enumValue(value interface{}, fi *fieldInfo) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return value, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+		if s == "" && fi.allowNull() {
+			return nil, nil
+		}
+		for _, e := range fi.enum {
+			if e == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("Invalid value %q for field %s, allowed: %s", s, fi.name, strings.Join(fi.enum, ", "))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		idx := rv.Int()
+		if idx < 0 || idx >= int64(len(fi.enum)) {
+			return nil, fmt.Errorf("Invalid value %d for field %s, allowed: 0..%d", idx, fi.name, len(fi.enum)-1)
+		}
+		return fi.enum[idx], nil
+	default:
+		return nil, fmt.Errorf("Unable to use enum for field %s of type %s.", fi.name, rv.Type())
+	}
+}
+
+// enumScan scans an enum stored as text into an integer field
+type enumScan struct {
+	sql.NullString
+	fi *fieldInfo
+}
+
+// setField sets the index of the scanned name into fieldV
+func (es *enumScan) setField(fieldV reflect.Value) error {
+	if !es.Valid {
+		fieldV.Set(reflect.Zero(fieldV.Type()))
+		return nil
+	}
+	for idx, e := range es.fi.enum {
+		if e == es.String {
+			if fieldV.Kind() == reflect.Ptr {
+				v := reflect.New(fieldV.Type().Elem())
+				v.Elem().SetInt(int64(idx))
+				fieldV.Set(v)
+			} else {
+				fieldV.SetInt(int64(idx))
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Unable to scan unknown value %q into enum field %s.", es.String, es.fi.name)
+}
+
+// isIntEnum returns true if the field stores an integer enum
+func (fi *fieldInfo) isIntEnum() bool {
+	if len(fi.enum) == 0 {
+		return false
+	}
+	t := fi.structField.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+type testStatus int
+
+const (
+	testDraft testStatus = iota
+	testPublished
+	testArchived
+)
+
+type testRowEnum struct {
+	ID     int64      `db:"id,pk,omitempty"`
+	Status testStatus `db:"status,enum=draft|published|archived"`
+	Kind   string     `db:"kind,enum=post|page"`
+}
+
+func TestEnum(t *testing.T) {
+	var (
+		rows     []testRowEnum
+		statuses []string
+	)
+
+	err := db.Exec("CREATE TABLE test_enum(id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT, kind TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_enum", []testRowEnum{{Status: testPublished, Kind: "post"}, {Status: testDraft, Kind: "page"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&statuses, "SELECT status FROM test_enum ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0] != "published" || statuses[1] != "draft" {
+		t.Errorf("Expected statuses stored by name, got: %v", statuses)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_enum ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Status != testPublished || rows[1].Status != testDraft || rows[1].Kind != "page" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	for _, bad := range []testRowEnum{{Status: testArchived + 1, Kind: "post"}, {Kind: "unknown"}, {}} {
+		err = db.Insert("test_enum", &bad)
+		if err == nil {
+			t.Errorf("Expected error for invalid enum: %v", bad)
+		}
+	}
+}
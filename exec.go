@@ -1,7 +1,10 @@
 package sqlpro
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -67,8 +70,21 @@ func checkData(data interface{}) (reflect.Value, bool, error) {
 // *struct
 //
 // sqlpro will executes one INSERT statement per row.
-// result.LastInsertId will be used to set the first primary
-// key column.
+// result.LastInsertId will be used to set the primary key
+// column, if the struct has exactly one primary key of an
+// integer type which is zero. Primary keys of other types, like string
+// or UUID keys, need to be set before calling Insert.
+//
+// Fields tagged with "uuid" are set to a new random UUID
+// before the INSERT, if they are zero. Fields tagged with
+// "seq=<sequence>" are set to the next value of the sequence.
+//
+// Fields tagged "autocreate" are set to the current time, if they
+// are zero, fields tagged "autoupdate" are always set. The time is
+// in TimeLocation, if set.
+//
+// Structs implementing BeforeInserter or AfterInserter have their
+// hooks called before and after each row is written.
 
 func (db *DB) Insert(table string, data interface{}) error {
 	var (
@@ -84,37 +100,58 @@ func (db *DB) Insert(table string, data interface{}) error {
 
 	if !structMode {
 		for i := 0; i < rv.Len(); i++ {
-			row := reflect.Indirect(rv.Index(i))
-			insert_id, structInfo, err := db.insertStruct(table, row.Interface())
+			err = db.insertRow(table, reflect.Indirect(rv.Index(i)))
 			if err != nil {
 				return err
 			}
-			pk := structInfo.onlyPrimaryKey()
-			if pk != nil && pk.structField.Type.Kind() == reflect.Int64 {
-				setPrimaryKey(row.FieldByName(pk.name), insert_id)
-			}
 		}
 	} else {
-		insert_id, structInfo, err := db.insertStruct(table, rv.Interface())
+		err = db.insertRow(table, rv)
 		if err != nil {
 			return err
 		}
-		pk := structInfo.onlyPrimaryKey()
-		// log.Printf("PK: %d", insert_id)
-		if pk != nil && pk.structField.Type.Kind() == reflect.Int64 {
-			setPrimaryKey(rv.FieldByName(pk.name), insert_id)
-		}
 	}
 
 	// data
 	return nil
 }
 
+func (db *DB) insertRow(table string, row reflect.Value) error {
+	err := db.runHook(row, beforeInsert)
+	if err != nil {
+		return err
+	}
+	err = db.generateKeys(row)
+	if err != nil {
+		return err
+	}
+	err = db.validate(row)
+	if err != nil {
+		return err
+	}
+	insert_id, structInfo, err := db.insertStruct(table, db.stampTimes(row, true).Interface())
+	if err != nil {
+		return err
+	}
+	pk := structInfo.onlyPrimaryKey()
+	// log.Printf("PK: %d", insert_id)
+	if pk != nil && pk.integerKey() && pk.sequence == "" {
+		// a key set by the caller is kept, as the last insert id is not
+		// necessarily the key, e.g. for SQLite tables without rowid or
+		// with a key which is no alias of the rowid
+		pkV := row.FieldByIndex(pk.structField.Index)
+		if isZero(pkV.Interface()) {
+			setPrimaryKey(pkV, insert_id)
+		}
+	}
+	return db.runHook(row, afterInsert)
+}
+
 func setPrimaryKey(rv reflect.Value, id int64) {
 	switch rv.Type().Kind() {
-	case reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		rv.SetInt(id)
-	case reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		rv.SetUint(uint64(id))
 	default:
 		err := fmt.Errorf("Unknown type to set primary key: %s", rv.Type())
@@ -131,37 +168,163 @@ func setPrimaryKey(rv reflect.Value, id int64) {
 // []*struct
 // []struct
 //
-// sqlpro will executes one INSERT statement per call.
+// sqlpro will executes one INSERT statement per call. The values
+// are passed as arguments to the driver, using one placeholder per
+// column and row. If the statement would need more than MaxBulkParams
+// placeholders, the rows are split into multiple INSERT statements.
+// Set BulkTransaction to run these statements in one transaction.
+// Set BulkDuplicates to detect rows with equal keys in data.
+//
+// For ClickHouse, the rows are sent as one block, see insertBlock.
 func (db *DB) InsertBulk(table string, data interface{}) error {
-	var (
-		rv         reflect.Value
-		structMode bool
-		err        error
-	)
+	keys, key_map, rows, _, err := db.bulkRows(table, data, "InsertBulk")
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
 
-	rv, structMode, err = checkData(data)
+	if db.Driver == CLICKHOUSE {
+		return db.insertBlock(table, keys, key_map, rows)
+	}
+
+	return db.insertBulk(table, keys, key_map, rows)
+}
+
+// RowError is the error of one row, Index is the row's
+// index in the data passed to InsertBulkPartial.
+type RowError struct {
+	Index int
+	Err   error
+}
+
+func (re RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", re.Index, re.Err)
+}
+
+// InsertBulkPartial works like InsertBulk, but tolerates failing rows.
+// If an INSERT fails, the chunk is split in halves and these are
+// inserted separately, until the failing rows are isolated. All other
+// rows are inserted. The errors of the failing rows are returned with
+// the row's index, the returned error is only set if the data cannot
+// be used at all.
+//
+// Inside a transaction, each INSERT runs in a savepoint, so that a
+// failing row does not abort the transaction.
+func (db *DB) InsertBulkPartial(table string, data interface{}) ([]RowError, error) {
+	keys, key_map, rows, index, err := db.bulkRows(table, data, "InsertBulkPartial")
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize := len(rows)
+	if db.MaxBulkParams > 0 && len(keys) > 0 {
+		chunkSize = db.MaxBulkParams / len(keys)
+		if chunkSize < 1 {
+			chunkSize = 1
+		}
+	}
+
+	report := make([]RowError, 0)
+	for offset := 0; offset < len(rows); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		err = db.insertBulkBisect(table, keys, key_map, rows[offset:end], offset, &report)
+		if err != nil {
+			break
+		}
+	}
+
+	// report the index in data, not in the rows kept by BulkDuplicates
+	if index != nil {
+		for i := range report {
+			report[i].Index = index[report[i].Index]
+		}
+	}
+
+	return report, err
+}
+
+// insertBulkBisect inserts the rows, splitting them in halves on
+// error until the failing rows are found and added to report
+func (db *DB) insertBulkBisect(table string, keys []string, key_map map[string]*fieldInfo, rows []map[string]interface{}, offset int, report *[]RowError) error {
+	var err error
+
+	if db.sqlTx != nil {
+		var tx *Tx
+		tx, err = db.Begin()
+		if err != nil {
+			return err
+		}
+		err = tx.insertBulkRows(table, keys, key_map, rows)
+		if err != nil {
+			rbErr := tx.Rollback()
+			if rbErr != nil {
+				return rbErr
+			}
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = db.insertBulkRows(table, keys, key_map, rows)
+	}
+
+	if err == nil {
+		return nil
+	}
+	if len(rows) == 1 {
+		*report = append(*report, RowError{Index: offset, Err: err})
+		return nil
+	}
+
+	mid := len(rows) / 2
+	err = db.insertBulkBisect(table, keys, key_map, rows[:mid], offset, report)
 	if err != nil {
 		return err
 	}
+	return db.insertBulkBisect(table, keys, key_map, rows[mid:], offset+mid, report)
+}
+
+// bulkRows returns the values of all rows in data, together
+// with the union of their keys. If BulkDuplicates dropped rows, the
+// index of each returned row in data is returned, otherwise nil.
+func (db *DB) bulkRows(table string, data interface{}, caller string) ([]string, map[string]*fieldInfo, []map[string]interface{}, []int, error) {
+	var index []int
+
+	rv, structMode, err := checkData(data)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	if structMode {
-		return fmt.Errorf("InsertBulk: Need Slice to insert bulk.")
+		return nil, nil, nil, nil, fmt.Errorf("%s: Need Slice to insert bulk.", caller)
 	}
 
 	key_map := make(map[string]*fieldInfo, 0)
 	rows := make([]map[string]interface{}, 0)
 
-	if rv.Len() == 0 {
-		return nil
-	}
-
 	for i := 0; i < rv.Len(); i++ {
-		row := reflect.Indirect(rv.Index(i)).Interface()
+		err = db.generateKeys(reflect.Indirect(rv.Index(i)))
+		if err != nil {
+			return nil, nil, nil, nil, xerrors.Errorf("sqlpro.%s error: %w", caller, err)
+		}
+		err = db.validate(reflect.Indirect(rv.Index(i)))
+		if err != nil {
+			return nil, nil, nil, nil, xerrors.Errorf("sqlpro.%s: Row %d: %w", caller, i, err)
+		}
+
+		row := db.stampTimes(reflect.Indirect(rv.Index(i)), true).Interface()
 
-		values, structInfo, err := db.valuesFromStruct(row)
+		values, structInfo, err := db.tableValuesFromStruct(table, row)
 
 		if err != nil {
-			return xerrors.Errorf("sqlpro.InsertBulk error: %w", err)
+			return nil, nil, nil, nil, xerrors.Errorf("sqlpro.%s error: %w", caller, err)
 		}
 
 		rows = append(rows, values)
@@ -170,25 +333,88 @@ func (db *DB) InsertBulk(table string, data interface{}) error {
 		}
 	}
 
-	insert := strings.Builder{} // make([]string, 0)
+	if db.BulkDuplicates != DuplicatesAllow && rv.Len() > 0 {
+		info := db.tableStructInfo(reflect.Indirect(rv.Index(0)).Type(), table)
+		rows, index, err = db.handleDuplicates(rows, info)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
 	keys := make([]string, 0, len(key_map))
+	for key := range key_map {
+		keys = append(keys, key)
+	}
+
+	return keys, key_map, rows, index, nil
+}
+
+// insertBulk inserts the rows using as few INSERT statements
+// as MaxBulkParams allows
+func (db *DB) insertBulk(table string, keys []string, key_map map[string]*fieldInfo, rows []map[string]interface{}) error {
+	var err error
+
+	// split the rows, so that one statement does not exceed
+	// MaxBulkParams
+	chunkSize := len(rows)
+	if db.MaxBulkParams > 0 && len(keys) > 0 {
+		chunkSize = db.MaxBulkParams / len(keys)
+		if chunkSize < 1 {
+			chunkSize = 1
+		}
+	}
+
+	var tx *Tx
+	execDB := db
+	if db.BulkTransaction && len(rows) > chunkSize && db.sqlTx == nil && db.sqlDB != nil {
+		tx, err = db.Begin()
+		if err != nil {
+			return sqlError(err, "BEGIN TRANSACTION", []interface{}{})
+		}
+		execDB = tx.DB
+	}
+
+	for len(rows) > 0 {
+		n := chunkSize
+		if n > len(rows) {
+			n = len(rows)
+		}
+		err = execDB.insertBulkRows(table, keys, key_map, rows[:n])
+		if err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			return err
+		}
+		rows = rows[n:]
+	}
+
+	if tx != nil {
+		return tx.Commit()
+	}
+
+	return nil
+}
+
+// insertBulkRows executes one INSERT statement for the given rows
+func (db *DB) insertBulkRows(table string, keys []string, key_map map[string]*fieldInfo, rows []map[string]interface{}) error {
+	insert := strings.Builder{}
 
 	insert.WriteString("INSERT INTO ")
-	insert.WriteString(db.Esc(table))
+	insert.WriteString(db.EscTable(table))
 	insert.WriteString(" (")
 
-	idx := 0
-	for key := range key_map {
+	for idx, key := range keys {
 		if idx > 0 {
 			insert.WriteRune(',')
 		}
 		insert.WriteString(db.Esc(key))
-		keys = append(keys, key)
-		idx++
 	}
 
 	insert.WriteString(") VALUES ")
 
+	args := make([]interface{}, 0, len(rows)*len(keys))
+
 	for idx, row := range rows {
 		if idx > 0 {
 			insert.WriteRune(',')
@@ -198,115 +424,164 @@ func (db *DB) InsertBulk(table string, data interface{}) error {
 			if idx2 > 0 {
 				insert.WriteRune(',')
 			}
-			insert.WriteString(db.EscValueForInsert(row[key], key_map[key]))
+			insert.WriteRune(db.PlaceholderValue)
+			args = append(args, db.argValue(row[key], key_map[key]))
 		}
 		insert.WriteRune(')')
 	}
 
-	_, err = db.DB.Exec(insert.String())
+	_, err := db.exec(int64(len(rows)), insert.String(), args...)
 	if err != nil {
-		return sqlError(err, insert.String(), []interface{}{})
+		return err
 	}
 
 	return nil
 }
 
+// isPQDriver returns true for lib/pq, the only driver supporting
+// "COPY FROM" through database/sql
+func isPQDriver(drv driver.Driver) bool {
+	return strings.HasPrefix(reflect.Indirect(reflect.ValueOf(drv)).Type().PkgPath(), "github.com/lib/pq")
+}
+
+// InsertBulkCopyIn is the old name of CopyFrom.
+//
+// Deprecated: Use CopyFrom.
 func (db *DB) InsertBulkCopyIn(table string, data interface{}) error {
+	return db.CopyFrom(table, data)
+}
+
+// CopyFrom takes a table name and a slice of structs and loads
+// the records into the DB using Postgres' "COPY FROM". This is much
+// faster than InsertBulk for large amounts of rows. The columns are
+// mapped the same way Insert maps them.
+// The given data needs to be:
+//
+// *[]*strcut
+// *[]struct
+// []*struct
+// []struct
+//
+// Outside of a transaction CopyFrom starts its own transaction, which
+// needs the wrapper to be initialized using "Open".
+//
+// CopyFrom needs the driver lib/pq, other Postgres drivers like pgx's
+// database/sql adapter do not support "COPY FROM", use InsertBulk.
+func (db *DB) CopyFrom(table string, data interface{}) error {
 	var (
-		rv         reflect.Value
-		structMode bool
-		err        error
+		err error
+		txn *sql.Tx
 	)
 
-	rv, structMode, err = checkData(data)
-	if err != nil {
-		return err
+	if db.Driver != POSTGRES {
+		return fmt.Errorf("sqlpro.CopyFrom: COPY FROM is only supported for driver '%s'.", POSTGRES)
 	}
-
-	if structMode {
-		return fmt.Errorf("InsertBulk: Need Slice to insert bulk.")
+	if db.sqlDB != nil && !isPQDriver(db.sqlDB.Driver()) {
+		return fmt.Errorf("sqlpro.CopyFrom: COPY FROM needs the driver lib/pq, have: %T", db.sqlDB.Driver())
 	}
 
-	key_map := make(map[string]*fieldInfo, 0)
-	rows := make([]map[string]interface{}, 0)
-
-	if rv.Len() == 0 {
+	keys, key_map, rows, _, err := db.bulkRows(table, data, "CopyFrom")
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
 		return nil
 	}
 
-	for i := 0; i < rv.Len(); i++ {
-		row := reflect.Indirect(rv.Index(i)).Interface()
-
-		values, structInfo, err := db.valuesFromStruct(row)
-
-		if err != nil {
-			return xerrors.Errorf("sqlpro.InsertBulk error: %w", err)
+	if db.sqlTx != nil {
+		txn = db.sqlTx
+	} else {
+		if db.sqlDB == nil {
+			panic("sqlpro.DB.CopyFrom: The wrapper must be created using Open. The wrapper does not have access to the underlying sql.DB handle.")
 		}
-
-		rows = append(rows, values)
-		for key := range values {
-			key_map[key] = structInfo[key]
+		txn, err = db.sqlDB.Begin()
+		if err != nil {
+			return sqlError(err, "BEGIN TRANSACTION", []interface{}{})
 		}
 	}
 
-	txn, err := db.sqlDB.Begin()
-	if err != nil {
-		return sqlError(err, "BEGIN TRANSACTION", []interface{}{})
+	rollback := func(err error) error {
+		if txn != db.sqlTx {
+			txn.Rollback()
+		}
+		return err
 	}
 
-	keys := make([]string, 0, len(key_map))
-	for key := range key_map {
-		keys = append(keys, key)
-	}
+	copySql := pq.CopyIn(table, keys...)
 
-	stmt, err := txn.Prepare(pq.CopyIn(table, keys...))
+	stmt, err := txn.Prepare(copySql)
 	if err != nil {
-		return sqlError(err, "Prepare", []interface{}{})
+		return rollback(sqlError(err, copySql, []interface{}{}))
 	}
+	defer stmt.Close()
 
 	for _, row := range rows {
 		values := make([]interface{}, 0, len(key_map))
 		for _, key := range keys {
-			values = append(values, row[key])
+			values = append(values, db.argValue(row[key], key_map[key]))
 		}
 		_, err = stmt.Exec(values...)
 		if err != nil {
-			return sqlError(err, "Exec", values)
+			return rollback(sqlError(err, copySql, db.debugArgs(values)))
 		}
 	}
 
 	_, err = stmt.Exec()
 	if err != nil {
-		return sqlError(err, "Exec DONE", []interface{}{})
+		return rollback(sqlError(err, copySql, []interface{}{}))
+	}
+
+	if txn == db.sqlTx {
+		return nil
 	}
 
 	err = txn.Commit()
 	if err != nil {
-		return sqlError(err, "Commit DONE", []interface{}{})
+		return sqlError(err, "COMMIT", []interface{}{})
 	}
 
 	return nil
 }
 
-func (db *DB) insertStruct(table string, row interface{}) (int64, structInfo, error) {
+// errInsertSkipped is returned by insertStruct, if the conflict clause
+// set in onConflict skipped the insert
+var errInsertSkipped = errors.New("sqlpro: Insert skipped.")
 
-	values, info, err := db.valuesFromStruct(row)
-	if err != nil {
-		return 0, nil, err
-	}
+func (db *DB) insertStruct(table string, row interface{}) (int64, structInfo, error) {
 
-	sql, args, err := db.insertClauseFromValues(table, values, info)
+	values, info, err := db.tableValuesFromStruct(table, row)
 	if err != nil {
 		return 0, nil, err
 	}
 
 	if db.UseReturningForLastId {
 		pk := info.onlyPrimaryKey()
-		if pk != nil && pk.structField.Type.Kind() == reflect.Int64 {
-			sql = sql + " RETURNING " + db.Esc(pk.dbName)
+		if pk != nil && pk.integerKey() {
+			var (
+				sql  string
+				args []interface{}
+			)
+			switch db.Driver {
+			case MSSQL:
+				sql, args, err = db.insertClause(table, values, info, "OUTPUT INSERTED."+db.Esc(pk.dbName))
+			case ORACLE:
+				return db.insertReturningInto(table, values, info, pk)
+			default:
+				sql, args, err = db.insertClauseFromValues(table, values, info)
+				if db.onConflict != "" {
+					sql = sql + " " + db.onConflict
+				}
+				sql = sql + " RETURNING " + db.Esc(pk.dbName)
+			}
+			if err != nil {
+				return 0, nil, err
+			}
 
 			var insert_id int64 = 0
 			err := db.Query(&insert_id, sql, args...)
+			if db.onConflict != "" && errors.Is(err, ErrQueryReturnedZeroRows) {
+				return 0, nil, errInsertSkipped
+			}
 			if err != nil {
 				return 0, nil, err
 			}
@@ -315,6 +590,24 @@ func (db *DB) insertStruct(table string, row interface{}) (int64, structInfo, er
 		}
 	}
 
+	sql, args, err := db.insertClauseFromValues(table, values, info)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if db.onConflict != "" {
+		// exec returns the number of inserted rows here, the key
+		// is left to the caller
+		n, err := db.exec(-1, sql+" "+db.onConflict, args...)
+		if err != nil {
+			return 0, nil, err
+		}
+		if n == 0 {
+			return 0, nil, errInsertSkipped
+		}
+		return 0, info, nil
+	}
+
 	// log.Printf("SQL: %s Debug: %v", sql, db.Debug)
 	insert_id, err := db.exec(1, sql, args...)
 	if err != nil {
@@ -324,7 +617,30 @@ func (db *DB) insertStruct(table string, row interface{}) (int64, structInfo, er
 	return insert_id, info, nil
 }
 
+// insertReturningInto inserts values using Oracle's "RETURNING ... INTO"
+// and returns the key generated for pk
+func (db *DB) insertReturningInto(table string, values map[string]interface{}, info structInfo, pk *fieldInfo) (int64, structInfo, error) {
+	var id int64
+
+	insert, args, err := db.insertClauseFromValues(table, values, info)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	_, err = db.exec(1, insert+" RETURNING "+db.Esc(pk.dbName)+" INTO ?", append(args, sql.Out{Dest: &id})...)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, info, nil
+}
+
 func (db *DB) insertClauseFromValues(table string, values map[string]interface{}, info structInfo) (string, []interface{}, error) {
+	return db.insertClause(table, values, info, "")
+}
+
+// insertClause returns the INSERT statement for values, with output
+// (e.g. MSSQL's "OUTPUT INSERTED.id") added before VALUES, if set
+func (db *DB) insertClause(table string, values map[string]interface{}, info structInfo, output string) (string, []interface{}, error) {
 	cols := make([]string, 0, len(values))
 	vs := make([]string, 0, len(values))
 	args := make([]interface{}, 0, len(values))
@@ -332,118 +648,250 @@ func (db *DB) insertClauseFromValues(table string, values map[string]interface{}
 	for col, value := range values {
 		cols = append(cols, db.Esc(col))
 		vs = append(vs, "?")
-		args = append(args, db.nullValue(value, info[col]))
+		args = append(args, db.argValue(value, info[col]))
+	}
+	if output != "" {
+		output = " " + output
 	}
-	return fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s)",
-		db.Esc(table),
+	verb := "INSERT"
+	if db.insertOr != "" {
+		verb += " OR " + db.insertOr
+	}
+	return fmt.Sprintf("%s INTO %s (%s)%s VALUES(%s)",
+		verb,
+		db.EscTable(table),
 		strings.Join(cols, ","),
+		output,
 		strings.Join(vs, ","),
 	), args, nil
 }
 
-func (db *DB) updateClauseFromRow(table string, row interface{}) (string, []interface{}, error) {
+// updateClauseFromRow returns the UPDATE statement for row. If
+// columns are given, only these are set.
+func (db *DB) updateClauseFromRow(table string, row interface{}, columns ...string) (string, []interface{}, error) {
 
 	var (
-		valid    bool
-		args     []interface{}
-		pk_value interface{}
+		args []interface{}
 	)
 
-	values, structInfo, err := db.valuesFromStruct(row)
+	values, structInfo, err := db.tableValuesFromStruct(table, row)
 	if err != nil {
 		return "", nil, err
 	}
 
 	update := strings.Builder{}
-	where := strings.Builder{}
 
-	update.WriteString("UPDATE ")
-	update.WriteString(db.Esc(table))
-	update.WriteString(" SET ")
+	update.WriteString(db.updatePrefix(table))
 
-	where.WriteString(" WHERE ")
+	setValues := values
+	if len(columns) > 0 {
+		setValues = make(map[string]interface{}, len(columns))
+		for _, col := range columns {
+			fi, ok := structInfo[col]
+			if !ok {
+				return "", nil, fmt.Errorf("Column %q is not mapped in %T.", col, row)
+			}
+			if fi.primaryKey || fi.readOnly {
+				return "", nil, fmt.Errorf("Unable to update pk or readonly column %q.", col)
+			}
+			value, ok := values[col]
+			if !ok {
+				// omitted empty value
+				value = reflect.Zero(fi.structField.Type).Interface()
+				if fi.isJson && !fi.ptr {
+					value = ""
+				}
+			}
+			setValues[col] = value
+		}
+	}
 
 	idx := 0
-	for key, value := range values {
+	for key, value := range setValues {
 		if structInfo.primaryKey(key) {
 			// skip primary keys for update
-			pk_value = db.nullValue(value, structInfo[key])
-			if pk_value == nil {
-				return "", args, fmt.Errorf("Unable to build UPDATE clause with <nil> key: %s", key)
-			}
-			where.WriteString(db.Esc(key))
-			where.WriteString("=")
-			where.WriteRune(db.PlaceholderValue)
-			valid = true
-		} else {
-			if idx > 0 {
-				update.WriteString(",")
-			}
-			update.WriteString(db.Esc(key))
-			update.WriteString("=")
-			update.WriteRune(db.PlaceholderValue)
-			args = append(args, db.nullValue(value, structInfo[key]))
-			idx++
+			continue
+		}
+		if idx > 0 {
+			update.WriteString(",")
 		}
+		update.WriteString(db.Esc(key))
+		update.WriteString("=")
+		update.WriteRune(db.PlaceholderValue)
+		args = append(args, db.argValue(value, structInfo[key]))
+		idx++
 	}
 
-	if !valid {
-		return "", args, fmt.Errorf("Unable to build UPDATE clause, at least one key needed.")
+	where, whereArgs, err := db.pkWhere(values, structInfo)
+	if err != nil {
+		return "", args, xerrors.Errorf("Unable to build UPDATE clause: %w", err)
 	}
 
-	args = append(args, pk_value)
+	args = append(args, whereArgs...)
 
 	// Add where clause
-	return update.String() + where.String(), args, nil
+	return update.String() + where, args, nil
+}
+
+// pkWhere returns the WHERE clause matching all "pk" columns of
+// the row given by values.
+func (db *DB) pkWhere(values map[string]interface{}, info structInfo) (string, []interface{}, error) {
+	pks := info.primaryKeys()
+	if len(pks) == 0 {
+		return "", nil, fmt.Errorf("At least one key needed.")
+	}
+
+	where := strings.Builder{}
+	args := make([]interface{}, 0, len(pks))
+
+	where.WriteString(" WHERE ")
+
+	for idx, pk := range pks {
+		value, ok := values[pk.dbName]
+		if ok {
+			value = db.argValue(value, pk)
+		}
+		if value == nil {
+			return "", nil, fmt.Errorf("Unable to use <nil> key: %s", pk.dbName)
+		}
+		if idx > 0 {
+			where.WriteString(" AND ")
+		}
+		where.WriteString(db.Esc(pk.dbName))
+		where.WriteString("=")
+		where.WriteRune(db.PlaceholderValue)
+		args = append(args, value)
+	}
+
+	return where.String(), args, nil
 }
 
 // Update updates the given struct or slice of structs
 // The WHERE clause is put together from the "pk" columns.
 // If not all "pk" columns have non empty values, Update returns
-// an error.
+// an error. Fields tagged "autoupdate" are set to the current time.
+// Structs implementing BeforeUpdater or AfterUpdater have their hooks
+// called before and after each row is written.
+//
+// For ClickHouse, Update needs AllowMutations, see checkMutation.
 func (db *DB) Update(table string, data interface{}) error {
+	return db.update(table, data)
+}
+
+// UpdateColumns works like Update, but only sets the given columns.
+// Empty values are written as well, even for "omitempty" fields. Use
+// this to avoid overwriting columns changed concurrently.
+//
+//	err := db.UpdateColumns("user", &user, "name", "email")
+func (db *DB) UpdateColumns(table string, data interface{}, columns ...string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("sqlpro.UpdateColumns: Need at least one column to update.")
+	}
+	return db.update(table, data, columns...)
+}
+
+func (db *DB) update(table string, data interface{}, columns ...string) error {
 	var (
 		rv         reflect.Value
 		structMode bool
 		err        error
 		update     string
 		args       []interface{}
+		rows       []reflect.Value
 	)
 
+	err = db.checkMutation("Update")
+	if err != nil {
+		return err
+	}
+
 	rv, structMode, err = checkData(data)
 	if err != nil {
 		return err
 	}
 
 	if structMode {
-		update, args, err = db.updateClauseFromRow(table, rv.Interface())
+		rows = append(rows, rv)
+	} else {
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, reflect.Indirect(rv.Index(i)))
+		}
+	}
+
+	for _, row := range rows {
+		err = db.runHook(row, beforeUpdate)
 		if err != nil {
 			return err
 		}
-		_, err = db.exec(1, update, args...)
+		err = db.validate(row)
 		if err != nil {
 			return err
 		}
-	} else {
-		for i := 0; i < rv.Len(); i++ {
-			row := reflect.Indirect(rv.Index(i))
-			update, args, err = db.updateClauseFromRow(table, row.Interface())
+
+		cols := columns
+		tracked := false
+		if len(cols) == 0 && row.CanAddr() {
+			var changed []string
+			changed, tracked, err = db.changedColumns(table, row.Addr().Interface())
 			if err != nil {
 				return err
 			}
-			_, err = db.exec(1, update, args...)
+			if tracked {
+				if len(changed) == 0 {
+					// nothing to update
+					continue
+				}
+				cols = changed
+			}
+		}
+
+		row = db.stampTimes(row, false)
+		if len(cols) > 0 {
+			// always set the "autoupdate" columns
+			set := make(map[string]bool, len(cols))
+			for _, col := range cols {
+				set[col] = true
+			}
+			for _, col := range db.autoUpdateColumns(row.Type(), table) {
+				if !set[col] {
+					cols = append(cols, col)
+				}
+			}
+		}
+
+		update, args, err = db.updateClauseFromRow(table, row.Interface(), cols...)
+		if err != nil {
+			return err
+		}
+		err = db.withHistory(table, row, func(db *DB) error {
+			_, err := db.exec(1, update, args...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if tracked {
+			err = db.Track(row.Addr().Interface())
 			if err != nil {
 				return err
 			}
 		}
+
+		err = db.runHook(row, afterUpdate)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 // Save saves the given data. It performs an INSERT if the only
-// primary key is zero, and and UPDATE if it is not. It panics
-// if it the record has no primary key or less than one
+// primary key is zero, and and UPDATE if it is not. For composite
+// or non integer primary keys, Save performs an INSERT if all keys
+// are zero or no row with the keys exists, and an UPDATE otherwise. Save returns an
+// error if the record has no primary key.
 func (db *DB) Save(table string, data interface{}) error {
 
 	rv, structMode, err := checkData(data)
@@ -468,23 +916,45 @@ func (db *DB) Save(table string, data interface{}) error {
 func (db *DB) saveRow(table string, data interface{}) error {
 	row := reflect.Indirect(reflect.ValueOf(data))
 
-	values, info, err := db.valuesFromStruct(row.Interface())
+	values, info, err := db.tableValuesFromStruct(table, row.Interface())
 	if err != nil {
 		return err
 	}
-	pk := info.onlyPrimaryKey()
+	pks := info.primaryKeys()
 
-	if pk == nil {
-		return fmt.Errorf("Save needs a struct with exactly one 'pk' field.")
+	if len(pks) == 0 {
+		return fmt.Errorf("Save needs a struct with at least one 'pk' field.")
+	}
+
+	allZero := true
+	for _, pk := range pks {
+		pk_value, ok := values[pk.dbName]
+		if ok && !isZero(pk_value) {
+			allZero = false
+		}
 	}
 
-	pk_value, ok := values[pk.dbName]
-	if !ok || isZero(pk_value) {
+	if allZero {
 		return db.Insert(table, data)
-	} else {
-		return db.Update(table, data)
 	}
 
+	if len(pks) > 1 || !pks[0].integerKey() {
+		var count int64
+
+		where, args, err := db.pkWhere(values, info)
+		if err != nil {
+			return err
+		}
+		err = db.Query(&count, "SELECT count(*) FROM "+db.EscTable(table)+where, args...)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return db.Insert(table, data)
+		}
+	}
+
+	return db.Update(table, data)
 }
 
 // valuesFromStruct returns the relevant values
@@ -500,10 +970,10 @@ func (db *DB) valuesFromStruct(data interface{}) (map[string]interface{}, struct
 	values = make(map[string]interface{}, 0)
 	dataV = reflect.ValueOf(data)
 
-	info = getStructInfo(dataV.Type())
+	info = db.structInfo(dataV.Type())
 
 	for _, fieldInfo := range info {
-		dataF := dataV.FieldByName(fieldInfo.name)
+		dataF := dataV.FieldByIndex(fieldInfo.structField.Index)
 
 		actualData := dataF.Interface()
 		isZero := isZero(actualData)
@@ -516,6 +986,20 @@ func (db *DB) valuesFromStruct(data interface{}) (map[string]interface{}, struct
 			continue
 		}
 
+		if len(fieldInfo.normalizers) > 0 {
+			actualData, err = normalize(actualData, fieldInfo)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if len(fieldInfo.enum) > 0 {
+			actualData, err = enumValue(actualData, fieldInfo)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
 		if fieldInfo.isJson {
 			if isZero {
 				if fieldInfo.ptr {
@@ -531,6 +1015,14 @@ func (db *DB) valuesFromStruct(data interface{}) (map[string]interface{}, struct
 			}
 		}
 
+		if fieldInfo.isHstore {
+			m, ok := actualData.(map[string]string)
+			if !ok {
+				return nil, nil, fmt.Errorf("Unable to use %s as hstore, need map[string]string.", fieldInfo.structField.Type)
+			}
+			actualData = encodeHstore(m)
+		}
+
 		values[fieldInfo.dbName] = actualData
 		// log.Printf("Name: %s Value: %v %v", fieldInfo.name, dataF.Interface(), isZero)
 	}
@@ -538,9 +1030,29 @@ func (db *DB) valuesFromStruct(data interface{}) (map[string]interface{}, struct
 }
 
 // isZero returns true if given "x" equals Go's empty value.
+// Values implementing driver.Valuer, like sql.NullString, are
+// also empty if they are NULL. Decimals are empty if they are zero,
+// []byte if it has no bytes.
 func isZero(x interface{}) bool {
 	if x == nil {
 		return true
 	}
+	xv := reflect.ValueOf(x)
+	if xv.Kind() == reflect.Ptr && xv.IsNil() {
+		return true
+	}
+	if d, ok := x.(Decimal); ok {
+		return d.IsZero()
+	}
+	if b, ok := x.([]byte); ok {
+		// nil and empty binary data are both empty
+		return len(b) == 0
+	}
+	if vr, ok := x.(driver.Valuer); ok {
+		v, err := vr.Value()
+		if err == nil && v == nil {
+			return true
+		}
+	}
 	return reflect.DeepEqual(x, reflect.Zero(reflect.TypeOf(x)).Interface())
 }
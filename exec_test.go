@@ -0,0 +1,185 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestInsertBulkPartial(t *testing.T) {
+	var count int64
+
+	err := db.Exec("CREATE TABLE test_partial(a INTEGER PRIMARY KEY, b TEXT NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type partialRow struct {
+		A int64   `db:"a"`
+		B *string `db:"b"`
+	}
+
+	b := "ok"
+	rows := make([]partialRow, 0)
+	for i := 1; i <= 10; i++ {
+		rows = append(rows, partialRow{A: int64(i), B: &b})
+	}
+	rows[3].B = nil // NOT NULL violation
+	rows[7].A = 1   // duplicate key
+
+	// 2 columns, 4 rows per statement
+	db2 := *db
+	db2.MaxBulkParams = 8
+
+	report, err := db2.InsertBulkPartial("test_partial", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 || report[0].Index != 3 || report[1].Index != 7 {
+		t.Errorf("Expected rows 3 and 7 to fail, got: %v", report)
+	}
+
+	// all rows exist already
+	report, err = db2.InsertBulkPartial("test_partial", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 10 {
+		t.Errorf("Expected all rows to fail, got: %v", report)
+	}
+
+	err = db.Query(&count, "SELECT count(*) FROM test_partial")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 rows, got: %d", count)
+	}
+}
+
+type testRowColumns struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Name  string `db:"name"`
+	Email string `db:"email,omitempty"`
+	Note  string `db:"note"`
+}
+
+func TestUpdateColumns(t *testing.T) {
+	var row2 testRowColumns
+
+	err := db.Exec("CREATE TABLE test_columns(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, email TEXT, note TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowColumns{Name: "henk", Email: "henk@example.com", Note: "original"}
+	err = db.Insert("test_columns", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent change of note
+	err = db.Exec("UPDATE test_columns SET note = 'changed' WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row.Name = "henk2"
+	row.Email = ""
+	err = db.UpdateColumns("test_columns", &row, "name", "email")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&row2, "SELECT * FROM test_columns WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := testRowColumns{ID: row.ID, Name: "henk2", Email: "", Note: "changed"}
+	if row2 != exp {
+		t.Errorf("Expected %v, got: %v", exp, row2)
+	}
+
+	for _, cols := range [][]string{{}, {"id"}, {"unknown"}} {
+		if db.UpdateColumns("test_columns", &row, cols...) == nil {
+			t.Errorf("Expected error for columns %v", cols)
+		}
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	rows := []testRow{{B: "copy"}}
+
+	err := sqliteDB.CopyFrom("test", rows)
+	if err == nil || !strings.Contains(err.Error(), "only supported") {
+		t.Errorf("Expected error for sqlite, got: %v", err)
+	}
+	err = sqliteDB.InsertBulkCopyIn("test", rows)
+	if err == nil || !strings.Contains(err.Error(), "only supported") {
+		t.Errorf("Expected error for InsertBulkCopyIn with sqlite, got: %v", err)
+	}
+
+	// a Postgres dialect on a driver other than lib/pq
+	pg := *sqliteDB
+	pg.Driver = POSTGRES
+	err = pg.CopyFrom("test", rows)
+	if err == nil || !strings.Contains(err.Error(), "lib/pq") {
+		t.Errorf("Expected error for driver without COPY support, got: %v", err)
+	}
+
+	if !isPQDriver(&pq.Driver{}) || isPQDriver(sqliteDB.sqlDB.Driver()) {
+		t.Errorf("Unexpected driver detection")
+	}
+}
+
+// bulkRecorder records the number of args of each statement, each
+// statement affects one row per cols args
+type bulkRecorder struct {
+	cols int
+	args []int
+}
+
+func (br *bulkRecorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, fmt.Errorf("bulkRecorder: Query not supported")
+}
+
+func (br *bulkRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	br.args = append(br.args, len(args))
+	return driver.RowsAffected(len(args) / br.cols), nil
+}
+
+func TestMaxBulkParams(t *testing.T) {
+	type bulkRow struct {
+		A int64 `db:"a"`
+		B int64 `db:"b"`
+		C int64 `db:"c"`
+	}
+
+	for drv, limit := range map[dbDriver]int{POSTGRES: 65535, MSSQL: 2100, SQLITE3: 999} {
+		rec := &bulkRecorder{cols: 3}
+		bdb := New(rec)
+		err := bdb.setDriver(drv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the recorder has no last insert id
+		bdb.SupportsLastInsertId = false
+		if bdb.MaxBulkParams != limit {
+			t.Errorf("%s: Expected MaxBulkParams %d, got: %d", drv, limit, bdb.MaxBulkParams)
+		}
+
+		// one row more than fits into one statement
+		rows := make([]bulkRow, limit/3+1)
+		err = bdb.InsertBulk("test_bulk", rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.args) != 2 || rec.args[0] != limit/3*3 || rec.args[1] != 3 {
+			t.Errorf("%s: Expected a split after %d args, got: %v", drv, limit/3*3, rec.args)
+		}
+	}
+}
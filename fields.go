@@ -0,0 +1,166 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Fields returns a copy which selects only the given columns in Get,
+// First, Last and FindInBatches. All other fields of the target are
+// left zero. Use this for list endpoints which do not need heavy
+// columns.
+//
+// Without Fields, these functions select all columns but the ones of
+// fields tagged "lazy". Use LoadField to load these.
+//
+//	err := db.Fields("id", "name").Get(&users, "user", "active = ?", true)
+func (db *DB) Fields(columns ...string) *DB {
+	newDB := *db
+	newDB.fields = columns
+	return &newDB
+}
+
+// Get loads the rows of table matching condition into target, which
+// can be anything Query accepts. For a pointer to a struct, target is
+// reset and set to the first row. An empty condition matches all rows.
+// Soft deleted rows are skipped, see Delete.
+func (db *DB) Get(target interface{}, table string, condition string, args ...interface{}) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr {
+		return fmt.Errorf("sqlpro.Get: Target needs to be a pointer, have: %T", target)
+	}
+	condition = db.scopeCondition(targetV.Type(), table, condition)
+
+	cols, err := db.selectList(targetV.Type(), table)
+	if err != nil {
+		return err
+	}
+	from, err := db.fromTable(table)
+	if err != nil {
+		return err
+	}
+
+	query := Fragment("SELECT " + cols + " FROM").Append(from)
+	if condition != "" {
+		query = query.Append(Fragment("WHERE "+condition, args...))
+	}
+
+	if targetV.Elem().Kind() == reflect.Struct {
+		targetV.Elem().Set(reflect.Zero(targetV.Elem().Type()))
+	}
+
+	return db.Query(target, query.SQL, query.Args...)
+}
+
+// LoadField loads the column of the given field into target, which
+// needs to be a pointer to a struct with its primary keys set. field
+// is the name of the struct field or its column. Use this for fields
+// tagged "lazy".
+//
+//	err := db.LoadField(&doc, "document", "Payload")
+func (db *DB) LoadField(target interface{}, table string, field string) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.LoadField: Target needs to be a pointer to a struct, have: %T", target)
+	}
+
+	info := db.tableStructInfo(targetV.Elem().Type(), table)
+
+	var fi *fieldInfo
+	for _, info := range info {
+		if info.name == field || info.dbName == field || info.mappedFrom == field {
+			fi = info
+			break
+		}
+	}
+	if fi == nil {
+		return fmt.Errorf("sqlpro.LoadField: Field %q is not mapped in %T.", field, target)
+	}
+
+	values := make(map[string]interface{}, 0)
+	for _, pk := range info.primaryKeys() {
+		values[pk.dbName] = targetV.Elem().FieldByIndex(pk.structField.Index).Interface()
+	}
+	where, args, err := db.pkWhere(values, info)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.LoadField: %w", err)
+	}
+
+	col := db.Esc(fi.dbName)
+	if fi.mappedFrom != "" {
+		col += " AS " + db.Esc(fi.mappedFrom)
+	}
+
+	return db.Query(target, "SELECT "+col+" FROM "+db.EscTable(table)+where, args...)
+}
+
+// selectList returns the column list to select for a target of
+// type t, which is "*" unless Fields was used, t has fields tagged
+// "lazy" or a mapping for table was registered using MapStruct. The
+// given columns are added to the list, if missing. All columns are
+// named as in the "db" tag.
+func (db *DB) selectList(t reflect.Type, table string, always ...string) (string, error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	var (
+		info    structInfo
+		mapping map[string]string
+	)
+	if t.Kind() == reflect.Struct {
+		info = db.structInfo(t)
+		mapping = db.columnMapping(t, table)
+	}
+
+	fields := db.fields
+	if len(fields) == 0 {
+		hasLazy := false
+		for _, fi := range info {
+			if fi.lazy {
+				hasLazy = true
+				break
+			}
+		}
+		if !hasLazy && len(mapping) == 0 {
+			return "*", nil
+		}
+
+		// all columns but the lazy ones, in struct order
+		fis := make([]*fieldInfo, 0, len(info))
+		for _, fi := range info {
+			if !fi.lazy {
+				fis = append(fis, fi)
+			}
+		}
+		sort.Slice(fis, func(i, j int) bool {
+			return fis[i].before(fis[j])
+		})
+		for _, fi := range fis {
+			fields = append(fields, fi.dbName)
+		}
+	}
+
+	cols := make([]string, 0, len(fields)+len(always))
+	seen := make(map[string]bool, 0)
+	for _, col := range append(append([]string{}, fields...), always...) {
+		if seen[col] {
+			continue
+		}
+		if info != nil && !info.hasDbName(col) {
+			return "", fmt.Errorf("sqlpro.Fields: Column %q is not mapped in %s.", col, t)
+		}
+		seen[col] = true
+		if to, ok := mapping[col]; ok {
+			cols = append(cols, db.Esc(to)+" AS "+db.Esc(col))
+			continue
+		}
+		cols = append(cols, db.Esc(col))
+	}
+
+	return strings.Join(cols, ", "), nil
+}
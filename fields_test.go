@@ -0,0 +1,106 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	var (
+		row  testRowValuer
+		rows []*testRowValuer
+	)
+
+	err := db.Exec("CREATE TABLE test_fields(id INTEGER PRIMARY KEY AUTOINCREMENT, tags TEXT, price INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Insert("test_fields", []testRowValuer{
+		{Tags: testCSV{"a", "b"}, Price: testMoney{150}},
+		{Tags: testCSV{"c"}, Price: testMoney{99}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row.Tags = testCSV{"stale"}
+	err = db.Fields("id", "price").Get(&row, "test_fields", "price = ?", 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID == 0 || row.Price.Cents != 150 || row.Tags != nil {
+		t.Errorf("Expected only id and price to be set: %v", row)
+	}
+
+	err = db.Fields("tags").Get(&rows, "test_fields", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].ID != 0 || len(rows[0].Tags) == 0 {
+		t.Errorf("Expected only tags to be set: %v", rows)
+	}
+
+	err = db.Fields("price").Last(&row, "test_fields", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if row.Price.Cents != 99 || row.ID != 0 {
+		t.Errorf("Unexpected last row: %v", row)
+	}
+
+	err = db.Fields("password").Get(&row, "test_fields", "")
+	if err == nil {
+		t.Errorf("Expected error for unmapped column.")
+	}
+}
+
+type testRowLazy struct {
+	ID      int64  `db:"id,pk,omitempty"`
+	Name    string `db:"name"`
+	Payload string `db:"payload,lazy"`
+}
+
+func TestLazyField(t *testing.T) {
+	var (
+		row  testRowLazy
+		rows []testRowLazy
+	)
+
+	err := db.Exec("CREATE TABLE test_lazy(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, payload TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_lazy", &testRowLazy{Name: "doc", Payload: "heavy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Get(&rows, "test_lazy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Name != "doc" || rows[0].Payload != "" {
+		t.Errorf("Expected payload not to be loaded: %v", rows)
+	}
+
+	err = db.First(&row, "test_lazy", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Payload != "" {
+		t.Errorf("Expected payload not to be loaded: %v", row)
+	}
+
+	err = db.LoadField(&row, "test_lazy", "Payload")
+	if err != nil {
+		t.Error(err)
+	}
+	if row.Payload != "heavy" || row.Name != "doc" {
+		t.Errorf("Expected payload to be loaded: %v", row)
+	}
+
+	err = db.LoadField(&testRowLazy{}, "test_lazy", "payload")
+	if err == nil {
+		t.Errorf("Expected error for missing primary key.")
+	}
+}
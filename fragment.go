@@ -0,0 +1,397 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLFragment is a piece of SQL together with its arguments. Use
+// Append and JoinFragments to put queries together without maintaining
+// the SQL and the arguments in parallel.
+//
+// Postgres style "$1" placeholders are renumbered when fragments are
+// concatenated. "?" placeholders are positional and need no renumbering.
+//
+// A fragment passed as argument is embedded as subquery:
+//
+//	active := Fragment("SELECT user_id FROM session WHERE expires > ?", now)
+//	err := db.Query(&users, "SELECT * FROM user WHERE id IN ?", active)
+type SQLFragment struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Fragment returns a new fragment for the given SQL and arguments.
+func Fragment(sqlS string, args ...interface{}) SQLFragment {
+	return SQLFragment{SQL: sqlS, Args: args}
+}
+
+// Expr returns an SQL expression to be used as value in Insert,
+// Update and UpdateMap. Instead of being bound as argument, the
+// expression is written into the statement, with its args bound.
+//
+//	_, err := db.UpdateMap("post", map[string]interface{}{
+//		"views":      Expr("views + ?", 1),
+//		"updated_at": Expr("CURRENT_TIMESTAMP"),
+//	}, "id = ?", id)
+//
+// To use Expr for a struct field, the field needs to be of type
+// interface{}. InsertBulk does not support expressions.
+func Expr(sqlS string, args ...interface{}) SQLFragment {
+	return Fragment(sqlS, args...)
+}
+
+// Append returns a new fragment with the given fragments appended,
+// separated by a space.
+func (f SQLFragment) Append(others ...SQLFragment) SQLFragment {
+	return JoinFragments(" ", append([]SQLFragment{f}, others...)...)
+}
+
+// IsEmpty returns true if the fragment has no SQL.
+func (f SQLFragment) IsEmpty() bool {
+	return strings.TrimSpace(f.SQL) == ""
+}
+
+// JoinFragments concatenates the given fragments using sep as
+// separator. Empty fragments are skipped, so that optional
+// conditions can be joined with " AND ".
+func JoinFragments(sep string, frags ...SQLFragment) SQLFragment {
+	var (
+		sb   strings.Builder
+		args []interface{}
+	)
+
+	sb = strings.Builder{}
+	args = make([]interface{}, 0)
+
+	for _, frag := range frags {
+		if frag.IsEmpty() {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(shiftDollarPlaceholders(frag.SQL, len(args)))
+		args = append(args, frag.Args...)
+	}
+
+	return SQLFragment{SQL: sb.String(), Args: args}
+}
+
+// shiftDollarPlaceholders adds offset to all "$N" placeholders in sqlS.
+// Placeholders inside single quoted strings are left alone.
+func shiftDollarPlaceholders(sqlS string, offset int) string {
+	return shiftPlaceholders(sqlS, "$", offset)
+}
+
+// shiftPlaceholders adds offset to all numbered placeholders in sqlS,
+// which start with prefix, e.g. "$" or "@p". Placeholders inside
+// single quoted strings are left alone.
+func shiftPlaceholders(sqlS string, prefix string, offset int) string {
+	var (
+		sb      strings.Builder
+		inQuote bool
+	)
+
+	if offset == 0 || !strings.Contains(sqlS, prefix) {
+		return sqlS
+	}
+
+	prefixRunes := []rune(prefix)
+
+	sb = strings.Builder{}
+	runes := []rune(sqlS)
+
+	for i := 0; i < len(runes); i++ {
+		currRune := runes[i]
+
+		if currRune == '\'' {
+			inQuote = !inQuote
+		}
+
+		if inQuote || !hasRunePrefix(runes[i:], prefixRunes) {
+			sb.WriteRune(currRune)
+			continue
+		}
+
+		start := i + len(prefixRunes)
+		j := start
+		for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+			j++
+		}
+		if j == start {
+			// prefix not followed by a number
+			sb.WriteRune(currRune)
+			continue
+		}
+
+		n, _ := strconv.Atoi(string(runes[start:j]))
+		sb.WriteString(prefix)
+		sb.WriteString(strconv.Itoa(n + offset))
+		i = j - 1
+	}
+
+	return sb.String()
+}
+
+// queries matching selectTopRe and not matching noTopRe are paginated
+// using TOP for MSSQL
+var (
+	selectTopRe = regexp.MustCompile(`^\s*SELECT\s+(DISTINCT\s+)?`)
+	noTopRe     = regexp.MustCompile(`\b(UNION|INTERSECT|EXCEPT|TOP)\b`)
+)
+
+// hasRunePrefix returns true if runes start with prefix
+func hasRunePrefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// From returns a "FROM" fragment for table, which can have an alias,
+// e.g. "user u" or "user AS u". Table and alias are escaped.
+//
+//	query := Fragment("SELECT u.name, o.total").Append(
+//		db.From("user u"),
+//		db.LeftJoin("orders o", "o.user_id = u.id AND o.status = ?", "paid"),
+//	)
+func (db *DB) From(table string) SQLFragment {
+	return Fragment("FROM " + db.tableRef(table))
+}
+
+// Join returns a "JOIN ... ON ..." fragment for table, which can have
+// an alias like in From. The on condition is used as is, with args
+// as arguments.
+func (db *DB) Join(table string, on string, args ...interface{}) SQLFragment {
+	return Fragment("JOIN "+db.tableRef(table)+" ON "+on, args...)
+}
+
+// LeftJoin works like Join, using "LEFT JOIN".
+func (db *DB) LeftJoin(table string, on string, args ...interface{}) SQLFragment {
+	return Fragment("LEFT JOIN "+db.tableRef(table)+" ON "+on, args...)
+}
+
+// tableRef returns table escaped, with its optional alias escaped
+func (db *DB) tableRef(table string) string {
+	parts := strings.Fields(table)
+	if len(parts) == 3 && strings.EqualFold(parts[1], "AS") {
+		parts = []string{parts[0], parts[2]}
+	}
+	switch len(parts) {
+	case 1:
+		return db.EscTable(parts[0])
+	case 2:
+		return db.EscTable(parts[0]) + " " + db.Esc(parts[1])
+	default:
+		// not a table with alias, the database reports an unknown table
+		return db.EscTable(table)
+	}
+}
+
+// WhereEq returns a fragment comparing column to value. For a NULL
+// value, which is nil, a nil pointer or a driver.Valuer returning
+// nil, "column IS NULL" is returned, as "column = NULL" never matches.
+//
+//	where := JoinFragments(" AND ", db.WhereEq("parent_id", filter.ParentID))
+func (db *DB) WhereEq(column string, value interface{}) SQLFragment {
+	if isNull(value) {
+		return Fragment(db.Esc(column) + " IS NULL")
+	}
+	return Fragment(db.Esc(column)+" = ?", value)
+}
+
+// WhereNotEq returns a fragment matching all rows where column is not
+// value. For a NULL value "column IS NOT NULL" is returned. Otherwise
+// rows with a NULL column are matched as well, as "column <> value"
+// alone would skip them.
+func (db *DB) WhereNotEq(column string, value interface{}) SQLFragment {
+	col := db.Esc(column)
+	if isNull(value) {
+		return Fragment(col + " IS NOT NULL")
+	}
+	return Fragment("("+col+" <> ? OR "+col+" IS NULL)", value)
+}
+
+// WhereTrue returns a fragment matching rows where the boolean column
+// is true. Rows with NULL are not matched, use WhereUnknown for those.
+func (db *DB) WhereTrue(column string) SQLFragment {
+	return db.whereBool(column, true)
+}
+
+// WhereFalse returns a fragment matching rows where the boolean column
+// is false. Rows with NULL are not matched, so WhereFalse is not the
+// negation of WhereTrue.
+func (db *DB) WhereFalse(column string) SQLFragment {
+	return db.whereBool(column, false)
+}
+
+// WhereUnknown returns a fragment matching rows where the boolean
+// column is NULL.
+func (db *DB) WhereUnknown(column string) SQLFragment {
+	return Fragment(db.Esc(column) + " IS NULL")
+}
+
+func (db *DB) whereBool(column string, value bool) SQLFragment {
+	switch db.Driver {
+	case MSSQL, ORACLE:
+		// no boolean type, BIT or NUMBER(1) are used
+		if value {
+			return Fragment(db.Esc(column) + " = 1")
+		}
+		return Fragment(db.Esc(column) + " = 0")
+	default:
+		if value {
+			return Fragment(db.Esc(column) + " IS TRUE")
+		}
+		return Fragment(db.Esc(column) + " IS FALSE")
+	}
+}
+
+// WhereNullSafeEq returns a fragment comparing column to value where
+// NULL equals NULL, like "IS NOT DISTINCT FROM". Unlike WhereEq this
+// works for comparing columns to values unknown when building the
+// query, e.g. inside prepared statements. The syntax of the db's
+// driver is used.
+func (db *DB) WhereNullSafeEq(column string, value interface{}) SQLFragment {
+	col := db.Esc(column)
+	switch db.Driver {
+	case POSTGRES:
+		return Fragment(col+" IS NOT DISTINCT FROM ?", value)
+	case SQLITE3:
+		return Fragment(col+" IS ?", value)
+	case ORACLE:
+		return Fragment("DECODE("+col+", ?, 1, 0) = 1", value)
+	default:
+		return Fragment("("+col+" = ? OR ("+col+" IS NULL AND ? IS NULL))", value, value)
+	}
+}
+
+// WhereNullSafeNotEq is the negation of WhereNullSafeEq, like "IS
+// DISTINCT FROM".
+func (db *DB) WhereNullSafeNotEq(column string, value interface{}) SQLFragment {
+	col := db.Esc(column)
+	switch db.Driver {
+	case POSTGRES:
+		return Fragment(col+" IS DISTINCT FROM ?", value)
+	case SQLITE3:
+		return Fragment(col+" IS NOT ?", value)
+	case ORACLE:
+		return Fragment("DECODE("+col+", ?, 1, 0) = 0", value)
+	default:
+		return Fragment("(("+col+" IS NULL AND ? IS NOT NULL) OR ("+col+" IS NOT NULL AND (? IS NULL OR "+col+" <> ?)))", value, value, value)
+	}
+}
+
+// isNull returns true if value is stored as NULL
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return true
+	}
+	if vr, ok := value.(driver.Valuer); ok {
+		v, err := vr.Value()
+		return err == nil && v == nil
+	}
+	return false
+}
+
+// OrderBySafe validates a user supplied sort order and returns the
+// matching ORDER BY clause as fragment. The input is a comma separated
+// list of columns, each either prefixed with "-" (descending) or "+"
+// (ascending), or followed by "ASC" or "DESC", e.g.
+//
+// "name,-created_at"
+// "name asc, created_at desc"
+//
+// Only columns listed in allowed are accepted, matched case
+// insensitively. For an empty input an empty fragment is returned.
+func (db *DB) OrderBySafe(input string, allowed ...string) (SQLFragment, error) {
+	parts := make([]string, 0)
+
+	for _, item := range strings.Split(input, ",") {
+		var (
+			col, dir string
+		)
+
+		fields := strings.Fields(item)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			col = fields[0]
+			dir = "ASC"
+			if strings.HasPrefix(col, "-") {
+				col = col[1:]
+				dir = "DESC"
+			} else if strings.HasPrefix(col, "+") {
+				col = col[1:]
+			}
+		case 2:
+			col = fields[0]
+			dir = strings.ToUpper(fields[1])
+			if dir != "ASC" && dir != "DESC" {
+				return SQLFragment{}, fmt.Errorf("sqlpro.OrderBySafe: Unknown sort direction %q.", fields[1])
+			}
+		default:
+			return SQLFragment{}, fmt.Errorf("sqlpro.OrderBySafe: Unable to parse sort order %q.", item)
+		}
+
+		found := ""
+		for _, a := range allowed {
+			if strings.EqualFold(a, col) {
+				found = a
+				break
+			}
+		}
+		if found == "" {
+			return SQLFragment{}, fmt.Errorf("sqlpro.OrderBySafe: Sorting by %q is not allowed.", col)
+		}
+
+		parts = append(parts, db.Esc(found)+" "+dir)
+	}
+
+	if len(parts) == 0 {
+		return SQLFragment{}, nil
+	}
+
+	return Fragment("ORDER BY " + strings.Join(parts, ", ")), nil
+}
+
+// Paginate returns the query limited to limit rows, starting after
+// offset rows, using the syntax of the db's driver. MSSQL and Oracle
+// use "OFFSET ... ROWS FETCH NEXT ... ROWS ONLY", all other drivers
+// "LIMIT ... OFFSET ...". As MSSQL requires an ORDER BY for OFFSET,
+// an ORDER BY (SELECT NULL) is added to queries without one. For
+// MSSQL, a single SELECT without offset uses "SELECT TOP (...)".
+func (db *DB) Paginate(query SQLFragment, limit, offset int64) SQLFragment {
+	switch db.Driver {
+	case MSSQL, ORACLE:
+		if db.Driver == MSSQL && offset == 0 {
+			if m := selectTopRe.FindStringSubmatchIndex(maskSQL(query.SQL)); m != nil && !noTopRe.MatchString(maskSQL(query.SQL)) {
+				return SQLFragment{
+					SQL:  query.SQL[:m[1]] + "TOP (?) " + query.SQL[m[1]:],
+					Args: append([]interface{}{limit}, query.Args...),
+				}
+			}
+		}
+		if db.Driver == MSSQL && !strings.Contains(strings.ToUpper(query.SQL), "ORDER BY") {
+			query = query.Append(Fragment("ORDER BY (SELECT NULL)"))
+		}
+		return query.Append(Fragment("OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", offset, limit))
+	default:
+		return query.Append(Fragment("LIMIT ? OFFSET ?", limit, offset))
+	}
+}
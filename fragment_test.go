@@ -0,0 +1,307 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFragmentAppend(t *testing.T) {
+	where := JoinFragments(" AND ",
+		Fragment("a = $1", 1),
+		Fragment(""),
+		Fragment("b IN ($1, $2) AND c = '$1'", "x", "y"),
+	)
+
+	query := Fragment("SELECT * FROM test WHERE").Append(where, Fragment("LIMIT $1", 10))
+
+	expSql := "SELECT * FROM test WHERE a = $1 AND b IN ($2, $3) AND c = '$1' LIMIT $4"
+	if query.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, query.SQL)
+	}
+	if len(query.Args) != 4 || query.Args[0] != 1 || query.Args[1] != "x" || query.Args[3] != 10 {
+		t.Errorf("Unexpected args: %v", query.Args)
+	}
+}
+
+func TestFragmentQuery(t *testing.T) {
+	var count int64
+
+	query := Fragment("SELECT count(*) FROM (SELECT 1 AS a UNION SELECT 2 UNION SELECT 3)").Append(
+		Fragment("WHERE"),
+		JoinFragments(" AND ", Fragment("a > ?", 0), Fragment("a IN ?", []int64{1, 2})),
+	)
+
+	err := db.Query(&count, query.SQL, query.Args...)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected count 2, got: %d", count)
+	}
+}
+
+func TestOrderBySafe(t *testing.T) {
+	allowed := []string{"name", "created_at"}
+
+	orderBy, err := db.OrderBySafe("Name, -created_at", allowed...)
+	if err != nil {
+		t.Error(err)
+	}
+	expSql := `ORDER BY "name" ASC, "created_at" DESC`
+	if orderBy.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, orderBy.SQL)
+	}
+
+	orderBy, err = db.OrderBySafe("created_at desc", allowed...)
+	if err != nil {
+		t.Error(err)
+	}
+	if orderBy.SQL != `ORDER BY "created_at" DESC` {
+		t.Errorf("Unexpected order by: %q", orderBy.SQL)
+	}
+
+	orderBy, err = db.OrderBySafe("", allowed...)
+	if err != nil || !orderBy.IsEmpty() {
+		t.Errorf("Expected empty order by, got: %q %v", orderBy.SQL, err)
+	}
+
+	for _, input := range []string{"password", "name; DROP TABLE test", "name sideways"} {
+		_, err = db.OrderBySafe(input, allowed...)
+		if err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	var ids []int64
+
+	query := Fragment("SELECT a FROM (SELECT 1 AS a UNION SELECT 2 UNION SELECT 3) ORDER BY a")
+
+	page := db.Paginate(query, 2, 1)
+	err := db.Query(&ids, page.SQL, page.Args...)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("Expected ids 2, 3, got: %v", ids)
+	}
+
+	db2 := *db
+	db2.Driver = MSSQL
+	page = db2.Paginate(Fragment("SELECT a FROM t"), 10, 20)
+	expSql := "SELECT a FROM t ORDER BY (SELECT NULL) OFFSET ? ROWS FETCH NEXT ? ROWS ONLY"
+	if page.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, page.SQL)
+	}
+	if len(page.Args) != 2 || page.Args[0] != int64(20) || page.Args[1] != int64(10) {
+		t.Errorf("Unexpected args: %v", page.Args)
+	}
+
+	page = db2.Paginate(Fragment("SELECT DISTINCT a FROM t WHERE b = ?", 1), 10, 0)
+	expSql = "SELECT DISTINCT TOP (?) a FROM t WHERE b = ?"
+	if page.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, page.SQL)
+	}
+	if len(page.Args) != 2 || page.Args[0] != int64(10) || page.Args[1] != 1 {
+		t.Errorf("Unexpected args: %v", page.Args)
+	}
+
+	page = db2.Paginate(Fragment("SELECT a FROM t UNION SELECT b FROM u"), 10, 0)
+	if !strings.HasSuffix(page.SQL, "OFFSET ? ROWS FETCH NEXT ? ROWS ONLY") {
+		t.Errorf("Expected OFFSET for UNION, got %q", page.SQL)
+	}
+}
+
+func TestWhereEq(t *testing.T) {
+	var (
+		nilInt *int64
+		count  int64
+	)
+	one := int64(1)
+
+	for _, tc := range []struct {
+		frag   SQLFragment
+		expSql string
+		args   int
+	}{
+		{db.WhereEq("a", nil), `"a" IS NULL`, 0},
+		{db.WhereEq("a", nilInt), `"a" IS NULL`, 0},
+		{db.WhereEq("a", NullString{}), `"a" IS NULL`, 0},
+		{db.WhereEq("a", &one), `"a" = ?`, 1},
+		{db.WhereNotEq("a", nilInt), `"a" IS NOT NULL`, 0},
+		{db.WhereNotEq("a", 1), `("a" <> ? OR "a" IS NULL)`, 1},
+	} {
+		if tc.frag.SQL != tc.expSql || len(tc.frag.Args) != tc.args {
+			t.Errorf("Expected %q with %d args, got %q %v", tc.expSql, tc.args, tc.frag.SQL, tc.frag.Args)
+		}
+	}
+
+	sub := "(SELECT 1 AS a UNION SELECT 2 UNION SELECT NULL)"
+	for _, tc := range []struct {
+		frag  SQLFragment
+		count int64
+	}{
+		{db.WhereEq("a", nilInt), 1},
+		{db.WhereEq("a", &one), 1},
+		{db.WhereNotEq("a", one), 2},
+		{db.WhereNotEq("a", nil), 2},
+	} {
+		query := Fragment("SELECT count(*) FROM " + sub + " WHERE").Append(tc.frag)
+		err := db.Query(&count, query.SQL, query.Args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != tc.count {
+			t.Errorf("%s: Expected count %d, got: %d", tc.frag.SQL, tc.count, count)
+		}
+	}
+}
+
+func TestWhereBool(t *testing.T) {
+	var count int64
+
+	sqlite := *sqliteDB
+
+	sub := "(SELECT 1 AS a, 1 AS b UNION SELECT 0, 2 UNION SELECT NULL, NULL)"
+	for _, tdb := range []*DB{db, &sqlite} {
+		for _, tc := range []struct {
+			frag  SQLFragment
+			count int64
+		}{
+			{tdb.WhereTrue("a"), 1},
+			{tdb.WhereFalse("a"), 1},
+			{tdb.WhereUnknown("a"), 1},
+			{tdb.WhereNullSafeEq("b", nil), 1},
+			{tdb.WhereNullSafeEq("b", 2), 1},
+			{tdb.WhereNullSafeNotEq("b", nil), 2},
+			{tdb.WhereNullSafeNotEq("b", 2), 2},
+		} {
+			query := Fragment("SELECT count(*) FROM " + sub + " WHERE").Append(tc.frag)
+			err := tdb.Query(&count, query.SQL, query.Args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tc.count {
+				t.Errorf("%s: Expected count %d, got: %d", tc.frag.SQL, tc.count, count)
+			}
+		}
+	}
+
+	mssql := *db
+	mssql.Driver = MSSQL
+	if frag := mssql.WhereTrue("a"); frag.SQL != `[a] = 1` {
+		t.Errorf("Unexpected MSSQL fragment: %s", frag.SQL)
+	}
+	if frag := mssql.WhereNullSafeEq("a", 1); len(frag.Args) != 2 {
+		t.Errorf("Expected 2 args for MSSQL, got: %v", frag.Args)
+	}
+}
+
+func TestFragmentSubquery(t *testing.T) {
+	var count int64
+
+	sub := Fragment("SELECT a FROM (SELECT 1 AS a UNION SELECT 2 UNION SELECT 3) WHERE a > ?", 1)
+	query := Fragment("SELECT count(*) FROM (SELECT 1 AS b UNION SELECT 2 UNION SELECT 3 UNION SELECT 4) WHERE b IN ? AND b < ?", sub, 4)
+
+	err := db.Query(&count, query.SQL, query.Args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected count 2, got: %d", count)
+	}
+
+	pg := *db
+	pg.PlaceholderMode = DOLLAR
+	sqlS, args, err := pg.replaceArgs("SELECT * FROM a WHERE x = ? AND EXISTS ? AND y = ?", 1,
+		&SQLFragment{SQL: "SELECT 1 FROM b WHERE b.z = ? AND b.w IN ?", Args: []interface{}{2, []int64{3, 4}}}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expSql := "SELECT * FROM a WHERE x = $1 AND EXISTS (SELECT 1 FROM b WHERE b.z = $2 AND b.w IN ($3,$4)) AND y = $5"
+	if sqlS != expSql {
+		t.Errorf("Expected %q, got %q", expSql, sqlS)
+	}
+	if len(args) != 5 || args[1] != 2 || args[4] != 5 {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	var names []string
+
+	for _, stmt := range []string{
+		"CREATE TABLE test_from_org(id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE test_from_user(id INTEGER PRIMARY KEY, name TEXT, org_id INTEGER)",
+		"INSERT INTO test_from_org VALUES(1, 'acme'), (2, 'globex')",
+		"INSERT INTO test_from_user VALUES(10, 'ann', 2), (11, 'bob', 1)",
+	} {
+		err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := Fragment("SELECT u.name").Append(
+		db.From("test_from_user AS u"),
+		db.LeftJoin("test_from_org o", "o.id = u.org_id AND o.name <> ?", "acme"),
+		Fragment("WHERE o.id IS NULL"),
+	)
+
+	expSql := `SELECT u.name FROM "test_from_user" "u" LEFT JOIN "test_from_org" "o" ON o.id = u.org_id AND o.name <> ? WHERE o.id IS NULL`
+	if query.SQL != expSql {
+		t.Errorf("Expected %q, got %q", expSql, query.SQL)
+	}
+
+	err := db.Query(&names, query.SQL, query.Args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "bob" {
+		t.Errorf("Expected [bob], got: %v", names)
+	}
+
+	if frag := db.Join("a", "a.id = b.id"); frag.SQL != `JOIN "a" ON a.id = b.id` {
+		t.Errorf("Unexpected join: %s", frag.SQL)
+	}
+}
+
+type testRowExpr struct {
+	ID      int64       `db:"id,pk,omitempty"`
+	Counter interface{} `db:"counter"`
+}
+
+func TestExpr(t *testing.T) {
+	var counter int64
+
+	err := db.Exec("CREATE TABLE test_expr(id INTEGER PRIMARY KEY AUTOINCREMENT, counter INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowExpr{Counter: Expr("? * 2", 5)}
+	err = db.Insert("test_expr", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.UpdateMap("test_expr", map[string]interface{}{"counter": Expr("counter + ?", 3)}, "id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row.Counter = Expr("counter * 2")
+	err = db.Update("test_expr", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&counter, "SELECT counter FROM test_expr WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter != 26 {
+		t.Errorf("Expected counter 26, got: %d", counter)
+	}
+}
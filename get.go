@@ -0,0 +1,161 @@
+package sqlpro
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GetOrCreate loads the first row of table matching condition into
+// target. If no row matches, target is inserted and loaded again.
+// GetOrCreate returns true if the row was created.
+//
+// created, err := db.GetOrCreate(&user, "user", "email = ?", user.Email)
+//
+// The insert works like Insert, keys tagged "uuid" or "seq" are
+// generated and the insert hooks run. For drivers supporting
+// "ON CONFLICT DO NOTHING" the insert is race-safe, if the table has
+// a unique constraint covering the condition: a row inserted
+// concurrently is loaded instead.
+func (db *DB) GetOrCreate(target interface{}, table string, condition string, args ...interface{}) (bool, error) {
+	var (
+		err     error
+		created bool
+	)
+
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Struct {
+		return false, fmt.Errorf("sqlpro.GetOrCreate: Target needs to be a pointer to a struct, have: %T", target)
+	}
+
+	cols, err := db.selectList(targetV.Type(), table)
+	if err != nil {
+		return false, err
+	}
+
+	query := db.Paginate(Fragment("SELECT "+cols+" FROM "+db.EscTable(table)+" WHERE "+db.scopeCondition(targetV.Type(), table, condition), args...), 1, 0)
+
+	err = db.Query(target, query.SQL, query.Args...)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrQueryReturnedZeroRows) {
+		return false, err
+	}
+
+	switch db.Driver {
+	case POSTGRES, SQLITE3:
+		cdb := *db
+		cdb.onConflict = "ON CONFLICT DO NOTHING"
+		err = cdb.insertRow(table, targetV.Elem())
+		if errors.Is(err, errInsertSkipped) {
+			err = nil
+		} else {
+			created = err == nil
+		}
+	default:
+		err = db.insertRow(table, targetV.Elem())
+		created = err == nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// load the row, to get keys and defaults set by the database
+	err = db.Query(target, query.SQL, query.Args...)
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// First loads the first row of table matching condition into target,
+// ordered by the primary key of target. An empty condition matches all
+// rows. Soft deleted rows are skipped, see Delete. If no row matches,
+// ErrQueryReturnedZeroRows is returned.
+//
+// err := db.First(&job, "jobs", "status = ?", "queued")
+func (db *DB) First(target interface{}, table string, condition string, args ...interface{}) error {
+	return db.firstOrLast(target, table, "", "ASC", condition, args...)
+}
+
+// Last works like First, but loads the row with the highest primary key.
+func (db *DB) Last(target interface{}, table string, condition string, args ...interface{}) error {
+	return db.firstOrLast(target, table, "", "DESC", condition, args...)
+}
+
+// FirstBy works like First, but orders by column instead of the primary key.
+func (db *DB) FirstBy(target interface{}, table string, column string, condition string, args ...interface{}) error {
+	return db.firstOrLast(target, table, column, "ASC", condition, args...)
+}
+
+// LastBy works like Last, but orders by column instead of the primary key.
+func (db *DB) LastBy(target interface{}, table string, column string, condition string, args ...interface{}) error {
+	return db.firstOrLast(target, table, column, "DESC", condition, args...)
+}
+
+func (db *DB) firstOrLast(target interface{}, table, column, dir string, condition string, args ...interface{}) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.First: Target needs to be a pointer to a struct, have: %T", target)
+	}
+
+	orderBy := make([]string, 0)
+	if column != "" {
+		orderBy = append(orderBy, db.Esc(column)+" "+dir)
+	} else {
+		for _, pk := range db.tableStructInfo(targetV.Elem().Type(), table).primaryKeys() {
+			orderBy = append(orderBy, db.Esc(pk.dbName)+" "+dir)
+		}
+		if len(orderBy) == 0 {
+			return fmt.Errorf("sqlpro.First: Target %T has no 'pk' field to order by.", target)
+		}
+	}
+
+	cols, err := db.selectList(targetV.Type(), table)
+	if err != nil {
+		return err
+	}
+
+	from, err := db.fromTable(table)
+	if err != nil {
+		return err
+	}
+
+	condition = db.scopeCondition(targetV.Type(), table, condition)
+	query := Fragment("SELECT " + cols + " FROM").Append(from)
+	if condition != "" {
+		query = query.Append(Fragment("WHERE "+condition, args...))
+	}
+	query = db.Paginate(query.Append(Fragment("ORDER BY "+strings.Join(orderBy, ", "))), 1, 0)
+
+	targetV.Elem().Set(reflect.Zero(targetV.Elem().Type()))
+
+	return db.Query(target, query.SQL, query.Args...)
+}
+
+// Pluck loads column of all rows in table matching condition into
+// target, which needs to be a pointer to a slice of a scalar type.
+// An empty condition matches all rows.
+//
+// err := db.Pluck(&names, "users", "name", "active = ?", true)
+func (db *DB) Pluck(target interface{}, table string, column string, condition string, args ...interface{}) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqlpro.Pluck: Target needs to be a pointer to a slice, have: %T", target)
+	}
+
+	from, err := db.fromTable(table)
+	if err != nil {
+		return err
+	}
+
+	query := Fragment("SELECT " + db.Esc(column) + " FROM").Append(from)
+	if condition != "" {
+		query = query.Append(Fragment("WHERE "+condition, args...))
+	}
+
+	return db.Query(target, query.SQL, query.Args...)
+}
@@ -0,0 +1,149 @@
+package sqlpro
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testRowUnique struct {
+	A int64  `db:"a,pk,omitempty"`
+	B string `db:"b"`
+	C string `db:"c"`
+}
+
+func TestGetOrCreate(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_unique(a INTEGER PRIMARY KEY AUTOINCREMENT, b TEXT UNIQUE, c TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []*DB{db, sqliteDB} {
+		tr := testRowUnique{B: fmt.Sprintf("unique %s", d.Driver), C: "created"}
+		created, err := d.GetOrCreate(&tr, "test_unique", "b = ?", tr.B)
+		if err != nil {
+			t.Error(err)
+		}
+		if !created || tr.A == 0 {
+			t.Errorf("Expected row to be created: %v", tr)
+		}
+
+		tr2 := testRowUnique{B: tr.B, C: "not created"}
+		created, err = d.GetOrCreate(&tr2, "test_unique", "b = ?", tr.B)
+		if err != nil {
+			t.Error(err)
+		}
+		if created || tr2.A != tr.A || tr2.C != "created" {
+			t.Errorf("Expected existing row to be loaded: %v", tr2)
+		}
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	var first, last, byName testRowText
+
+	err := db.Exec("CREATE TABLE test_first(key TEXT PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.InsertBulk("test_first", []testRowText{{"b", "z"}, {"a", "queued"}, {"c", "queued"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.First(&first, "test_first", "value = ?", "queued")
+	if err != nil {
+		t.Error(err)
+	}
+	err = db.Last(&last, "test_first", "")
+	if err != nil {
+		t.Error(err)
+	}
+	err = db.LastBy(&byName, "test_first", "value", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if first.Key != "a" || last.Key != "c" || byName.Key != "b" {
+		t.Errorf("Unexpected rows: %v %v %v", first, last, byName)
+	}
+
+	err = db.First(&first, "test_first", "value = ?", "missing")
+	if err != ErrQueryReturnedZeroRows {
+		t.Errorf("Expected ErrQueryReturnedZeroRows, got: %v", err)
+	}
+}
+
+func TestPluck(t *testing.T) {
+	var (
+		keys   []string
+		values []string
+	)
+
+	err := db.Pluck(&keys, "test_first", "key", "value = ?", "queued")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected 2 keys, got: %v", keys)
+	}
+
+	err = db.Pluck(&values, "test_first", "value", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(values) != 3 {
+		t.Errorf("Expected 3 values, got: %v", values)
+	}
+
+	err = db.Pluck(&keys[0], "test_first", "key", "")
+	if err == nil {
+		t.Errorf("Expected error for non slice target.")
+	}
+}
+
+func TestGetOrCreateInsertRow(t *testing.T) {
+	type testRowUniqueUUID struct {
+		ID    string `db:"id,pk,uuid"`
+		Email string `db:"email"`
+	}
+
+	err := sqliteDB.Exec("CREATE TABLE test_unique_uuid(id TEXT PRIMARY KEY, email TEXT UNIQUE)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowUniqueUUID{Email: "henk@example.com"}
+	created, err := sqliteDB.GetOrCreate(&row, "test_unique_uuid", "email = ?", row.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || row.ID == "" {
+		t.Errorf("Expected row to be created with a uuid: %v", row)
+	}
+
+	row2 := testRowUniqueUUID{Email: row.Email}
+	created, err = sqliteDB.GetOrCreate(&row2, "test_unique_uuid", "email = ?", row.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || row2.ID != row.ID {
+		t.Errorf("Expected existing row to be loaded: %v", row2)
+	}
+
+	err = sqliteDB.Exec("CREATE TABLE test_unique_hooks(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooked := testRowHooks{Name: "henk"}
+	created, err = sqliteDB.GetOrCreate(&hooked, "test_unique_hooks", "name = ?", hooked.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || len(hooked.calls) != 2 || hooked.calls[0] != "BeforeInsert" {
+		t.Errorf("Expected insert hooks to run, got: %v", hooked.calls)
+	}
+	_, err = sqliteDB.GetOrCreate(&testRowHooks{}, "test_unique_hooks", "name = ?", "")
+	if err == nil {
+		t.Errorf("Expected error from BeforeInsert")
+	}
+}
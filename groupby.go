@@ -0,0 +1,224 @@
+package sqlpro
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	groupByClauseRe = regexp.MustCompile(`\b(SELECT|FROM|WHERE|GROUP\s+BY|HAVING|WINDOW|ORDER\s+BY|LIMIT|OFFSET|FETCH|UNION|INTERSECT|EXCEPT)\b`)
+	aggregateRe     = regexp.MustCompile(`\b(COUNT|SUM|AVG|MIN|MAX|GROUP_CONCAT|STRING_AGG|ARRAY_AGG|JSON_AGG|JSONB_AGG|JSON_GROUP_ARRAY|JSON_GROUP_OBJECT|BOOL_AND|BOOL_OR|EVERY|BIT_AND|BIT_OR|BIT_XOR|STDDEV|STDDEV_POP|STDDEV_SAMP|VARIANCE|VAR_POP|VAR_SAMP|LISTAGG|TOTAL)\s*\(|\bOVER\b`)
+	aliasAsRe       = regexp.MustCompile(`(?s)^(.*\S)\s+AS\s+(\S+)$`)
+	aliasBareRe     = regexp.MustCompile(`(?s)^(.*[\w")\]'` + "`" + `])\s+([\w"\]` + "`" + `\[]+)$`)
+	constantRe      = regexp.MustCompile(`^(-?[0-9.]+|'_*'|NULL|TRUE|FALSE|\?|\$[0-9]+|:[0-9]+)$`)
+)
+
+// words which end an expression but are no alias
+var groupByNoAlias = map[string]bool{
+	"END": true, "NULL": true, "TRUE": true, "FALSE": true,
+}
+
+// words after which an expression continues
+var groupByOperators = map[string]bool{
+	"DISTINCT": true, "IS": true, "NOT": true, "AND": true, "OR": true,
+	"LIKE": true, "ILIKE": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "INTERVAL": true, "COLLATE": true,
+}
+
+// checkGroupBy checks that all columns selected by query which are
+// not aggregated are part of its GROUP BY clause. A query aggregating
+// without GROUP BY must not select other columns. Only the top level
+// SELECTs of query are checked, subqueries are not.
+//
+// This is a simple client-side check to catch grouping errors which
+// some databases (e.g. MySQL without ONLY_FULL_GROUP_BY) accept
+// silently. Columns which are functionally dependent on the grouped
+// columns are reported as well.
+func checkGroupBy(query string) error {
+	masked := maskSQL(query)
+	start := 0
+	for _, loc := range append(groupByClauseRe.FindAllStringIndex(masked, -1), []int{len(masked), len(masked)}) {
+		kw := ""
+		if loc[0] < len(masked) {
+			kw = masked[loc[0]:loc[1]]
+		}
+		if kw != "UNION" && kw != "INTERSECT" && kw != "EXCEPT" && kw != "" {
+			continue
+		}
+		err := checkGroupBySelect(query[start:loc[0]], masked[start:loc[0]])
+		if err != nil {
+			return err
+		}
+		start = loc[1]
+	}
+	return nil
+}
+
+// checkGroupBySelect checks a single SELECT, see checkGroupBy
+func checkGroupBySelect(query, masked string) error {
+	clauses := map[string]string{}
+	locs := groupByClauseRe.FindAllStringIndex(masked, -1)
+	for i, loc := range locs {
+		end := len(masked)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		kw := strings.Join(strings.Fields(masked[loc[0]:loc[1]]), " ")
+		if _, ok := clauses[kw]; !ok {
+			clauses[kw] = query[loc[1]:end]
+		}
+	}
+
+	selectList, ok := clauses["SELECT"]
+	if !ok {
+		return nil
+	}
+	groupBy, grouped := clauses["GROUP BY"]
+
+	items := splitTopLevel(selectList)
+	if !grouped {
+		// without GROUP BY, a query either aggregates all columns or none
+		aggregated := false
+		for _, item := range items {
+			if aggregateRe.MatchString(maskSQL(item)) {
+				aggregated = true
+				break
+			}
+		}
+		if !aggregated {
+			return nil
+		}
+	}
+
+	groupCols := map[string]bool{}
+	for _, g := range splitTopLevel(groupBy) {
+		groupCols[normalizeExpr(g)] = true
+	}
+
+	for idx, item := range items {
+		expr, alias := splitAlias(item)
+		maskedExpr := strings.TrimSpace(maskSQL(expr))
+		if aggregateRe.MatchString(maskedExpr) || constantRe.MatchString(maskedExpr) {
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(strings.TrimLeft(expr, " \t\r\n(")), "SELECT") {
+			// subqueries are not checked
+			continue
+		}
+		expr = normalizeExpr(expr)
+		if strings.HasPrefix(expr, "distinct ") {
+			expr = strings.TrimPrefix(expr, "distinct ")
+		}
+		if groupCols[expr] ||
+			groupCols[strconv.Itoa(idx+1)] ||
+			(alias != "" && groupCols[normalizeExpr(alias)]) {
+			continue
+		}
+		if !strings.HasSuffix(expr, "*") {
+			// match qualified and unqualified column names
+			matched := false
+			for g := range groupCols {
+				if strings.HasSuffix(expr, "."+g) || strings.HasSuffix(g, "."+expr) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		if !grouped {
+			return fmt.Errorf("sqlpro.Query: Column %q is not aggregated, but the query aggregates without GROUP BY.", strings.TrimSpace(item))
+		}
+		return fmt.Errorf("sqlpro.Query: Column %q is neither aggregated nor in GROUP BY.", strings.TrimSpace(item))
+	}
+	return nil
+}
+
+// splitAlias splits a select item into its expression and alias
+func splitAlias(item string) (expr string, alias string) {
+	masked := maskSQL(strings.TrimSpace(item))
+	item = strings.TrimSpace(item)
+	if m := aliasAsRe.FindStringSubmatchIndex(masked); m != nil {
+		return item[m[2]:m[3]], item[m[4]:m[5]]
+	}
+	if m := aliasBareRe.FindStringSubmatchIndex(masked); m != nil {
+		prev := strings.Fields(masked[m[2]:m[3]])
+		if !groupByNoAlias[masked[m[4]:m[5]]] && !groupByOperators[prev[len(prev)-1]] {
+			return item[m[2]:m[3]], item[m[4]:m[5]]
+		}
+	}
+	return item, ""
+}
+
+// normalizeExpr lowercases expr, removes identifier quotes and
+// collapses whitespace
+func normalizeExpr(expr string) string {
+	expr = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(expr)
+	expr = strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	expr = strings.ReplaceAll(expr, "( ", "(")
+	return strings.ReplaceAll(expr, " )", ")")
+}
+
+// splitTopLevel splits s at commas outside of parentheses and quotes
+func splitTopLevel(s string) []string {
+	masked := maskSQL(s)
+	parts := []string{}
+	start := 0
+	for i := 0; i < len(masked); i++ {
+		if masked[i] == ',' {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// maskSQL returns query with ASCII letters in upper case, the
+// contents of parentheses replaced by spaces and the contents of
+// quoted strings and identifiers replaced by "_", so that keywords
+// and commas found in the result are on the top level. The result
+// has the same length as query.
+func maskSQL(query string) string {
+	var (
+		out   = []byte(query)
+		depth int
+		quote byte
+	)
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if c >= 'a' && c <= 'z' {
+			out[i] = c - 'a' + 'A'
+		}
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				continue
+			}
+			out[i] = '_'
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			if depth > 0 {
+				out[i] = ' '
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth > 0 {
+				out[i] = ' '
+			}
+		case depth > 0:
+			out[i] = ' '
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,53 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestCheckGroupBy(t *testing.T) {
+	valid := []string{
+		"SELECT * FROM test",
+		"SELECT a, b FROM test WHERE c = ?",
+		"SELECT COUNT(*) FROM test",
+		"SELECT count(*) AS n, max(b) FROM test WHERE a IN (SELECT a FROM other)",
+		"SELECT a, COUNT(*) FROM test GROUP BY a",
+		"SELECT t.a, SUM(b) total FROM test t GROUP BY a ORDER BY total DESC",
+		`SELECT "a", lower(b) AS lb, COUNT(*) FROM test GROUP BY a, lower( b )`,
+		"SELECT a AS x, COUNT(*) FROM test GROUP BY x",
+		"SELECT a, b, COUNT(*) FROM test GROUP BY 1, 2",
+		"SELECT a, 'const', 1, COUNT(*) FROM test GROUP BY a HAVING COUNT(*) > 1",
+		"SELECT a, (SELECT MAX(x) FROM other), COUNT(*) FROM test GROUP BY a",
+		"SELECT a, COUNT(*) FROM test GROUP BY a UNION SELECT b, 1 FROM other",
+		"SELECT DISTINCT a, COUNT(*) FROM test GROUP BY a",
+	}
+	for _, q := range valid {
+		err := checkGroupBy(q)
+		if err != nil {
+			t.Errorf("Expected %q to be valid: %s", q, err)
+		}
+	}
+
+	invalid := []string{
+		"SELECT a, COUNT(*) FROM test",
+		"SELECT a, b, COUNT(*) FROM test GROUP BY a",
+		"SELECT a, b x, SUM(c) FROM test GROUP BY a",
+		"SELECT * FROM test GROUP BY a",
+		"SELECT a, COUNT(*) FROM test GROUP BY a UNION SELECT b, COUNT(*) FROM other",
+		"SELECT lower(b), COUNT(*) FROM test GROUP BY b",
+	}
+	for _, q := range invalid {
+		err := checkGroupBy(q)
+		if err == nil {
+			t.Errorf("Expected %q to be invalid", q)
+		}
+	}
+
+	check := *db
+	check.ValidateGroupBy = true
+
+	var ids []int64
+	err := check.Query(&ids, "SELECT a, COUNT(*) FROM test GROUP BY b")
+	if err == nil {
+		t.Errorf("Expected Query to fail the GROUP BY check")
+	}
+}
@@ -0,0 +1,124 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hints are per statement hints for the query planner. Each kind of
+// hint is only used by the drivers supporting it and ignored by all
+// others, so the same code can run against all databases.
+type Hints struct {
+	// Settings are set using "SET LOCAL key = value" before the
+	// statement (Postgres). Values are used as is, e.g.
+	// {"statement_timeout": "'5s'", "work_mem": "'64MB'"}. Outside
+	// of a transaction the statement runs in its own transaction.
+	Settings map[string]string
+	// Optimizer hints are added as "/*+ ... */" comment after the
+	// first keyword (MySQL, Oracle) or in front of the statement
+	// (Postgres with pg_hint_plan).
+	Optimizer []string
+	// Options are appended as "OPTION (...)" (MSSQL).
+	Options []string
+}
+
+var settingName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// WithHints returns a copy which runs all statements with the given
+// hints, see Hints.
+//
+//	err := db.WithHints(Hints{
+//		Settings: map[string]string{"statement_timeout": "'2s'"},
+//		Options:  []string{"MAXDOP 1"},
+//	}).Query(&rows, "SELECT * FROM report")
+func (db *DB) WithHints(h Hints) *DB {
+	newDB := *db
+	newDB.hints = &h
+	return &newDB
+}
+
+// apply returns query with the optimizer hints and options added
+func (h *Hints) apply(driver dbDriver, query string) (string, error) {
+	for _, hint := range append(append([]string{}, h.Optimizer...), h.Options...) {
+		if strings.Contains(hint, "*/") || strings.Contains(hint, ";") {
+			return "", fmt.Errorf("sqlpro.Hints: Invalid hint %q.", hint)
+		}
+	}
+
+	switch driver {
+	case MSSQL:
+		if len(h.Options) > 0 {
+			query = strings.TrimRight(query, " \t\n;") + " OPTION (" + strings.Join(h.Options, ", ") + ")"
+		}
+	case POSTGRES:
+		if len(h.Optimizer) > 0 {
+			query = "/*+ " + strings.Join(h.Optimizer, " ") + " */ " + query
+		}
+	case SQLITE3:
+		// no hints
+	default:
+		if len(h.Optimizer) > 0 {
+			trimmed := strings.TrimLeft(query, " \t\n")
+			idx := strings.IndexAny(trimmed, " \t\n")
+			if idx == -1 {
+				idx = len(trimmed)
+			}
+			query = trimmed[:idx] + " /*+ " + strings.Join(h.Optimizer, " ") + " */" + trimmed[idx:]
+		}
+	}
+	return query, nil
+}
+
+// needsTx returns true if the hints need a transaction for the driver
+func (h *Hints) needsTx(driver dbDriver) bool {
+	return driver == POSTGRES && len(h.Settings) > 0
+}
+
+// runHinted runs fn with the query rewritten for the hints of db and
+// a copy of db without hints. Settings are applied using SET LOCAL,
+// inside a new transaction if needed.
+func (db *DB) runHinted(query string, fn func(hdb *DB, query string) error) error {
+	h := db.hints
+	hdb := *db
+	hdb.hints = nil
+
+	query, err := h.apply(db.Driver, query)
+	if err != nil {
+		return err
+	}
+	if !h.needsTx(db.Driver) {
+		return fn(&hdb, query)
+	}
+
+	keys := make([]string, 0, len(h.Settings))
+	for key := range h.Settings {
+		if !settingName.MatchString(key) {
+			return fmt.Errorf("sqlpro.Hints: Invalid setting %q.", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	run := func(tdb *DB) error {
+		for _, key := range keys {
+			err := tdb.Exec("SET LOCAL " + key + " = " + h.Settings[key])
+			if err != nil {
+				return err
+			}
+		}
+		return fn(tdb, query)
+	}
+
+	if hdb.sqlTx != nil {
+		return run(&hdb)
+	}
+	if hdb.sqlDB == nil {
+		return fmt.Errorf("sqlpro.Hints: Settings need a transaction or a wrapper created using Open.")
+	}
+	return hdb.RunTx(context.Background(), func(tx *Tx) error {
+		return run(tx.DB)
+	})
+}
@@ -0,0 +1,62 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHints(t *testing.T) {
+	var count int64
+
+	h := Hints{
+		Settings:  map[string]string{"statement_timeout": "'5s'"},
+		Optimizer: []string{"SeqScan(t)", "NO_INDEX(t)"},
+		Options:   []string{"MAXDOP 1", "RECOMPILE"},
+	}
+
+	for driver, exp := range map[dbDriver]string{
+		MSSQL:    "SELECT * FROM t OPTION (MAXDOP 1, RECOMPILE)",
+		POSTGRES: "/*+ SeqScan(t) NO_INDEX(t) */ SELECT * FROM t",
+		ORACLE:   "SELECT /*+ SeqScan(t) NO_INDEX(t) */ * FROM t",
+		SQLITE3:  "SELECT * FROM t",
+	} {
+		query, err := h.apply(driver, "SELECT * FROM t;")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSuffix(query, ";") != exp {
+			t.Errorf("%s: Expected %q, got %q", driver, exp, query)
+		}
+	}
+
+	_, err := (&Hints{Optimizer: []string{"x */ DROP TABLE t; /*"}}).apply(ORACLE, "SELECT 1")
+	if err == nil {
+		t.Errorf("Expected error for invalid hint")
+	}
+
+	err = db.Exec("CREATE TABLE test_hints(id INTEGER PRIMARY KEY)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_hints(id) VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// settings are ignored for sqlite
+	sqlite := *sqliteDB
+	err = sqlite.WithHints(h).Query(&count, "SELECT count(*) FROM test_hints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Errorf("Expected rows in test_hints")
+	}
+
+	pg := *db
+	pg.Driver = POSTGRES
+	err = pg.WithHints(h).Exec("DELETE FROM test_hints")
+	if err == nil {
+		t.Errorf("Expected error for settings without Open")
+	}
+}
@@ -0,0 +1,155 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// historyRegistry holds the tables registered with RegisterHistory,
+// it is shared by all copies of a DB
+type historyRegistry struct {
+	sync.RWMutex
+	tables map[string]bool
+}
+
+// RegisterHistory enables history tables for tables. Before Update,
+// Delete, DeleteByIDs, UpdateMap, UpdateByKey, Patch, SyncDerivedTable
+// and EnforceRetention change rows of a registered table, the previous
+// rows are copied into its history table, in the same transaction. If
+// not already in a transaction and the wrapper was initialized using
+// "Open", a transaction is started for this. Statements run using
+// Exec are not recorded.
+//
+// The history table is named and has its end of validity column as
+// configured in History, "<table>_history" with "valid_to" by default.
+// It needs the columns of table, in the same order, followed by the
+// end of validity column, which is set to the time of the change:
+//
+//	CREATE TABLE price_history AS SELECT *, NULL AS valid_to FROM price WHERE 1 = 0
+//
+// Use HistoryOf to read the history of a row. For AsOf, table also
+// needs the start of validity column, e.g. tagged "autoupdate".
+func (db *DB) RegisterHistory(tables ...string) error {
+	if db.historyTables == nil {
+		return fmt.Errorf("sqlpro.RegisterHistory: The wrapper must be created using New or Open.")
+	}
+
+	db.historyTables.Lock()
+	defer db.historyTables.Unlock()
+
+	for _, table := range tables {
+		db.historyTables.tables[table] = true
+	}
+	return nil
+}
+
+// historyConfig returns the history table convention in use
+func (db *DB) historyConfig() *HistoryTables {
+	if db.History == nil {
+		return &HistoryTables{}
+	}
+	return db.History
+}
+
+// hasHistory returns true if table was registered with RegisterHistory
+func (db *DB) hasHistory(table string) bool {
+	if db.historyTables == nil {
+		return false
+	}
+	db.historyTables.RLock()
+	defer db.historyTables.RUnlock()
+
+	return db.historyTables.tables[table]
+}
+
+// withHistory runs write, which changes row in table. If table is
+// registered with RegisterHistory, the row is copied into the history
+// table first, in the same transaction as write.
+func (db *DB) withHistory(table string, row reflect.Value, write func(db *DB) error) error {
+	if !db.hasHistory(table) {
+		return write(db)
+	}
+
+	values, info, err := db.tableValuesFromStruct(table, row.Interface())
+	if err != nil {
+		return err
+	}
+	where, args, err := db.pkWhere(values, info)
+	if err != nil {
+		return fmt.Errorf("sqlpro.RegisterHistory: Unable to copy row: %s", err)
+	}
+
+	return db.withHistoryWhere(table, where, args, write)
+}
+
+// withHistoryWhere runs write, which changes the rows of table
+// matching where, a " WHERE ..." clause using args. If table is
+// registered with RegisterHistory, the rows are copied into the
+// history table first, in the same transaction as write.
+func (db *DB) withHistoryWhere(table string, where string, args []interface{}, write func(db *DB) error) error {
+	if !db.hasHistory(table) {
+		return write(db)
+	}
+
+	hist := db.historyConfig()
+	copyRows := func(db *DB) error {
+		_, err := db.exec(-1, "INSERT INTO "+db.EscTable(table+hist.suffix())+
+			" SELECT "+db.EscTable(table)+".*, "+string(db.PlaceholderValue)+" FROM "+db.EscTable(table)+where,
+			append([]interface{}{db.storeTime(db.now())}, args...)...)
+		if err != nil {
+			return err
+		}
+		return write(db)
+	}
+
+	if db.sqlTx == nil && db.sqlDB != nil {
+		return db.RunTx(db.context(), func(tx *Tx) error {
+			return copyRows(tx.DB)
+		})
+	}
+	return copyRows(db)
+}
+
+// HistoryOf loads the previous versions of the row of table with
+// primary key pk from its history table into target, a pointer to a
+// slice of structs, ordered by the time they were replaced. Only
+// versions replaced in [from, to) are loaded, zero times are
+// unbounded. See RegisterHistory.
+//
+//	var versions []Price
+//	err := db.HistoryOf(&versions, "price", id, lastMonth, time.Time{})
+func (db *DB) HistoryOf(target interface{}, table string, pk interface{}, from, to time.Time) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqlpro.HistoryOf: Target needs to be a pointer to a slice, have: %T", target)
+	}
+
+	elemT := targetV.Elem().Type().Elem()
+	for elemT.Kind() == reflect.Ptr {
+		elemT = elemT.Elem()
+	}
+	if elemT.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.HistoryOf: Target needs to be a slice of structs, have: %T", target)
+	}
+
+	pkField := db.tableStructInfo(elemT, table).onlyPrimaryKey()
+	if pkField == nil {
+		return fmt.Errorf("sqlpro.HistoryOf: %s needs exactly one 'pk' field.", elemT)
+	}
+
+	hist := db.historyConfig()
+	validTo := db.Esc(hist.validTo())
+
+	query := Fragment("SELECT * FROM "+db.EscTable(table+hist.suffix())+" WHERE "+db.Esc(pkField.dbName)+" = ?", pk)
+	if !from.IsZero() {
+		query = query.Append(Fragment("AND "+validTo+" >= ?", db.storeTime(from)))
+	}
+	if !to.IsZero() {
+		query = query.Append(Fragment("AND "+validTo+" < ?", db.storeTime(to)))
+	}
+	query = query.Append(Fragment("ORDER BY " + validTo))
+
+	return db.Query(target, query.SQL, query.Args...)
+}
@@ -0,0 +1,185 @@
+package sqlpro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRowHistory struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Price int64  `db:"price"`
+	Note  string `db:"note"`
+}
+
+type testRowHistoryVersion struct {
+	testRowHistory
+	ValidTo time.Time `db:"valid_to"`
+}
+
+func TestHistory(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.Exec("CREATE TABLE price(id INTEGER PRIMARY KEY AUTOINCREMENT, price INTEGER, note TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.Exec("CREATE TABLE price_history(id INTEGER, price INTEGER, note TEXT, valid_to DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.RegisterHistory("price")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowHistory{Price: 10, Note: "a"}
+	err = tdb.Insert("price", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	row.Price = 20
+	err = tdb.Update("price", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.Price = 30
+	err = tdb.Update("price", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a failing update leaves no history
+	row.Price = 40
+	err = tdb.Update("price", &testRowHistory{ID: row.ID + 1, Price: 40})
+	if err == nil {
+		t.Errorf("Expected update of missing row to fail")
+	}
+
+	err = tdb.Delete("price", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []testRowHistoryVersion
+	err = tdb.HistoryOf(&versions, "price", row.ID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := []int64{}
+	for _, v := range versions {
+		prices = append(prices, v.Price)
+		if v.ValidTo.Before(start.Add(-time.Second)) || v.Note != "a" {
+			t.Errorf("Unexpected version: %v", v)
+		}
+	}
+	if !reflect.DeepEqual(prices, []int64{10, 20, 30}) {
+		t.Errorf("Expected 3 versions, got: %v", prices)
+	}
+
+	versions = nil
+	err = tdb.HistoryOf(&versions, "price", row.ID, time.Time{}, start.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("Expected no versions before start, got: %v", versions)
+	}
+
+	// rolled back changes leave no history
+	row2 := testRowHistory{Price: 1}
+	err = tdb.Insert("price", &row2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := tdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row2.Price = 2
+	err = tx.Update("price", &row2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	err = tdb.Query(&count, "SELECT COUNT(*) FROM price_history WHERE id = ?", row2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected rolled back history, got: %d", count)
+	}
+}
+
+func TestHistoryAllWrites(t *testing.T) {
+	type testRowStock struct {
+		ID      int64     `db:"id,pk,omitempty"`
+		SKU     string    `db:"sku"`
+		Qty     int64     `db:"qty"`
+		Updated time.Time `db:"updated"`
+	}
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	for _, stmt := range []string{
+		"CREATE TABLE stock(id INTEGER PRIMARY KEY AUTOINCREMENT, sku TEXT, qty INTEGER, updated DATETIME)",
+		"CREATE TABLE stock_history(id INTEGER, sku TEXT, qty INTEGER, updated DATETIME, valid_to DATETIME)",
+	} {
+		err := tdb.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := tdb.RegisterHistory("stock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	rows := []testRowStock{{SKU: "a", Qty: 1, Updated: old}, {SKU: "b", Qty: 1, Updated: time.Now()}, {SKU: "c", Qty: 1, Updated: time.Now()}}
+	err = tdb.InsertBulk("stock", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	err = tdb.Query(&ids, "SELECT id FROM stock ORDER BY sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tdb.UpdateMap("stock", map[string]interface{}{"qty": 2}, "sku = ?", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tdb.UpdateByKey("stock", []testRowStock{{SKU: "b", Qty: 3, Updated: time.Now()}}, "sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tdb.DeleteByIDs("stock", &testRowStock{}, []int64{ids[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tdb.RetentionPolicy("stock", "updated", 24*time.Hour)
+	_, err = tdb.EnforceRetention(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	err = tdb.Query(&versions, "SELECT sku || qty FROM stock_history ORDER BY valid_to, sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []string{"b1", "b2", "c1", "a1"}) {
+		t.Errorf("Expected history of all writes, got: %v", versions)
+	}
+}
@@ -0,0 +1,128 @@
+package sqlpro
+
+import (
+	"context"
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// BeforeInserter is implemented by structs which need to run code
+// before Insert writes them, e.g. to set defaults or validate.
+// Returning an error aborts the Insert.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is implemented by structs which need to run code
+// after Insert wrote them. The primary key is set at this point.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is implemented by structs which need to run code
+// before Update writes them. Returning an error aborts the Update.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is implemented by structs which need to run code
+// after Update wrote them, e.g. to invalidate caches.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter is implemented by structs which need to run code
+// before Delete removes them. Returning an error aborts the Delete.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleter is implemented by structs which need to run code
+// after Delete removed them.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// WithContext returns a copy which runs its statements with ctx, if
+// the wrapped handle supports contexts, and passes ctx to the
+// lifecycle hooks run by Insert, Update, Save and Delete. Inside a
+// transaction, the transaction is bound to the context passed to the
+// hooks, so they can join it using FromContext.
+func (db *DB) WithContext(ctx context.Context) *DB {
+	newDB := *db
+	newDB.ctx = ctx
+	return &newDB
+}
+
+// hookContext returns the context passed to lifecycle hooks
+func (db *DB) hookContext() context.Context {
+	ctx := db.context()
+	if db.sqlTx != nil {
+		ctx = ContextWithTx(ctx, &Tx{DB: db})
+	}
+	return ctx
+}
+
+// runHook calls the lifecycle hook of row selected by call, if row
+// implements it. For addressable rows, the hook is looked up on the
+// pointer to row.
+func (db *DB) runHook(row reflect.Value, call func(ctx context.Context, v interface{}) (bool, error)) error {
+	v := row.Interface()
+	if row.CanAddr() {
+		v = row.Addr().Interface()
+	}
+	ok, err := call(db.hookContext(), v)
+	if !ok || err == nil {
+		return nil
+	}
+	return xerrors.Errorf("sqlpro: Hook of %T failed: %w", v, err)
+}
+
+func beforeInsert(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(BeforeInserter)
+	if !ok {
+		return false, nil
+	}
+	return true, h.BeforeInsert(ctx)
+}
+
+func afterInsert(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(AfterInserter)
+	if !ok {
+		return false, nil
+	}
+	return true, h.AfterInsert(ctx)
+}
+
+func beforeUpdate(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(BeforeUpdater)
+	if !ok {
+		return false, nil
+	}
+	return true, h.BeforeUpdate(ctx)
+}
+
+func afterUpdate(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(AfterUpdater)
+	if !ok {
+		return false, nil
+	}
+	return true, h.AfterUpdate(ctx)
+}
+
+func beforeDelete(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(BeforeDeleter)
+	if !ok {
+		return false, nil
+	}
+	return true, h.BeforeDelete(ctx)
+}
+
+func afterDelete(ctx context.Context, v interface{}) (bool, error) {
+	h, ok := v.(AfterDeleter)
+	if !ok {
+		return false, nil
+	}
+	return true, h.AfterDelete(ctx)
+}
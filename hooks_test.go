@@ -0,0 +1,85 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testRowHooks struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Name  string `db:"name"`
+	calls []string
+}
+
+func (r *testRowHooks) BeforeInsert(ctx context.Context) error {
+	r.calls = append(r.calls, "BeforeInsert")
+	if r.Name == "" {
+		return fmt.Errorf("name required")
+	}
+	return nil
+}
+
+func (r *testRowHooks) AfterInsert(ctx context.Context) error {
+	r.calls = append(r.calls, fmt.Sprintf("AfterInsert:%t", r.ID > 0))
+	return nil
+}
+
+func (r *testRowHooks) BeforeUpdate(ctx context.Context) error {
+	r.calls = append(r.calls, "BeforeUpdate")
+	return nil
+}
+
+func (r *testRowHooks) AfterUpdate(ctx context.Context) error {
+	r.calls = append(r.calls, "AfterUpdate")
+	return nil
+}
+
+func (r *testRowHooks) BeforeDelete(ctx context.Context) error {
+	r.calls = append(r.calls, fmt.Sprintf("BeforeDelete:%v", ctx.Value(testHookKey{})))
+	return nil
+}
+
+type testHookKey struct{}
+
+func TestLifecycleHooks(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_hooks(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_hooks", &testRowHooks{})
+	if err == nil {
+		t.Errorf("Expected BeforeInsert to abort the insert")
+	}
+	var count int64
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no row inserted, got: %d", count)
+	}
+
+	row := testRowHooks{Name: "a"}
+	err = db.Save("test_hooks", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.Name = "b"
+	err = db.Save("test_hooks", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), testHookKey{}, "ctx")
+	err = db.WithContext(ctx).Delete("test_hooks", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"BeforeInsert", "AfterInsert:true", "BeforeUpdate", "AfterUpdate", "BeforeDelete:ctx"}
+	if !reflect.DeepEqual(row.calls, expected) {
+		t.Errorf("Expected hooks %v, got: %v", expected, row.calls)
+	}
+}
@@ -0,0 +1,145 @@
+package sqlpro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// encodeHstore returns the Postgres hstore text representation of m.
+// The keys are sorted, so that equal maps are encoded equally.
+func encodeHstore(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sb := strings.Builder{}
+	for idx, key := range keys {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quoteHstore(key))
+		sb.WriteString("=>")
+		sb.WriteString(quoteHstore(m[key]))
+	}
+	return sb.String()
+}
+
+func quoteHstore(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// parseHstore parses the Postgres hstore text representation.
+// NULL values are returned as empty strings.
+func parseHstore(s string) (map[string]string, error) {
+	m := make(map[string]string, 0)
+	runes := []rune(s)
+	pos := 0
+
+	skipSpace := func() {
+		for pos < len(runes) && (runes[pos] == ' ' || runes[pos] == '\t' || runes[pos] == '\n') {
+			pos++
+		}
+	}
+
+	// readItem reads a quoted or unquoted key or value, unquoted
+	// NULL is reported using isNull
+	readItem := func() (item string, isNull bool, err error) {
+		sb := strings.Builder{}
+		if pos < len(runes) && runes[pos] == '"' {
+			pos++
+			for {
+				if pos >= len(runes) {
+					return "", false, fmt.Errorf("sqlpro: Unterminated quote in hstore: %q", s)
+				}
+				r := runes[pos]
+				pos++
+				if r == '"' {
+					return sb.String(), false, nil
+				}
+				if r == '\\' && pos < len(runes) {
+					r = runes[pos]
+					pos++
+				}
+				sb.WriteRune(r)
+			}
+		}
+		for pos < len(runes) && runes[pos] != ',' && runes[pos] != '=' && runes[pos] != ' ' {
+			sb.WriteRune(runes[pos])
+			pos++
+		}
+		item = sb.String()
+		if item == "" {
+			return "", false, fmt.Errorf("sqlpro: Unable to parse hstore at %d: %q", pos, s)
+		}
+		return item, strings.EqualFold(item, "NULL"), nil
+	}
+
+	for {
+		skipSpace()
+		if pos >= len(runes) {
+			return m, nil
+		}
+
+		key, _, err := readItem()
+		if err != nil {
+			return nil, err
+		}
+
+		skipSpace()
+		if pos+1 >= len(runes) || runes[pos] != '=' || runes[pos+1] != '>' {
+			return nil, fmt.Errorf(`sqlpro: Expected "=>" in hstore at %d: %q`, pos, s)
+		}
+		pos += 2
+		skipSpace()
+
+		value, isNull, err := readItem()
+		if err != nil {
+			return nil, err
+		}
+		if isNull {
+			value = ""
+		}
+		m[key] = value
+
+		skipSpace()
+		if pos < len(runes) {
+			if runes[pos] != ',' {
+				return nil, fmt.Errorf(`sqlpro: Expected "," in hstore at %d: %q`, pos, s)
+			}
+			pos++
+		}
+	}
+}
+
+// nullHstore scans hstore columns, see parseHstore
+type nullHstore struct {
+	Map   map[string]string
+	Valid bool
+}
+
+// Scan implements the Scanner interface.
+func (nh *nullHstore) Scan(value interface{}) error {
+	var err error
+
+	switch v := value.(type) {
+	case nil:
+		nh.Map, nh.Valid = nil, false
+		return nil
+	case []byte:
+		nh.Map, err = parseHstore(string(v))
+	case string:
+		nh.Map, err = parseHstore(v)
+	default:
+		return fmt.Errorf("sqlpro: Unable to scan hstore from %T", value)
+	}
+	if err != nil {
+		return err
+	}
+	nh.Valid = true
+	return nil
+}
@@ -0,0 +1,62 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestHstoreEncoding(t *testing.T) {
+	m := map[string]string{"b": `say "hi"`, "a": `back\slash`, "empty": ""}
+
+	s := encodeHstore(m)
+	expS := `"a"=>"back\\slash", "b"=>"say \"hi\"", "empty"=>""`
+	if s != expS {
+		t.Errorf("Expected %s, got %s", expS, s)
+	}
+
+	m2, err := parseHstore(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m2) != 3 || m2["a"] != m["a"] || m2["b"] != m["b"] {
+		t.Errorf("Unexpected map: %v", m2)
+	}
+
+	m2, err = parseHstore(`"k"=>NULL,"x"=>"1"`)
+	if err != nil || len(m2) != 2 || m2["k"] != "" || m2["x"] != "1" {
+		t.Errorf("Unexpected map: %v %v", m2, err)
+	}
+
+	for _, s := range []string{`"a"=>`, `"a"`, `"a"=>"b" "c"=>"d"`, `"a=>"b"`} {
+		_, err = parseHstore(s)
+		if err == nil {
+			t.Errorf("Expected error for %s", s)
+		}
+	}
+}
+
+type testRowHstore struct {
+	ID    int64             `db:"id,pk,omitempty"`
+	Attrs map[string]string `db:"attrs,hstore"`
+}
+
+func TestHstoreTag(t *testing.T) {
+	var rows []testRowHstore
+
+	err := db.Exec("CREATE TABLE test_hstore(id INTEGER PRIMARY KEY AUTOINCREMENT, attrs TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_hstore", []testRowHstore{{Attrs: map[string]string{"color": "red", "size": "XL"}}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_hstore ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Attrs["color"] != "red" || len(rows[0].Attrs) != 2 || len(rows[1].Attrs) != 0 {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+}
@@ -0,0 +1,85 @@
+package sqlpro
+
+import (
+	"crypto/rand"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// newUUID returns a random version 4 UUID
+func newUUID() ([16]byte, error) {
+	var u [16]byte
+
+	_, err := rand.Read(u[:])
+	if err != nil {
+		return u, err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	return u, nil
+}
+
+// generateKeys sets all zero fields of row tagged with "uuid" or
+// "seq=<sequence>". row needs to be addressable.
+//
+// "uuid" fields receive a new random UUID, string fields in the
+// canonical text form, [16]byte fields as raw bytes.
+//
+// "seq" fields receive the next value of the sequence.
+func (db *DB) generateKeys(row reflect.Value) error {
+	for _, fi := range db.structInfo(row.Type()) {
+		if !fi.uuid && fi.sequence == "" {
+			continue
+		}
+
+		field := row.FieldByIndex(fi.structField.Index)
+		if !isZero(field.Interface()) {
+			continue
+		}
+
+		if fi.sequence != "" {
+			var next int64
+
+			if !fi.integerKey() {
+				return fmt.Errorf("sqlpro: Unable to set sequence value for field %s of type %s.", fi.name, field.Type())
+			}
+			err := db.Query(&next, db.nextvalQuery(fi.sequence))
+			if err != nil {
+				return xerrors.Errorf("sqlpro: Unable to get next value of sequence %s: %w", fi.sequence, err)
+			}
+			setPrimaryKey(field, next)
+			continue
+		}
+
+		u, err := newUUID()
+		if err != nil {
+			return xerrors.Errorf("sqlpro: Unable to generate uuid for %s: %w", fi.name, err)
+		}
+
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]))
+		case field.Type() == reflect.TypeOf(u):
+			field.Set(reflect.ValueOf(u))
+		default:
+			return fmt.Errorf("sqlpro: Unable to set uuid for field %s of type %s.", fi.name, field.Type())
+		}
+	}
+	return nil
+}
+
+// nextvalQuery returns the query to fetch the next value
+// of sequence, using the syntax of the db's driver
+func (db *DB) nextvalQuery(sequence string) string {
+	switch db.Driver {
+	case ORACLE:
+		return "SELECT " + db.Esc(sequence) + ".NEXTVAL FROM DUAL"
+	case MSSQL:
+		return "SELECT NEXT VALUE FOR " + db.Esc(sequence)
+	default:
+		return "SELECT nextval(" + db.EscValue(sequence) + ")"
+	}
+}
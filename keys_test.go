@@ -0,0 +1,229 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testRowComposite struct {
+	A int64  `db:"a,pk"`
+	B string `db:"b,pk"`
+	C string `db:"c"`
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	var (
+		rows  []testRowComposite
+		count int64
+	)
+
+	err := db.Exec("CREATE TABLE test_composite(a INTEGER, b TEXT, c TEXT, PRIMARY KEY (a, b))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trs := []*testRowComposite{
+		{A: 1, B: "x", C: "first"},
+		{A: 1, B: "y", C: "second"},
+	}
+	err = db.Insert("test_composite", trs)
+	if err != nil {
+		t.Error(err)
+	}
+
+	trs[1].C = "second updated"
+	err = db.Update("test_composite", trs[1])
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Save inserts unknown keys and updates existing ones
+	err = db.Save("test_composite", []*testRowComposite{
+		{A: 1, B: "x", C: "first saved"},
+		{A: 2, B: "x", C: "third"},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_composite ORDER BY a, b")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rows) != 3 || rows[0].C != "first saved" || rows[1].C != "second updated" || rows[2].C != "third" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	err = db.Delete("test_composite", trs[0])
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&count, "SELECT count(*) FROM test_composite")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows after delete, got: %d", count)
+	}
+
+	err = db.Delete("test_composite", &testRowComposite{A: 1})
+	if err == nil {
+		t.Errorf("Expected error for delete with empty key.")
+	}
+}
+
+type testRowText struct {
+	Key   string `db:"key,pk"`
+	Value string `db:"value"`
+}
+
+func TestTextPrimaryKey(t *testing.T) {
+	var rows []testRowText
+
+	err := db.Exec("CREATE TABLE test_text(key TEXT PRIMARY KEY, value TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := testRowText{Key: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Value: "first"}
+	err = db.Insert("test_text", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+	if tr.Key != "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11" {
+		t.Errorf("Expected key to be unchanged, got: %q", tr.Key)
+	}
+
+	// Save inserts unknown keys and updates existing ones
+	err = db.Save("test_text", []*testRowText{{Key: "other", Value: "second"}, {Key: tr.Key, Value: "updated"}})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_text ORDER BY value")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rows) != 2 || rows[0].Value != "second" || rows[1].Value != "updated" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+}
+
+type testRowUUID struct {
+	ID   string `db:"id,pk,uuid"`
+	Name string `db:"name"`
+}
+
+// createUUIDTable creates table for testRowUUID, with three rows
+func createUUIDTable(t *testing.T, table string) {
+	err := db.Exec("CREATE TABLE @(id TEXT PRIMARY KEY, name TEXT)", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Insert(table, []*testRowUUID{{Name: "a"}, {Name: "b"}, {ID: "given", Name: "given"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUUIDPrimaryKey(t *testing.T) {
+	var rows []testRowUUID
+
+	err := db.Exec("CREATE TABLE test_uuid(id TEXT PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := testRowUUID{Name: "single"}
+	err = db.Insert("test_uuid", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tr.ID) != 36 || tr.ID[14] != '4' {
+		t.Errorf("Expected v4 uuid, got: %q", tr.ID)
+	}
+
+	bulk := []*testRowUUID{{Name: "bulk"}, {ID: "given", Name: "given"}}
+	err = db.InsertBulk("test_uuid", bulk)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(bulk[0].ID) != 36 || bulk[0].ID == tr.ID || bulk[1].ID != "given" {
+		t.Errorf("Unexpected ids: %q %q", bulk[0].ID, bulk[1].ID)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_uuid WHERE id IN ?", []string{tr.ID, bulk[0].ID, "given"})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("Expected 3 rows, got: %v", rows)
+	}
+}
+
+func TestUUIDBytes(t *testing.T) {
+	type testRowUUIDBytes struct {
+		ID   [16]byte `db:"id,pk,uuid"`
+		Name string   `db:"name"`
+	}
+
+	err := db.Exec("CREATE TABLE test_uuid_bytes(id BLOB PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := testRowUUIDBytes{Name: "bytes"}
+	err = db.Insert("test_uuid_bytes", &tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.ID[6]>>4 != 4 {
+		t.Errorf("Expected v4 uuid, got: %x", tr.ID)
+	}
+
+	tr.Name = "updated"
+	err = db.Update("test_uuid_bytes", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	var rows []testRowUUIDBytes
+	err = db.Query(&rows, "SELECT * FROM test_uuid_bytes WHERE id = ?", tr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ID != tr.ID || rows[0].Name != "updated" {
+		t.Errorf("Expected row with uuid %x, got: %v", tr.ID, rows)
+	}
+}
+
+type testRowSeq struct {
+	ID   int64  `db:"id,pk,seq=test_seq"`
+	Name string `db:"name"`
+}
+
+func TestSequencePrimaryKey(t *testing.T) {
+	info := getStructInfo(reflect.TypeOf(testRowSeq{}))
+	if info["id"].sequence != "test_seq" {
+		t.Errorf("Expected sequence test_seq, got: %q", info["id"].sequence)
+	}
+
+	db2 := *db
+	for driver, expSql := range map[dbDriver]string{
+		POSTGRES: `SELECT nextval('test_seq')`,
+		ORACLE:   `SELECT "TEST_SEQ".NEXTVAL FROM DUAL`,
+		MSSQL:    `SELECT NEXT VALUE FOR [test_seq]`,
+	} {
+		db2.Driver = driver
+		if sql := db2.nextvalQuery("test_seq"); sql != expSql {
+			t.Errorf("Expected %q, got %q", expSql, sql)
+		}
+	}
+
+	// sqlite has no sequences
+	err := db.Insert("test_uuid", &testRowSeq{Name: "seq"})
+	if err == nil {
+		t.Errorf("Expected error for sequence on sqlite.")
+	}
+}
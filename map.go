@@ -0,0 +1,114 @@
+package sqlpro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InsertMap inserts one row into table, using the keys of values as
+// column names. Use this for dynamic data, where no struct can be
+// declared. The values are passed to the driver as they are.
+func (db *DB) InsertMap(table string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return fmt.Errorf("sqlpro.InsertMap: Need at least one value to insert.")
+	}
+	if _, ok := values[""]; ok {
+		return fmt.Errorf("sqlpro.InsertMap: Column name must not be empty.")
+	}
+
+	insert, args, err := db.insertClauseFromValues(table, values, structInfo{})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.exec(1, insert, args...)
+	return err
+}
+
+// InsertMaps inserts the given rows into table with as few INSERT
+// statements as MaxBulkParams allows, like InsertBulk. The columns
+// are the union of all keys, columns missing in a row are inserted
+// as NULL.
+func (db *DB) InsertMaps(table string, rows []map[string]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	key_map := make(map[string]*fieldInfo, 0)
+	for _, row := range rows {
+		for key := range row {
+			if key == "" {
+				return fmt.Errorf("sqlpro.InsertMaps: Column name must not be empty.")
+			}
+			key_map[key] = nil
+		}
+	}
+
+	if len(key_map) == 0 {
+		return fmt.Errorf("sqlpro.InsertMaps: Need at least one value to insert.")
+	}
+
+	keys := make([]string, 0, len(key_map))
+	for key := range key_map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return db.insertBulk(table, keys, key_map, rows)
+}
+
+// UpdateMap updates the rows of table matching condition, setting the
+// columns to the values of setValues. The condition is required and can
+// use placeholders for the given args, e.g.
+//
+// db.UpdateMap("user", map[string]interface{}{"name": "Henk"}, "id = ?", 5)
+//
+// UpdateMap returns the number of affected rows.
+func (db *DB) UpdateMap(table string, setValues map[string]interface{}, condition string, args ...interface{}) (int64, error) {
+	if len(setValues) == 0 {
+		return 0, fmt.Errorf("sqlpro.UpdateMap: Need at least one value to update.")
+	}
+	if strings.TrimSpace(condition) == "" {
+		return 0, fmt.Errorf("sqlpro.UpdateMap: Need a condition to update.")
+	}
+	err := db.checkMutation("UpdateMap")
+	if err != nil {
+		return 0, err
+	}
+
+	cols := make([]string, 0, len(setValues))
+	for col := range setValues {
+		if col == "" {
+			return 0, fmt.Errorf("sqlpro.UpdateMap: Column name must not be empty.")
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	update := strings.Builder{}
+	updateArgs := make([]interface{}, 0, len(setValues)+len(args))
+
+	update.WriteString(db.updatePrefix(table))
+
+	for idx, col := range cols {
+		if idx > 0 {
+			update.WriteString(",")
+		}
+		update.WriteString(db.Esc(col))
+		update.WriteString("=")
+		update.WriteRune(db.PlaceholderValue)
+		updateArgs = append(updateArgs, db.nullValue(setValues[col], nil))
+	}
+
+	where := " WHERE " + condition
+	update.WriteString(where)
+	updateArgs = append(updateArgs, args...)
+
+	var n int64
+	err = db.withHistoryWhere(table, where, args, func(db *DB) (err error) {
+		n, err = db.exec(-1, update.String(), updateArgs...)
+		return err
+	})
+	return n, err
+}
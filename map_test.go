@@ -0,0 +1,97 @@
+package sqlpro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInsertMap(t *testing.T) {
+	var rows []testRow
+
+	createTestTable(t, "test_map")
+
+	err := db.InsertMap("test_map", map[string]interface{}{"b": "map'1", "c": "insert_map", "d": 1.5})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.InsertMaps("test_map", []map[string]interface{}{
+		{"b": "map2", "c": "insert_map"},
+		{"c": "insert_map", "d": 2.5},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_map WHERE c = ? ORDER BY a", "insert_map")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got: %d", len(rows))
+	}
+	if rows[0].B != "map'1" || rows[0].D != 1.5 || rows[1].B != "map2" || rows[2].B != "" || rows[2].D != 2.5 {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	err = db.InsertMap("test_map", map[string]interface{}{})
+	if err == nil {
+		t.Errorf("Expected error for empty map.")
+	}
+
+	err = db.InsertMap("test_map", map[string]interface{}{"": "x"})
+	if err == nil {
+		t.Errorf("Expected error for empty column.")
+	}
+	err = db.InsertMaps("test_map", []map[string]interface{}{{"b": "x"}, {"": "x"}})
+	if err == nil {
+		t.Errorf("Expected error for empty column.")
+	}
+}
+
+func TestUpdateMap(t *testing.T) {
+	var names []string
+
+	n, err := db.UpdateMap("test_map", map[string]interface{}{"b": "updated'map", "d": 3.5}, "c = ? AND d > ?", "insert_map", 1.0)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 updated rows, got: %d", n)
+	}
+
+	err = db.Query(&names, "SELECT b FROM test_map WHERE c = ? AND d = ?", "insert_map", 3.5)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(names) != 2 || names[0] != "updated'map" {
+		t.Errorf("Unexpected rows: %v", names)
+	}
+
+	_, err = db.UpdateMap("test_map", map[string]interface{}{"b": "all"}, "")
+	if err == nil {
+		t.Errorf("Expected error for missing condition.")
+	}
+
+	_, err = db.UpdateMap("test_map", map[string]interface{}{"": "all"}, "1 = 1")
+	if err == nil {
+		t.Errorf("Expected error for empty column.")
+	}
+
+	// times are stored like in InsertMap
+	utcDB := *db
+	utcDB.StoreTimesUTC = true
+	local := time.Date(2020, 1, 1, 12, 0, 0, 0, time.FixedZone("X", 3600))
+	_, err = utcDB.UpdateMap("test_map", map[string]interface{}{"e": local}, "c = ?", "insert_map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e string
+	err = db.Query(&e, "SELECT e FROM test_map WHERE c = ? LIMIT 1", "insert_map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != "2020-01-01T11:00:00Z" {
+		t.Errorf("Expected %s stored as UTC, got: %s", local, e)
+	}
+}
@@ -0,0 +1,139 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type structMappingKey struct {
+	t     reflect.Type
+	table string
+}
+
+// structMappings holds the column mappings registered using MapStruct,
+// it is shared by all copies of a DB
+type structMappings struct {
+	sync.RWMutex
+	m map[structMappingKey]map[string]string
+}
+
+// MapStruct registers alternate column names for model, used when
+// model is written to or read from table. columns maps the column
+// name of the "db" tag (or the Go field name) to the column name in
+// table. This way one Go type can serve a legacy and a new table, e.g.
+// during a migration:
+//
+//	err := db.MapStruct(&Event{}, "events_v2", map[string]string{"ts": "created_at"})
+//
+// The mapping is used by Insert, InsertBulk, Update, Save, Delete,
+// DeleteByIDs, Get, First, Last, FindInBatches and LoadField. For
+// Query, use aliases in the SELECT.
+func (db *DB) MapStruct(model interface{}, table string, columns map[string]string) error {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.MapStruct: Model needs to be a struct, have: %T", model)
+	}
+
+	info := db.structInfo(t)
+	mapping := make(map[string]string, len(columns))
+	for from, to := range columns {
+		found := false
+		for _, fi := range info {
+			if fi.dbName == from || fi.name == from {
+				mapping[fi.dbName] = to
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("sqlpro.MapStruct: Column %q is not mapped in %s.", from, t)
+		}
+	}
+
+	if db.mappings == nil {
+		db.mappings = &structMappings{}
+	}
+
+	db.mappings.Lock()
+	defer db.mappings.Unlock()
+
+	if db.mappings.m == nil {
+		db.mappings.m = make(map[structMappingKey]map[string]string, 0)
+	}
+	db.mappings.m[structMappingKey{t: t, table: table}] = mapping
+
+	return nil
+}
+
+// columnMapping returns the mapping registered for t and table or nil
+func (db *DB) columnMapping(t reflect.Type, table string) map[string]string {
+	if db.mappings == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	db.mappings.RLock()
+	defer db.mappings.RUnlock()
+
+	return db.mappings.m[structMappingKey{t: t, table: table}]
+}
+
+// tableStructInfo returns the struct info of t, with the
+// columns renamed as registered for table using MapStruct.
+// Renamed fields have their original column in mappedFrom.
+func (db *DB) tableStructInfo(t reflect.Type, table string) structInfo {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	info := db.structInfo(t)
+
+	mapping := db.columnMapping(t, table)
+	if len(mapping) == 0 {
+		return info
+	}
+
+	mapped := make(structInfo, len(info))
+	for dbName, fi := range info {
+		to, ok := mapping[dbName]
+		if !ok {
+			mapped[dbName] = fi
+			continue
+		}
+		fi2 := *fi
+		fi2.dbName = to
+		fi2.mappedFrom = dbName
+		mapped[to] = &fi2
+	}
+	return mapped
+}
+
+// tableValuesFromStruct works like valuesFromStruct, with the
+// columns renamed as registered for table using MapStruct
+func (db *DB) tableValuesFromStruct(table string, data interface{}) (map[string]interface{}, structInfo, error) {
+	values, info, err := db.valuesFromStruct(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapping := db.columnMapping(reflect.TypeOf(data), table)
+	if len(mapping) == 0 {
+		return values, info, nil
+	}
+
+	mappedValues := make(map[string]interface{}, len(values))
+	for dbName, value := range values {
+		to, ok := mapping[dbName]
+		if !ok {
+			to = dbName
+		}
+		mappedValues[to] = value
+	}
+
+	return mappedValues, db.tableStructInfo(reflect.TypeOf(data), table), nil
+}
@@ -0,0 +1,213 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testRowEvent struct {
+	ID   int64  `db:"id,pk,omitempty"`
+	Name string `db:"name"`
+	TS   string `db:"ts"`
+}
+
+func TestMapStruct(t *testing.T) {
+	var (
+		ev     testRowEvent
+		events []testRowEvent
+		count  int64
+	)
+
+	err := db.Exec("CREATE TABLE test_events_v2(event_id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, created_at TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.MapStruct(&testRowEvent{}, "test_events_v2", map[string]string{"id": "event_id", "TS": "created_at"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := testRowEvent{Name: "first", TS: "2020-01-01"}
+	err = db.Insert("test_events_v2", &tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.InsertBulk("test_events_v2", []testRowEvent{{Name: "second", TS: "2020-01-02"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr.TS = "2020-01-03"
+	err = db.Update("test_events_v2", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.First(&ev, "test_events_v2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.ID != tr.ID || ev.TS != "2020-01-03" {
+		t.Errorf("Unexpected event: %v", ev)
+	}
+
+	err = db.Get(&events, "test_events_v2", "created_at > ?", "2020-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].Name != "second" || events[1].TS != "2020-01-02" {
+		t.Errorf("Unexpected events: %v", events)
+	}
+
+	err = db.Delete("test_events_v2", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+	err = db.Query(&count, "SELECT count(*) FROM test_events_v2")
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 event after delete, got: %d %v", count, err)
+	}
+
+	err = db.MapStruct(&testRowEvent{}, "test_events_v2", map[string]string{"unknown": "x"})
+	if err == nil {
+		t.Errorf("Expected error for unknown column.")
+	}
+}
+
+type testTimestamps struct {
+	Created string `db:"created"`
+	Updated string `db:"updated"`
+}
+
+type testRowEmbedded struct {
+	ID int64 `db:"id,pk,omitempty"`
+	testTimestamps
+	Name    string `db:"name"`
+	Updated string `db:"changed"`
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	var rows []testRowEmbedded
+
+	err := db.Exec("CREATE TABLE test_embedded(id INTEGER PRIMARY KEY AUTOINCREMENT, created TEXT, updated TEXT, name TEXT, changed TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowEmbedded{Name: "one", Updated: "outer"}
+	row.Created = "c"
+	row.testTimestamps.Updated = "inner"
+	err = db.Insert("test_embedded", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID == 0 {
+		t.Errorf("Expected id to be set")
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !reflect.DeepEqual(rows[0], row) {
+		t.Errorf("Expected %v, got: %v", row, rows)
+	}
+
+	if got := getStructInfo(reflect.TypeOf(row)); len(got) != 5 {
+		t.Errorf("Expected 5 fields, got: %d", len(got))
+	}
+}
+
+type testAddress struct {
+	Street string `db:"street"`
+	City   string `db:"city"`
+}
+
+type testRowPrefix struct {
+	ID       int64 `db:"id,pk,omitempty"`
+	Name     string
+	Home     testAddress `db:"home_"`
+	Shipping testAddress `db:"ship_"`
+}
+
+func TestStructPrefix(t *testing.T) {
+	var rows []testRowPrefix
+
+	err := db.Exec("CREATE TABLE test_prefix(id INTEGER PRIMARY KEY AUTOINCREMENT, home_street TEXT, home_city TEXT, ship_street TEXT, ship_city TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowPrefix{
+		Home:     testAddress{Street: "Main St", City: "Berlin"},
+		Shipping: testAddress{Street: "Side St", City: "Hamburg"},
+	}
+	err = db.Insert("test_prefix", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var city string
+	err = db.Query(&city, "SELECT ship_city FROM test_prefix WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if city != "Hamburg" {
+		t.Errorf("Expected ship_city Hamburg, got: %s", city)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !reflect.DeepEqual(rows[0], row) {
+		t.Errorf("Expected %v, got: %v", row, rows)
+	}
+}
+
+type testJoinUser struct {
+	ID    int64  `db:"id"`
+	Name  string `db:"name"`
+	OrgID int64  `db:"org_id"`
+}
+
+type testJoinOrg struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestJoinScan(t *testing.T) {
+	var rows []struct {
+		User testJoinUser `db:"u."`
+		Org  testJoinOrg  `db:"o."`
+	}
+
+	for _, stmt := range []string{
+		"CREATE TABLE test_join_org(id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE test_join_user(id INTEGER PRIMARY KEY, name TEXT, org_id INTEGER)",
+		"INSERT INTO test_join_org VALUES(1, 'acme'), (2, 'globex')",
+		"INSERT INTO test_join_user VALUES(10, 'ann', 2), (11, 'bob', 1)",
+	} {
+		err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := db.Query(&rows, `SELECT u.id AS "u.id", u.name AS "u.name", u.org_id AS "u.org_id", o.id AS "o.id", o.name AS "o.name"
+		FROM test_join_user u JOIN test_join_org o ON o.id = u.org_id ORDER BY u.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %d", len(rows))
+	}
+	if rows[0].User != (testJoinUser{ID: 10, Name: "ann", OrgID: 2}) || rows[0].Org != (testJoinOrg{ID: 2, Name: "globex"}) {
+		t.Errorf("Unexpected first row: %v", rows[0])
+	}
+	if rows[1].User.Name != "bob" || rows[1].Org.Name != "acme" {
+		t.Errorf("Unexpected second row: %v", rows[1])
+	}
+}
@@ -0,0 +1,248 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// MigrationTable is the table used to record which migrations have
+// already been applied.
+const MigrationTable = "sqlpro_migrations"
+
+// MigrationLockTable holds the lock taken by Migrate, so that only
+// one instance applies migrations at a time.
+const MigrationLockTable = "sqlpro_migration_lock"
+
+// ErrMigrationLocked is returned by Migrate if the lock could not be
+// taken within MigrationLockTimeout.
+var ErrMigrationLocked = errors.New("sqlpro: Migrations are locked by another instance.")
+
+// migrationLockPoll is the interval to retry taking the lock
+var migrationLockPoll = 200 * time.Millisecond
+
+// Migration is a versioned schema change. Either SQL or Run needs to
+// be set. SQL is executed as is, without placeholder replacement.
+type Migration struct {
+	Version int64
+	Name    string
+	SQL     string
+	Run     func(ctx context.Context, tx *Tx) error
+}
+
+// PlannedMigration is a pending migration returned by Plan.
+type PlannedMigration struct {
+	Version int64
+	Name    string
+	SQL     string // the SQL which will run, empty for Run migrations
+}
+
+type migrationRecord struct {
+	Version   int64     `db:"version,pk"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Plan returns the migrations which Migrate would apply, in the order
+// they would run, together with their SQL. Nothing is changed, so that
+// operators can review the plan before applying it in production. If
+// MigrationTable does not exist yet, all migrations are pending.
+func (db *DB) Plan(ctx context.Context, migrations ...*Migration) ([]PlannedMigration, error) {
+	pending, err := db.WithContext(ctx).pendingMigrations(migrations, false)
+	if err != nil {
+		return nil, xerrors.Errorf("sqlpro.Plan: %w", err)
+	}
+
+	plan := make([]PlannedMigration, 0, len(pending))
+	for _, m := range pending {
+		plan = append(plan, PlannedMigration{Version: m.Version, Name: m.Name, SQL: m.SQL})
+	}
+	return plan, nil
+}
+
+// Migrate applies all migrations which have not been applied yet,
+// ordered by version. Each migration runs in its own transaction,
+// together with its record in MigrationTable.
+//
+// While applying, Migrate holds a lock in MigrationLockTable, so that
+// instances starting at the same time do not race each other. Other
+// instances wait up to MigrationLockTimeout (default 1 minute) for
+// the lock and then see the migrations applied. A lock older than
+// MigrationLockTTL (default 15 minutes) is considered stale, left by
+// an instance which died while holding it, and is taken over. The TTL
+// needs to exceed the time it takes to apply all migrations.
+//
+// Migrate needs a wrapper initialized using "Open".
+func (db *DB) Migrate(ctx context.Context, migrations ...*Migration) (err error) {
+	unlock, err := db.lockMigrations(ctx)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.Migrate: %w", err)
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = xerrors.Errorf("sqlpro.Migrate: Unable to release lock: %w", unlockErr)
+		}
+	}()
+
+	pending, err := db.WithContext(ctx).pendingMigrations(migrations, true)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.Migrate: %w", err)
+	}
+
+	for _, m := range pending {
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		err = db.runMigration(ctx, m)
+		if err != nil {
+			return xerrors.Errorf("sqlpro.Migrate: Migration %d %q failed: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// lockMigrations takes the migration lock and returns the func
+// to release it
+func (db *DB) lockMigrations(ctx context.Context) (func() error, error) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS @ (id INTEGER PRIMARY KEY, locked_at TIMESTAMP)`, MigrationLockTable)
+	if err != nil {
+		return nil, xerrors.Errorf("Unable to create migration lock table: %w", err)
+	}
+
+	timeout := db.MigrationLockTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+
+	ttl := db.MigrationLockTTL
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+
+	insert, args, err := db.replaceArgs(`INSERT INTO @ (id, locked_at) VALUES (1, ?)`, MigrationLockTable, db.storeTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		// the primary key lets only one instance insert the row, the
+		// statement is run without logging the expected errors
+		_, err = db.DB.Exec(insert, args...)
+		if err == nil {
+			return func() error {
+				return db.Exec(`DELETE FROM @ WHERE id = 1`, MigrationLockTable)
+			}, nil
+		}
+
+		// remove a stale lock and try again
+		n, delErr := db.exec(-1, `DELETE FROM @ WHERE id = 1 AND locked_at < ?`, MigrationLockTable, db.storeTime(time.Now().Add(-ttl)))
+		if delErr != nil {
+			return nil, xerrors.Errorf("Unable to remove stale migration lock: %w", delErr)
+		}
+		if n > 0 {
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, xerrors.Errorf("%w (%s)", ErrMigrationLocked, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+}
+
+// pendingMigrations returns the migrations not applied yet, ordered
+// by version. With create set, MigrationTable is created if missing,
+// otherwise a missing table means that nothing has been applied.
+func (db *DB) pendingMigrations(migrations []*Migration, create bool) ([]*Migration, error) {
+	var (
+		applied []int64
+		err     error
+	)
+
+	seen := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		if (m.SQL == "") == (m.Run == nil) {
+			return nil, fmt.Errorf("Migration %d needs either SQL or Run.", m.Version)
+		}
+		if seen[m.Version] {
+			return nil, fmt.Errorf("Migration %d given twice.", m.Version)
+		}
+		seen[m.Version] = true
+	}
+
+	exists := true
+	if create {
+		err = db.Exec(`CREATE TABLE IF NOT EXISTS @ (version BIGINT PRIMARY KEY, name TEXT, applied_at TIMESTAMP)`, MigrationTable)
+		if err != nil {
+			return nil, xerrors.Errorf("Unable to create migration table: %w", err)
+		}
+	} else {
+		// a failing SELECT would abort a Postgres transaction, so the
+		// table is looked up first, if the driver supports it
+		cols, err := db.tableColumns(MigrationTable)
+		exists = err != nil || len(cols) > 0
+	}
+
+	if exists {
+		err = db.Query(&applied, `SELECT version FROM @`, MigrationTable)
+		if err != nil {
+			return nil, xerrors.Errorf("Unable to read applied migrations: %w", err)
+		}
+	}
+
+	done := make(map[int64]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	pending := make([]*Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+
+	return pending, nil
+}
+
+func (db *DB) runMigration(ctx context.Context, m *Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if m.SQL != "" {
+		_, err = tx.DB.DB.Exec(m.SQL)
+	} else {
+		err = m.Run(ctx, tx)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Insert(MigrationTable, &migrationRecord{Version: m.Version, Name: m.Name, AppliedAt: time.Now()})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMigratePlan(t *testing.T) {
+	var count int64
+
+	defer os.Remove("./test_migrate.db")
+
+	mdb, err := Open("sqlite3", "./test_migrate.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdb.Close()
+
+	migrations := []*Migration{
+		{Version: 2, Name: "add row", SQL: "INSERT INTO migrate_test(a) VALUES ('?')"},
+		{Version: 1, Name: "create", SQL: "CREATE TABLE migrate_test(a TEXT)"},
+		{Version: 3, Name: "go", Run: func(ctx context.Context, tx *Tx) error {
+			return tx.Exec("INSERT INTO migrate_test(a) VALUES (?)", "go")
+		}},
+	}
+
+	plan, err := mdb.Plan(context.Background(), migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 3 || plan[0].Version != 1 || plan[0].SQL != "CREATE TABLE migrate_test(a TEXT)" || plan[2].SQL != "" {
+		t.Errorf("Unexpected plan: %v", plan)
+	}
+	cols, err := mdb.tableColumns(MigrationTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 0 {
+		t.Errorf("Expected Plan not to create %s", MigrationTable)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = mdb.Plan(ctx, migrations...)
+	if err == nil {
+		t.Errorf("Expected Plan to use the canceled context")
+	}
+
+	err = mdb.Migrate(context.Background(), migrations[:2]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err = mdb.Plan(context.Background(), migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 1 || plan[0].Name != "go" {
+		t.Errorf("Expected only the last migration pending, got: %v", plan)
+	}
+
+	err = mdb.Migrate(context.Background(), migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mdb.Query(&count, "SELECT count(*) FROM migrate_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got: %d", count)
+	}
+
+	_, err = mdb.Plan(context.Background(), &Migration{Version: 4})
+	if err == nil {
+		t.Errorf("Expected error for migration without SQL and Run")
+	}
+}
+
+func TestMigrateLock(t *testing.T) {
+	var count int64
+
+	defer os.Remove("./test_migrate_lock.db")
+
+	mdb, err := Open("sqlite3", "./test_migrate_lock.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdb.Close()
+	mdb.MigrationLockTimeout = 300 * time.Millisecond
+
+	migration := &Migration{Version: 1, SQL: "CREATE TABLE migrate_lock(a TEXT)"}
+
+	// another instance holds the lock
+	unlock, err := mdb.lockMigrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mdb.Migrate(context.Background(), migration)
+	if !errors.Is(err, ErrMigrationLocked) {
+		t.Errorf("Expected ErrMigrationLocked, got: %v", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		unlock()
+	}()
+
+	err = mdb.Migrate(context.Background(), migration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mdb.Query(&count, "SELECT count(*) FROM @", MigrationLockTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected lock to be released")
+	}
+
+	// the lock of a crashed instance is taken over after the TTL
+	_, err = mdb.lockMigrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mdb.Exec("UPDATE @ SET locked_at = ?", MigrationLockTable, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdb.MigrationLockTTL = time.Hour
+	err = mdb.Migrate(context.Background(), migration, &Migration{Version: 2, SQL: "INSERT INTO migrate_lock(a) VALUES ('stale')"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mdb.Query(&count, "SELECT count(*) FROM migrate_lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected migration to run after taking over the stale lock")
+	}
+}
@@ -0,0 +1,78 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
+
+// MultiResult holds the result sets of a query returning more than
+// one, see QueryMulti.
+type MultiResult struct {
+	db   *DB
+	rows *sql.Rows
+	idx  int // number of scanned result sets
+	err  error
+}
+
+// QueryMulti runs query, which returns multiple result sets, e.g.
+// a batch of statements for MSSQL or a stored procedure. Each call to
+// Scan consumes the next result set into its target. Close needs to
+// be called and returns the first error.
+//
+//	err := db.QueryMulti("SELECT * FROM user; SELECT * FROM role").
+//		Scan(&users).
+//		Scan(&roles).
+//		Close()
+//
+// Support for multiple result sets depends on the driver.
+func (db *DB) QueryMulti(query string, args ...interface{}) *MultiResult {
+	mr := &MultiResult{db: db}
+	mr.err = db.Query(&mr.rows, query, args...)
+	return mr
+}
+
+// Scan scans the next result set into target, which can be anything
+// Query accepts. After an error, Scan does nothing.
+func (mr *MultiResult) Scan(target interface{}) *MultiResult {
+	if mr.err != nil {
+		return mr
+	}
+
+	if mr.idx > 0 && !mr.rows.NextResultSet() {
+		mr.err = mr.rows.Err()
+		if mr.err == nil {
+			mr.err = fmt.Errorf("sqlpro.QueryMulti: No result set #%d.", mr.idx+1)
+		}
+		return mr
+	}
+
+	err := scanWith(target, mr.rows, scanOptions{
+		maxRows:   mr.db.MaxRows,
+		truncate:  mr.db.TruncateMaxRows,
+		truncated: mr.db.truncated,
+		location:  mr.db.TimeLocation,
+		hook:      mr.db.scanHook,
+		mapper:    mr.db.fieldMapper(),
+		foldCase:  mr.db.Driver == ORACLE,
+	})
+	if err != nil {
+		mr.err = xerrors.Errorf("sqlpro.QueryMulti: Result set #%d: %w", mr.idx+1, err)
+		return mr
+	}
+	mr.idx++
+	return mr
+}
+
+// Close closes the result sets and returns the first error of
+// QueryMulti or Scan.
+func (mr *MultiResult) Close() error {
+	if mr.rows != nil {
+		err := mr.rows.Close()
+		if mr.err == nil {
+			mr.err = err
+		}
+	}
+	return mr.err
+}
@@ -0,0 +1,47 @@
+package sqlpro
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQueryMulti(t *testing.T) {
+	var (
+		names []string
+		count int64
+	)
+
+	err := db.Exec("CREATE TABLE test_multi(id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_multi(id, name) VALUES (1, 'henk')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.QueryMulti("SELECT name FROM test_multi").Scan(&names).Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("Expected 1 name, got: %v", names)
+	}
+
+	// sqlite returns one result set only
+	err = db.QueryMulti("SELECT name FROM test_multi").Scan(&names).Scan(&count).Close()
+	if err == nil || !strings.Contains(err.Error(), "No result set #2") {
+		t.Errorf("Expected missing second result set, got: %v", err)
+	}
+
+	err = db.QueryMulti("SELECT name FROM test_multi WHERE id = -1").Scan(&count).Close()
+	if !errors.Is(err, ErrQueryReturnedZeroRows) {
+		t.Errorf("Expected ErrQueryReturnedZeroRows, got: %v", err)
+	}
+
+	err = db.QueryMulti("SELECT broken FROM").Scan(&names).Close()
+	if err == nil {
+		t.Errorf("Expected query error")
+	}
+}
@@ -0,0 +1,81 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// SnakeCase returns name in snake case, it is the default NameMapper.
+// Runs of upper case letters are kept together, so "UserID" becomes
+// "user_id" and "HTTPServer" becomes "http_server".
+func SnakeCase(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+					(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+					sb.WriteRune('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// fieldMapper returns the mapper for untagged fields, nil unless
+// MapUntaggedFields is set
+func (db *DB) fieldMapper() func(string) string {
+	if !db.MapUntaggedFields {
+		return nil
+	}
+	if db.NameMapper != nil {
+		return db.NameMapper
+	}
+	return SnakeCase
+}
+
+// structInfo returns the struct info of t, with untagged fields
+// mapped if MapUntaggedFields is set, see structInfoWith
+func (db *DB) structInfo(t reflect.Type) structInfo {
+	return structInfoWith(t, db.fieldMapper())
+}
+
+// mapUntagged returns true if the untagged field can be mapped to
+// a column. Nested structs (other than time.Time), slices (other
+// than []byte) and maps are skipped, tag them to map them.
+func mapUntagged(field reflect.StructField) bool {
+	if field.PkgPath != "" {
+		// unexported field
+		return false
+	}
+	t := field.Type
+	if t.Implements(valuerType) || reflect.PtrTo(t).Implements(scannerType) {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == timeType
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Interface:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package sqlpro
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRowUntagged struct {
+	ID        int64 `db:",pk,omitempty"`
+	FirstName string
+	UserID    int
+	CreatedAt time.Time
+	Tags      []string
+	Ignored   string `db:"-"`
+	internal  string
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"Name":       "name",
+		"FirstName":  "first_name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Field2":     "field2",
+		"Sha256Sum":  "sha256_sum",
+	} {
+		if got := SnakeCase(in); got != out {
+			t.Errorf("SnakeCase(%q) = %q, expected %q", in, got, out)
+		}
+	}
+}
+
+func TestMapUntaggedFields(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_untagged(id INTEGER PRIMARY KEY AUTOINCREMENT, first_name TEXT, user_id INTEGER, created_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mdb := *db
+	mdb.MapUntaggedFields = true
+
+	info := mdb.structInfo(reflect.TypeOf(testRowUntagged{}))
+	if len(info) != 4 || info["id"] == nil || !info["id"].primaryKey || info["first_name"] == nil {
+		t.Fatalf("Unexpected struct info: %v", info)
+	}
+	if len(getStructInfo(reflect.TypeOf(testRowUntagged{}))) != 1 {
+		t.Errorf("Expected only tagged fields without MapUntaggedFields")
+	}
+
+	now := time.Now().Truncate(time.Second)
+	row := testRowUntagged{FirstName: "Ada", UserID: 7, CreatedAt: now, internal: "x"}
+	err = mdb.Insert("test_untagged", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testRowUntagged
+	err = mdb.First(&got, "test_untagged", "user_id = ?", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != row.ID || got.FirstName != "Ada" || !got.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected row: %+v", got)
+	}
+
+	mdb.NameMapper = strings.ToUpper
+	if info := mdb.structInfo(reflect.TypeOf(testRowUntagged{})); info["FIRSTNAME"] == nil {
+		t.Errorf("Expected NameMapper to be used: %v", info)
+	}
+}
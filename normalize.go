@@ -0,0 +1,68 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// NormalizeFunc normalizes a string value before it is written.
+type NormalizeFunc func(s string) string
+
+var (
+	normalizersMtx sync.RWMutex
+	normalizers    = map[string]NormalizeFunc{
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}
+)
+
+// RegisterNormalizer registers fn under name, so that it can be used
+// in tags as "normalize=<name>". Normalizers run on string fields
+// before Insert, Update, Save and InsertBulk write them, in the order
+// given in the tag. The builtin normalizers "trim", "lower" and
+// "upper" can be used directly:
+//
+//	Email string `db:"email,trim,lower"`
+//	Phone string `db:"phone,normalize=digits"`
+func RegisterNormalizer(name string, fn NormalizeFunc) {
+	normalizersMtx.Lock()
+	defer normalizersMtx.Unlock()
+
+	normalizers[name] = fn
+}
+
+// normalize applies the normalizers of fi to value
+func normalize(value interface{}, fi *fieldInfo) (interface{}, error) {
+	var s string
+
+	switch v := value.(type) {
+	case string:
+		s = v
+	case *string:
+		if v == nil {
+			return value, nil
+		}
+		s = *v
+	default:
+		return nil, fmt.Errorf("Unable to normalize field %s of type %s, need string.", fi.name, reflect.TypeOf(value))
+	}
+
+	normalizersMtx.RLock()
+	defer normalizersMtx.RUnlock()
+
+	for _, name := range fi.normalizers {
+		fn, ok := normalizers[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown normalizer %q for field %s.", name, fi.name)
+		}
+		s = fn(s)
+	}
+
+	if _, ok := value.(*string); ok {
+		return &s, nil
+	}
+	return s, nil
+}
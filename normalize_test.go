@@ -0,0 +1,55 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+)
+
+type testRowNormalized struct {
+	ID    int64   `db:"id,pk,omitempty"`
+	Email string  `db:"email,trim,lower"`
+	Phone *string `db:"phone,normalize=test_digits"`
+}
+
+func TestNormalizers(t *testing.T) {
+	var row testRowNormalized
+
+	RegisterNormalizer("test_digits", func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+	})
+
+	err := db.Exec("CREATE TABLE test_normalized(id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT, phone TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phone := "+49 (30) 123-45"
+	tr := testRowNormalized{Email: "  Henk@Example.COM ", Phone: &phone}
+	err = db.Insert("test_normalized", &tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&row, "SELECT * FROM test_normalized WHERE id = ?", tr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Email != "henk@example.com" || *row.Phone != "493012345" {
+		t.Errorf("Unexpected normalized row: %s %s", row.Email, *row.Phone)
+	}
+
+	type badRow struct {
+		N int64 `db:"n,trim"`
+	}
+	_, _, err = db.valuesFromStruct(badRow{N: 1})
+	if err == nil {
+		t.Errorf("Expected error for normalizer on int64.")
+	}
+}
+
+// testDecimal is a minimal decimal keeping its string representation
@@ -0,0 +1,60 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// NullString is a string which can be NULL. Other than a *string it
+// needs no allocation. An invalid NullString counts as empty for
+// "omitempty".
+type NullString sql.NullString
+
+// Scan implements the Scanner interface.
+func (n *NullString) Scan(value interface{}) error {
+	return (*sql.NullString)(n).Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (n NullString) Value() (driver.Value, error) {
+	return sql.NullString(n).Value()
+}
+
+// NullInt64 is an int64 which can be NULL, see NullString.
+type NullInt64 sql.NullInt64
+
+// Scan implements the Scanner interface.
+func (n *NullInt64) Scan(value interface{}) error {
+	return (*sql.NullInt64)(n).Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (n NullInt64) Value() (driver.Value, error) {
+	return sql.NullInt64(n).Value()
+}
+
+// NullFloat64 is a float64 which can be NULL, see NullString.
+type NullFloat64 sql.NullFloat64
+
+// Scan implements the Scanner interface.
+func (n *NullFloat64) Scan(value interface{}) error {
+	return (*sql.NullFloat64)(n).Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (n NullFloat64) Value() (driver.Value, error) {
+	return sql.NullFloat64(n).Value()
+}
+
+// NullBool is a bool which can be NULL, see NullString.
+type NullBool sql.NullBool
+
+// Scan implements the Scanner interface.
+func (n *NullBool) Scan(value interface{}) error {
+	return (*sql.NullBool)(n).Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (n NullBool) Value() (driver.Value, error) {
+	return sql.NullBool(n).Value()
+}
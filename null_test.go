@@ -0,0 +1,112 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type testRowNull struct {
+	ID    int64           `db:"id,pk,omitempty"`
+	Name  sql.NullString  `db:"name"`
+	Count sql.NullInt64   `db:"count,omitempty"`
+	Score sql.NullFloat64 `db:"score"`
+}
+
+func TestSqlNullTypes(t *testing.T) {
+	var rows []testRowNull
+
+	err := db.Exec("CREATE TABLE test_null(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, count INTEGER DEFAULT 7, score REAL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isZero(sql.NullInt64{Int64: 5}) || isZero(sql.NullString{Valid: true}) {
+		t.Errorf("isZero needs to respect Valid of sql.Null types.")
+	}
+
+	err = db.Insert("test_null", []*testRowNull{
+		{Name: sql.NullString{String: "", Valid: true}, Score: sql.NullFloat64{Float64: 1.5, Valid: true}},
+		{Count: sql.NullInt64{Int64: 3}}, // not valid, omitted
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_null ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %v", rows)
+	}
+	if !rows[0].Name.Valid || rows[0].Score.Float64 != 1.5 || rows[0].Count.Int64 != 7 {
+		t.Errorf("Unexpected first row: %v", rows[0])
+	}
+	if rows[1].Name.Valid || rows[1].Score.Valid || rows[1].Count.Int64 != 7 {
+		t.Errorf("Unexpected second row: %v", rows[1])
+	}
+
+	rows[1].Name = sql.NullString{String: "set", Valid: true}
+	rows[0].Name = sql.NullString{}
+	err = db.Update("test_null", rows)
+	if err != nil {
+		t.Error(err)
+	}
+
+	rows = nil
+	err = db.Query(&rows, "SELECT * FROM test_null ORDER BY id")
+	if err != nil {
+		t.Error(err)
+	}
+	if rows[0].Name.Valid || rows[1].Name.String != "set" {
+		t.Errorf("Unexpected rows after update: %v", rows)
+	}
+}
+
+type testRowNullPro struct {
+	ID     int64       `db:"id,pk,omitempty"`
+	Name   NullString  `db:"name"`
+	Count  NullInt64   `db:"count,omitempty"`
+	Score  NullFloat64 `db:"score"`
+	Active NullBool    `db:"active"`
+	At     NullTime    `db:"at"`
+}
+
+func TestNullTypes(t *testing.T) {
+	var rows []testRowNullPro
+
+	err := db.Exec("CREATE TABLE test_null_pro(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, count INTEGER DEFAULT 7, score REAL, active BOOLEAN, at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err = db.Insert("test_null_pro", []*testRowNullPro{
+		{Name: NullString{String: "name", Valid: true}, Score: NullFloat64{Float64: 2.5, Valid: true}, Active: NullBool{Bool: false, Valid: true}, At: NullTime{Time: &now, Valid: true}},
+		{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_null_pro ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %v", rows)
+	}
+	r := rows[0]
+	if r.Name.String != "name" || r.Score.Float64 != 2.5 || !r.Active.Valid || r.Active.Bool || r.Count.Int64 != 7 || !r.At.Valid || !r.At.Time.Equal(now) {
+		t.Errorf("Unexpected first row: %+v", r)
+	}
+	r = rows[1]
+	if r.Name.Valid || r.Score.Valid || r.Active.Valid || r.At.Valid || r.Count.Int64 != 7 {
+		t.Errorf("Unexpected second row: %+v", r)
+	}
+
+	if db.EscValueForInsert(NullString{}, nil) != "NULL" || db.EscValueForInsert(NullInt64{Int64: 3, Valid: true}, nil) != "3" {
+		t.Errorf("Unexpected escaped values.")
+	}
+}
@@ -0,0 +1,44 @@
+package sqlpro
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOpenURL(t *testing.T) {
+	driver, dsn, pool, err := parseOpenURL("postgresql://u:p@localhost:5432/app?sslmode=disable&max_open_conns=5&conn_max_lifetime=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if driver != POSTGRES || dsn != "postgres://u:p@localhost:5432/app?sslmode=disable" ||
+		pool.maxOpenConns != 5 || pool.connMaxLifetime != time.Minute || pool.maxIdleConns != 25 {
+		t.Errorf("Unexpected result: %s %s %+v", driver, dsn, pool)
+	}
+
+	for _, u := range []string{"mysql://localhost/app", "app.db", "sqlite://", "sqlite://a.db?max_idle_conns=x"} {
+		_, _, _, err = parseOpenURL(u)
+		if err == nil {
+			t.Errorf("Expected error for %q", u)
+		}
+	}
+
+	defer os.Remove("./test_url.db")
+	udb, err := OpenURL("sqlite://./test_url.db?_fk=1&max_open_conns=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udb.Close()
+	if udb.Driver != SQLITE3 || udb.DSN != "./test_url.db?_fk=1" || udb.sqlDB.Stats().MaxOpenConnections != 3 {
+		t.Errorf("Unexpected DB: %s %s %d", udb.Driver, udb.DSN, udb.sqlDB.Stats().MaxOpenConnections)
+	}
+
+	mem, err := OpenURL("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mem.Close()
+	if mem.sqlDB.Stats().MaxOpenConnections != 1 {
+		t.Errorf("Expected in-memory database to use one connection")
+	}
+}
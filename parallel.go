@@ -0,0 +1,99 @@
+package sqlpro
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// ParallelQuery collects read queries to run concurrently, see Parallel.
+type ParallelQuery struct {
+	db      *DB
+	queries []parallelQuery
+}
+
+type parallelQuery struct {
+	target interface{}
+	query  string
+	args   []interface{}
+}
+
+// ParallelErrors is returned by ParallelQuery.Wait if queries failed.
+// It holds the errors in the order the queries were added.
+type ParallelErrors []error
+
+func (pe ParallelErrors) Error() string {
+	msgs := make([]string, 0, len(pe))
+	for _, err := range pe {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Parallel returns a collector for independent read queries, which
+// Wait runs concurrently, each on its own connection of the pool.
+// Use this for endpoints which fan out to several queries.
+//
+//	err := db.Parallel(ctx).
+//		Query(&users, "SELECT * FROM user").
+//		Query(&count, "SELECT COUNT(*) FROM orders").
+//		Wait()
+//
+// The queries run with ctx, see WithContext. Inside a transaction,
+// which has only one connection, the queries run one after another.
+func (db *DB) Parallel(ctx context.Context) *ParallelQuery {
+	return &ParallelQuery{db: db.WithContext(ctx)}
+}
+
+// Query adds a query to run, with the same arguments as DB.Query.
+func (pq *ParallelQuery) Query(target interface{}, query string, args ...interface{}) *ParallelQuery {
+	pq.queries = append(pq.queries, parallelQuery{target: target, query: query, args: args})
+	return pq
+}
+
+// Wait runs all queries and waits for them to finish. It returns
+// ParallelErrors if any query failed. The targets of the failed
+// queries are undefined.
+func (pq *ParallelQuery) Wait() error {
+	errs := make([]error, len(pq.queries))
+
+	run := func(idx int) {
+		q := pq.queries[idx]
+		err := pq.db.ctx.Err()
+		if err == nil {
+			err = pq.db.Query(q.target, q.query, q.args...)
+		}
+		if err != nil {
+			errs[idx] = xerrors.Errorf("sqlpro.Parallel: Query %d: %w", idx, err)
+		}
+	}
+
+	if pq.db.sqlTx != nil {
+		for idx := range pq.queries {
+			run(idx)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for idx := range pq.queries {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				run(idx)
+			}(idx)
+		}
+		wg.Wait()
+	}
+
+	var failed ParallelErrors
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
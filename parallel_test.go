@@ -0,0 +1,53 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	var (
+		names []string
+		count int64
+		ids   []int64
+	)
+
+	err := db.Exec("CREATE TABLE test_parallel(id INTEGER PRIMARY KEY)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_parallel(id) VALUES (1), (2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Parallel(context.Background()).
+		Query(&names, "SELECT name FROM test_hooks_missing").
+		Query(&count, "SELECT COUNT(*) FROM test_parallel").
+		Query(&ids, "SELECT id FROM test_parallel ORDER BY id").
+		Query(&names, "SELECT broken FROM").
+		Wait()
+
+	var pe ParallelErrors
+	if !errors.As(err, &pe) || len(pe) != 2 {
+		t.Fatalf("Expected 2 errors, got: %v", err)
+	}
+	if count == 0 || int64(len(ids)) != count {
+		t.Errorf("Expected successful queries to fill their targets, got: %d %v", count, ids)
+	}
+
+	err = db.Parallel(context.Background()).
+		Query(&count, "SELECT COUNT(*) FROM test_parallel").
+		Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = db.Parallel(ctx).Query(&count, "SELECT COUNT(*) FROM test_parallel").Wait()
+	if !errors.Is(err.(ParallelErrors)[0], context.Canceled) {
+		t.Errorf("Expected canceled context, got: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package sqlpro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ApplyPatch applies a JSON merge patch (RFC 7386) to the row of table
+// identified by pk, which maps the key columns to their values. The
+// top level keys of the patch are column names and must be listed
+// in allowedCols. A null value sets the column to NULL, objects and
+// arrays are stored as JSON.
+//
+// db.ApplyPatch("user", map[string]interface{}{"id": 5}, body, "name", "email")
+//
+// ApplyPatch returns the number of affected rows.
+func (db *DB) ApplyPatch(table string, pk map[string]interface{}, patch []byte, allowedCols ...string) (int64, error) {
+	var (
+		fields map[string]json.RawMessage
+		err    error
+	)
+
+	if len(pk) == 0 {
+		return 0, fmt.Errorf("sqlpro.ApplyPatch: Need a primary key to patch.")
+	}
+
+	err = json.Unmarshal(patch, &fields)
+	if err != nil {
+		return 0, xerrors.Errorf("sqlpro.ApplyPatch: Patch needs to be a JSON object: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	allowed := make(map[string]bool, len(allowedCols))
+	for _, col := range allowedCols {
+		allowed[col] = true
+	}
+
+	setValues := make(map[string]interface{}, len(fields))
+	for col, raw := range fields {
+		if !allowed[col] {
+			return 0, fmt.Errorf("sqlpro.ApplyPatch: Patching column %q is not allowed.", col)
+		}
+		setValues[col], err = patchValue(raw)
+		if err != nil {
+			return 0, xerrors.Errorf("sqlpro.ApplyPatch: Unable to use value for column %q: %w", col, err)
+		}
+	}
+
+	cols := make([]string, 0, len(pk))
+	for col := range pk {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	where := make([]string, 0, len(pk))
+	args := make([]interface{}, 0, len(pk))
+	for _, col := range cols {
+		where = append(where, db.Esc(col)+"="+string(db.PlaceholderValue))
+		args = append(args, pk[col])
+	}
+
+	return db.UpdateMap(table, setValues, strings.Join(where, " AND "), args...)
+}
+
+// patchValue converts the JSON value to a value suitable for the
+// driver.
+func patchValue(raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '{', '[':
+		return string(raw), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, ok := v.(json.Number); ok {
+		i, err := n.Int64()
+		if err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+
+	return v, nil
+}
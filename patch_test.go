@@ -0,0 +1,46 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	var (
+		tr  testRow
+		row testRow
+	)
+
+	createTestTable(t, "test_patch")
+
+	tr = testRow{B: "patch me", C: "patch", D: 1}
+	err := db.Insert("test_patch", &tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.ApplyPatch("test_patch", map[string]interface{}{"a": tr.A}, []byte(`{"b": "patched", "d": 2.5, "e": null}`), "b", "d", "e")
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 patched row, got: %d", n)
+	}
+
+	err = db.Query(&row, "SELECT * FROM test_patch WHERE a = ?", tr.A)
+	if err != nil {
+		t.Error(err)
+	}
+	if row.B != "patched" || row.C != "patch" || row.D != 2.5 {
+		t.Errorf("Unexpected row after patch: %v", row)
+	}
+
+	_, err = db.ApplyPatch("test_patch", map[string]interface{}{"a": tr.A}, []byte(`{"c": "not allowed"}`), "b", "d")
+	if err == nil {
+		t.Errorf("Expected error for patching a column not allowed.")
+	}
+
+	_, err = db.ApplyPatch("test_patch", map[string]interface{}{"a": tr.A}, []byte(`[1, 2]`), "b")
+	if err == nil {
+		t.Errorf("Expected error for patch which is not an object.")
+	}
+}
@@ -0,0 +1,176 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Preload loads the children of the given relation fields for all
+// structs in target, which needs to be a pointer to a struct or to a
+// slice of structs. Relation fields are slices of structs tagged with
+// "hasmany=<table>:<column>", where column is the foreign key in the
+// child table referencing the "pk" field of the parent:
+//
+//	type User struct {
+//		ID     int64   `db:"id,pk,omitempty"`
+//		Orders []Order `db:"-,hasmany=order:user_id"`
+//	}
+//
+//	err := db.Preload(&users, "Orders")
+//
+// All children are loaded with one query per relation (for up to
+// MaxPlaceholder parents), instead of one query per parent, ordered by
+// their primary key. Soft deleted children are skipped, see Delete.
+// Parents without children get an empty, non-nil slice.
+func (db *DB) Preload(target interface{}, fields ...string) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr {
+		return fmt.Errorf("sqlpro.Preload: Target needs to be a pointer, have: %T", target)
+	}
+
+	parents := make([]reflect.Value, 0)
+	elem := targetV.Elem()
+	switch elem.Kind() {
+	case reflect.Struct:
+		parents = append(parents, elem)
+	case reflect.Slice:
+		for i := 0; i < elem.Len(); i++ {
+			p := reflect.Indirect(elem.Index(i))
+			if p.IsValid() {
+				parents = append(parents, p)
+			}
+		}
+	default:
+		return fmt.Errorf("sqlpro.Preload: Target needs to be a pointer to a struct or a slice, have: %T", target)
+	}
+
+	parentT := elem.Type()
+	if parentT.Kind() == reflect.Slice {
+		parentT = parentT.Elem()
+	}
+	if parentT.Kind() == reflect.Ptr {
+		parentT = parentT.Elem()
+	}
+	if parentT.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.Preload: Target needs to be a slice of structs, have: %T", target)
+	}
+
+	pk := db.structInfo(parentT).onlyPrimaryKey()
+	if pk == nil {
+		return fmt.Errorf("sqlpro.Preload: %s needs exactly one 'pk' field.", parentT)
+	}
+
+	for _, name := range fields {
+		field, ok := parentT.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("sqlpro.Preload: Field %q not found in %s.", name, parentT)
+		}
+		table, column, err := hasManyTag(field)
+		if err != nil {
+			return fmt.Errorf("sqlpro.Preload: %s", err)
+		}
+		err = db.preloadHasMany(parents, pk, field, table, column)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasManyTag returns the table and column of a "hasmany=<table>:<column>" tag
+func hasManyTag(field reflect.StructField) (string, string, error) {
+	for _, p := range strings.Split(field.Tag.Get("db"), ",") {
+		if !strings.HasPrefix(p, "hasmany=") {
+			continue
+		}
+		parts := strings.SplitN(p[8:], ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", fmt.Errorf("Unable to parse %q of field %s, need hasmany=<table>:<column>.", p, field.Name)
+		}
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("Field %s has no hasmany tag.", field.Name)
+}
+
+func (db *DB) preloadHasMany(parents []reflect.Value, pk *fieldInfo, field reflect.StructField, table, column string) error {
+	if field.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("sqlpro.Preload: Field %s needs to be a slice, have: %s", field.Name, field.Type)
+	}
+	childT := field.Type.Elem()
+	if childT.Kind() == reflect.Ptr {
+		childT = childT.Elem()
+	}
+	if childT.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.Preload: Field %s needs to be a slice of structs, have: %s", field.Name, field.Type)
+	}
+
+	childInfo := db.tableStructInfo(childT, table)
+	fk, ok := childInfo[column]
+	if !ok {
+		return fmt.Errorf("sqlpro.Preload: Column %q is not mapped in %s.", column, childT)
+	}
+
+	orderBy := ""
+	for idx, childPk := range childInfo.primaryKeys() {
+		if idx == 0 {
+			orderBy = " ORDER BY "
+		} else {
+			orderBy += ", "
+		}
+		orderBy += db.Esc(childPk.dbName)
+	}
+
+	cols, err := db.selectList(childT, table, column)
+	if err != nil {
+		return err
+	}
+
+	// parents by key, a key can be used by more than one parent
+	byKey := make(map[string][]reflect.Value, 0)
+	keys := make([]interface{}, 0, len(parents))
+	for _, p := range parents {
+		p.FieldByIndex(field.Index).Set(reflect.MakeSlice(field.Type, 0, 0))
+
+		key := p.FieldByIndex(pk.structField.Index).Interface()
+		k := fmt.Sprint(key)
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[k] = append(byKey[k], p)
+	}
+
+	// above MaxPlaceholder the keys would be inlined into the query
+	chunkSize := db.MaxPlaceholder
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	where := db.scopeCondition(childT, table, db.Esc(column)+" IN ?")
+
+	for start := 0; start < len(keys); start += chunkSize {
+		end := start + chunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		children := reflect.New(field.Type)
+		err = db.Query(children.Interface(),
+			"SELECT "+cols+" FROM "+db.EscTable(table)+" WHERE "+where+orderBy, keys[start:end])
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < children.Elem().Len(); i++ {
+			child := children.Elem().Index(i)
+			fkV := reflect.Indirect(child).FieldByIndex(fk.structField.Index)
+			k := fmt.Sprint(reflect.Indirect(fkV).Interface())
+			for _, p := range byKey[k] {
+				f := p.FieldByIndex(field.Index)
+				f.Set(reflect.Append(f, child))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package sqlpro
+
+import (
+	"testing"
+	"time"
+)
+
+type testPreloadOrder struct {
+	ID     int64  `db:"id,pk,omitempty"`
+	UserID int64  `db:"user_id"`
+	Item   string `db:"item"`
+}
+
+type testPreloadUser struct {
+	ID     int64               `db:"id,pk,omitempty"`
+	Name   string              `db:"name"`
+	Orders []testPreloadOrder  `db:"-,hasmany=test_preload_order:user_id"`
+	Recent []*testPreloadOrder `db:"-,hasmany=test_preload_order:user_id"`
+}
+
+func TestPreload(t *testing.T) {
+	var users []*testPreloadUser
+
+	for _, stmt := range []string{
+		"CREATE TABLE test_preload_user(id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE test_preload_order(id INTEGER PRIMARY KEY, user_id INTEGER, item TEXT)",
+		"INSERT INTO test_preload_user VALUES(1, 'ann'), (2, 'bob'), (3, 'carl')",
+		"INSERT INTO test_preload_order VALUES(1, 1, 'a'), (2, 2, 'b'), (3, 1, 'c')",
+	} {
+		err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := db.Query(&users, "SELECT * FROM test_preload_user ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Preload(&users, "Orders", "Recent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users[0].Orders) != 2 || users[0].Orders[0].Item != "a" || users[0].Orders[1].Item != "c" {
+		t.Errorf("Unexpected orders of first user: %v", users[0].Orders)
+	}
+	if len(users[1].Recent) != 1 || users[1].Recent[0].Item != "b" {
+		t.Errorf("Unexpected orders of second user: %v", users[1].Recent)
+	}
+	if users[2].Orders == nil || len(users[2].Orders) != 0 {
+		t.Errorf("Expected empty orders for third user, got: %v", users[2].Orders)
+	}
+
+	var user testPreloadUser
+	err = db.Query(&user, "SELECT * FROM test_preload_user WHERE id = 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Preload(&user, "Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Orders) != 1 {
+		t.Errorf("Expected 1 order, got: %v", user.Orders)
+	}
+
+	if db.Preload(&user, "Name") == nil {
+		t.Errorf("Expected error for field without hasmany tag")
+	}
+}
+
+func TestPreloadChunks(t *testing.T) {
+	type testChunkOrder struct {
+		ID        int        `db:"id,pk,omitempty"`
+		UserID    int        `db:"user_id"`
+		DeletedAt *time.Time `db:"deleted_at,softdelete"`
+	}
+	type testChunkUser struct {
+		ID     int              `db:"id,pk,omitempty"`
+		Orders []testChunkOrder `db:"-,hasmany=test_preload_chunk_order:user_id"`
+	}
+
+	err := db.Exec("CREATE TABLE test_preload_chunk_order(id INTEGER PRIMARY KEY, user_id INTEGER, deleted_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// more parents than MaxPlaceholder, with int keys which cannot
+	// be inlined into the query
+	users := make([]testChunkUser, 150)
+	orders := make([]testChunkOrder, 0, len(users))
+	for i := range users {
+		users[i].ID = i + 1
+		orders = append(orders, testChunkOrder{UserID: i + 1})
+	}
+	err = db.InsertBulk("test_preload_chunk_order", orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("UPDATE test_preload_chunk_order SET deleted_at = ? WHERE user_id = 150", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Preload(&users, "Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users[0].Orders) != 1 || len(users[120].Orders) != 1 || users[120].Orders[0].UserID != 121 {
+		t.Errorf("Expected one order per user, got: %v %v", users[0].Orders, users[120].Orders)
+	}
+	if len(users[149].Orders) != 0 {
+		t.Errorf("Expected soft deleted order to be skipped, got: %v", users[149].Orders)
+	}
+
+	err = db.Unscoped().Preload(&users, "Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users[149].Orders) != 1 {
+		t.Errorf("Expected soft deleted order for Unscoped, got: %v", users[149].Orders)
+	}
+}
@@ -16,6 +16,10 @@ import (
 
 var db *DB
 
+// sqliteDB uses the same database as db, but is created by Open and
+// has the SQLite dialect set, for tests of dialect specific statements
+var sqliteDB *DB
+
 type jsonStore struct {
 	Field  string `db:"field"`
 	Field2 string `db:"field2"`
@@ -123,11 +127,27 @@ func TestMain(m *testing.M) {
 
 	db = New(dbWrap)
 
+	sqliteDB, err = Open("sqlite3", "./test.db")
+	if err != nil {
+		cleanup()
+		log.Fatal(err)
+	}
+
 	exitCode := m.Run()
+	sqliteDB.Close()
 	cleanup()
 	os.Exit(exitCode)
 }
 
+// createTestTable creates table with the columns of "test", for tests
+// which must not change the rows of "test"
+func createTestTable(t *testing.T, table string) {
+	err := db.Exec(`CREATE TABLE @(a INTEGER PRIMARY KEY AUTOINCREMENT, b TEXT, c TEXT, d REAL, e DATETIME, f TEXT)`, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestInsertSliceStructPtr(t *testing.T) {
 	var (
 		err      error
@@ -590,11 +610,14 @@ func TestInsertMany(t *testing.T) {
 	}
 }
 
-func ATestInsertBulk(t *testing.T) {
+func TestInsertBulk(t *testing.T) {
+	var count int64
+
 	rows := make([]*testRow, 0)
-	for i := 0; i < 1000; i++ {
+	for i := 0; i < 100; i++ {
 		tr := &testRow{
-			B: fmt.Sprintf("row %d", i+1),
+			B: fmt.Sprintf("bulk'row %d", i+1),
+			C: "bulk?",
 			D: float64(i + 1),
 		}
 		rows = append(rows, tr)
@@ -604,6 +627,39 @@ func ATestInsertBulk(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+
+	err = db.Query(&count, "SELECT count(*) FROM test WHERE c = ? AND b LIKE ?", "bulk?", "bulk'row %")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 100 {
+		t.Errorf("Expected 100 bulk inserted rows, got: %d", count)
+	}
+}
+
+func TestInsertBulkChunked(t *testing.T) {
+	var count int64
+
+	db2 := *db
+	db2.MaxBulkParams = 12
+
+	rows := make([]testRow, 0)
+	for i := 0; i < 25; i++ {
+		rows = append(rows, testRow{B: fmt.Sprintf("chunk %d", i+1), C: "chunked"})
+	}
+
+	err := db2.InsertBulk("test", rows)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Query(&count, "SELECT count(*) FROM test WHERE c = ?", "chunked")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 25 {
+		t.Errorf("Expected 25 bulk inserted rows, got: %d", count)
+	}
 }
 
 func TestDelete(t *testing.T) {
@@ -621,7 +677,7 @@ func TestQueryIntStruct(t *testing.T) {
 		t.Errorf("Expected ErrQueryReturnedZeroRows.")
 	}
 	if !errors.Is(err, ErrQueryReturnedZeroRows) {
-		t.Errorf("Expected ErrQueryReturnedZeroRows, got: %w", err)
+		t.Errorf("Expected ErrQueryReturnedZeroRows, got: %v", err)
 	}
 }
 
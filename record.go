@@ -0,0 +1,71 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TableNamer is implemented by structs which know their table. The
+// Record functions use it, so that the table does not need to be
+// passed.
+type TableNamer interface {
+	TableName() string
+}
+
+// InsertRecord works like Insert, with the table taken from the
+// TableName method of data, or its elements for slices.
+//
+//	err := db.InsertRecord(&user)
+func (db *DB) InsertRecord(data interface{}) error {
+	table, err := recordTable(data)
+	if err != nil {
+		return err
+	}
+	return db.Insert(table, data)
+}
+
+// UpdateRecord works like Update, see InsertRecord.
+func (db *DB) UpdateRecord(data interface{}) error {
+	table, err := recordTable(data)
+	if err != nil {
+		return err
+	}
+	return db.Update(table, data)
+}
+
+// SaveRecord works like Save, see InsertRecord.
+func (db *DB) SaveRecord(data interface{}) error {
+	table, err := recordTable(data)
+	if err != nil {
+		return err
+	}
+	return db.Save(table, data)
+}
+
+// DeleteRecord works like Delete, see InsertRecord.
+func (db *DB) DeleteRecord(data interface{}) error {
+	table, err := recordTable(data)
+	if err != nil {
+		return err
+	}
+	return db.Delete(table, data)
+}
+
+// recordTable returns the table of data, which is a struct or a
+// slice of structs implementing TableNamer, with a value or pointer
+// receiver
+func recordTable(data interface{}) (string, error) {
+	t := reflect.TypeOf(data)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("sqlpro: Need a struct or slice of structs to get the table, have: %T", data)
+	}
+
+	tn, ok := reflect.New(t).Interface().(TableNamer)
+	if !ok {
+		return "", fmt.Errorf("sqlpro: Unable to get table of %s, implement TableName().", t)
+	}
+	return tn.TableName(), nil
+}
@@ -0,0 +1,57 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testRowRecord struct {
+	ID   int64  `db:"id,pk,omitempty"`
+	Name string `db:"name"`
+}
+
+func (testRowRecord) TableName() string {
+	return "test_record"
+}
+
+func TestRecord(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_record(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowRecord{Name: "a"}
+	err = db.InsertRecord(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.Name = "b"
+	err = db.UpdateRecord(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []*testRowRecord{{Name: "c"}, &row}
+	err = db.SaveRecord(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	err = db.Query(&names, "SELECT name FROM test_record ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"b", "c"}) {
+		t.Errorf("Unexpected names: %v", names)
+	}
+
+	err = db.DeleteRecord(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.InsertRecord(&testRowValidate{Name: "x"})
+	if err == nil {
+		t.Errorf("Expected error for struct without TableName")
+	}
+}
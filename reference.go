@@ -0,0 +1,81 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+// SyncOptions configures SyncReferenceTable.
+type SyncOptions struct {
+	// DeleteMissing deletes all rows of the table not in the
+	// given rows
+	DeleteMissing bool
+}
+
+// SyncReferenceTable upserts rows, a slice of structs, into table.
+// Rows are matched by keyCols, missing rows are inserted and changed
+// rows updated. Use this at startup to keep enum or reference tables
+// in sync with constants defined in code.
+//
+//	res, err := db.SyncReferenceTable(ctx, "status", []Status{
+//		{Code: "draft", Label: "Draft"},
+//		{Code: "published", Label: "Published"},
+//	}, []string{"code"}, SyncOptions{DeleteMissing: true})
+//
+// Rows are changed if the text representation of a column differs.
+// Like SyncDerivedTable the changes are done in one transaction.
+func (db *DB) SyncReferenceTable(ctx context.Context, table string, rows interface{}, keyCols []string, opts SyncOptions) (SyncResult, error) {
+	if len(keyCols) == 0 {
+		return SyncResult{}, fmt.Errorf("sqlpro.SyncReferenceTable: Need at least one key column.")
+	}
+
+	rowsV := reflect.ValueOf(rows)
+	if rowsV.Kind() != reflect.Slice {
+		return SyncResult{}, fmt.Errorf("sqlpro.SyncReferenceTable: rows needs to be a slice, have: %T", rows)
+	}
+
+	colSet := make(map[string]bool, 0)
+	sourceRows := make([]map[string]interface{}, 0, rowsV.Len())
+	seen := make(map[string]int, rowsV.Len())
+	for i := 0; i < rowsV.Len(); i++ {
+		err := db.validate(reflect.Indirect(rowsV.Index(i)))
+		if err != nil {
+			return SyncResult{}, xerrors.Errorf("sqlpro.SyncReferenceTable: Row %d: %w", i, err)
+		}
+		values, _, err := db.tableValuesFromStruct(table, reflect.Indirect(rowsV.Index(i)).Interface())
+		if err != nil {
+			return SyncResult{}, xerrors.Errorf("sqlpro.SyncReferenceTable: Row %d: %w", i, err)
+		}
+		for _, key := range keyCols {
+			if _, ok := values[key]; !ok {
+				return SyncResult{}, fmt.Errorf("sqlpro.SyncReferenceTable: Row %d has no value for key column %q.", i, key)
+			}
+		}
+		key := syncKey(values, keyCols)
+		if first, ok := seen[key]; ok {
+			return SyncResult{}, fmt.Errorf("sqlpro.SyncReferenceTable: Row %d has the same key as row %d.", i, first)
+		}
+		seen[key] = i
+
+		for col := range values {
+			colSet[col] = true
+		}
+		sourceRows = append(sourceRows, values)
+	}
+
+	cols := make([]string, 0, len(colSet))
+	for col := range colSet {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	res, err := db.syncRows(ctx, table, cols, sourceRows, keyCols, opts.DeleteMissing)
+	if err != nil {
+		return res, xerrors.Errorf("sqlpro.SyncReferenceTable: %w", err)
+	}
+	return res, nil
+}
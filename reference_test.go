@@ -0,0 +1,71 @@
+package sqlpro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type testRowReference struct {
+	Code  string `db:"code"`
+	Label string `db:"label"`
+	Rank  int64  `db:"rank"`
+}
+
+func TestSyncReferenceTable(t *testing.T) {
+	var rows []testRowReference
+
+	err := db.Exec("CREATE TABLE test_reference(code TEXT PRIMARY KEY, label TEXT, rank INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_reference VALUES('draft', 'Old', 1), ('legacy', 'Legacy', 9)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref := []testRowReference{
+		{Code: "draft", Label: "Draft", Rank: 1},
+		{Code: "published", Label: "Published", Rank: 2},
+	}
+
+	res, err := db.SyncReferenceTable(context.Background(), "test_reference", ref, []string{"code"}, SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (SyncResult{Inserted: 1, Updated: 1}) {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+
+	res, err = db.SyncReferenceTable(context.Background(), "test_reference", ref, []string{"code"}, SyncOptions{DeleteMissing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (SyncResult{Deleted: 1}) {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_reference ORDER BY rank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, ref) {
+		t.Errorf("Expected %v, got: %v", ref, rows)
+	}
+
+	res, err = db.SyncReferenceTable(context.Background(), "test_reference", []*testRowReference{
+		{Code: "draft", Label: "Draft", Rank: 1},
+		{Code: "published", Label: "Published!", Rank: 2},
+	}, []string{"code"}, SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (SyncResult{Updated: 1}) {
+		t.Errorf("Unexpected result for pointer rows: %+v", res)
+	}
+
+	_, err = db.SyncReferenceTable(context.Background(), "test_reference", append(ref, ref[0]), []string{"code"}, SyncOptions{})
+	if err == nil {
+		t.Errorf("Expected error for duplicate keys")
+	}
+}
@@ -0,0 +1,92 @@
+package sqlpro
+
+import (
+	"strings"
+)
+
+// reservedWords are the keywords which need to be quoted when used
+// as identifiers in all supported databases.
+var reservedWords = wordSet(`
+	all alter and any as asc between by case check column constraint
+	create cross current_date current_time current_timestamp current_user
+	default delete desc distinct drop else end except exists false fetch
+	for foreign from full grant group having in inner insert intersect
+	into is join key left like limit natural not null offset on or order
+	outer primary references right select session_user set some table
+	then to true union unique update user using values when where with
+`)
+
+// reservedWordsByDriver are the additional keywords per driver
+var reservedWordsByDriver = map[dbDriver]map[string]bool{
+	POSTGRES: wordSet(`
+		analyse analyze array asymmetric both cast collate concurrently
+		current_catalog current_role current_schema deferrable do freeze
+		ilike initially isnull lateral leading localtime localtimestamp
+		notnull only overlaps placing returning similar symmetric trailing
+		variadic verbose window
+	`),
+	SQLITE3: wordSet(`
+		abort autoincrement collate conflict deferrable escape glob
+		indexed isnull notnull raise regexp replace returning transaction
+	`),
+	MSSQL: wordSet(`
+		backup browse bulk clustered collate compute contains containstable
+		database dbcc deny file fillfactor freetext holdlock identity index
+		kill lineno merge nocheck nonclustered openquery option pivot plan
+		proc procedure public raiserror read readtext revert rowcount rule
+		save schema top tran transaction trigger truncate unpivot view
+		waitfor while writetext
+	`),
+	ORACLE: wordSet(`
+		access audit cluster comment compress connect date exclusive file
+		identified immediate increment index initial level lock long
+		maxextents minus mode modify nocompress number online option pctfree
+		prior public raw rename resource row rowid rownum rows share size
+		start successful synonym sysdate uid validate view whenever
+	`),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool, 0)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// IsReserved returns true if the given identifier is a reserved
+// word for the db's driver.
+func (db *DB) IsReserved(ident string) bool {
+	lower := strings.ToLower(ident)
+	if reservedWords[lower] {
+		return true
+	}
+	return reservedWordsByDriver[db.Driver][lower]
+}
+
+// needsQuoting returns true if the identifier cannot be used
+// unquoted: it is reserved, or it contains anything but lowercase
+// letters, digits and underscores.
+func (db *DB) needsQuoting(ident string) bool {
+	if ident == "" || db.IsReserved(ident) {
+		return true
+	}
+	return !isLowerIdent(ident)
+}
+
+// isLowerIdent returns true if ident consists of lower case letters,
+// digits and "_", and does not start with a digit
+func isLowerIdent(ident string) bool {
+	if ident == "" {
+		return false
+	}
+	for idx, r := range ident {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && idx > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+func TestMinimalEscape(t *testing.T) {
+	db2 := *db
+	db2.MinimalEscape = true
+	db2.Driver = POSTGRES
+
+	for ident, exp := range map[string]string{
+		"name":      `name`,
+		"order":     `"order"`,
+		"User":      `"User"`,
+		"returning": `"returning"`,
+		"my col":    `"my col"`,
+		"1st":       `"1st"`,
+		`a"b`:       `"a""b"`,
+	} {
+		if db2.Esc(ident) != exp {
+			t.Errorf("Esc(%q): expected %s, got %s", ident, exp, db2.Esc(ident))
+		}
+	}
+
+	if db.Esc("name") != `"name"` {
+		t.Errorf("Esc must quote all identifiers without MinimalEscape.")
+	}
+}
+
+func TestEscQualified(t *testing.T) {
+	db2 := *db
+	for ident, exp := range map[string]string{
+		"analytics.events":    `"analytics"."events"`,
+		`"my.schema".events`:  `"my.schema"."events"`,
+		`"a""b".c`:            `"a""b"."c"`,
+		"db.analytics.events": `"db"."analytics"."events"`,
+	} {
+		if got := db2.Esc(ident); got != exp {
+			t.Errorf("Esc(%q): expected %s, got %s", ident, exp, got)
+		}
+	}
+
+	db2.DefaultSchema = "analytics"
+	if got := db2.EscTable("events"); got != `"analytics"."events"` {
+		t.Errorf("Unexpected EscTable: %s", got)
+	}
+	if got := db2.EscTable("public.events"); got != `"public"."events"` {
+		t.Errorf("Unexpected EscTable: %s", got)
+	}
+	if got := db2.Esc("events"); got != `"events"` {
+		t.Errorf("Esc must not add DefaultSchema: %s", got)
+	}
+
+	db2.MinimalEscape = true
+	db2.Driver = POSTGRES
+	if got := db2.EscTable("Order"); got != `analytics."Order"` {
+		t.Errorf("Unexpected EscTable: %s", got)
+	}
+}
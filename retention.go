@@ -0,0 +1,170 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// retentionChunk is the default number of rows removed per chunk
+const retentionChunk = 1000
+
+// Retention is a data retention policy registered with
+// RetentionPolicy. Its fields can be changed after registration,
+// before EnforceRetention runs.
+type Retention struct {
+	Table        string
+	Column       string        // time column the age of a row is measured by
+	MaxAge       time.Duration // rows older than this are expired
+	KeyColumn    string        // unique column to remove rows by, "id" if empty
+	ArchiveTable string        // if set, expired rows are copied here before removal
+	ChunkSize    int           // rows per chunk, 1000 if 0, at most MaxPlaceholder
+}
+
+// RetentionResult reports the work done by EnforceRetention for one
+// policy.
+type RetentionResult struct {
+	Table    string
+	Deleted  int64
+	Archived int64
+	Chunks   int
+	Duration time.Duration
+}
+
+// retentionRegistry holds the registered policies, it is shared by
+// all copies of a DB
+type retentionRegistry struct {
+	sync.Mutex
+	policies []*Retention
+}
+
+// RetentionPolicy registers a policy which removes the rows of table
+// whose column is older than maxAge, when EnforceRetention runs.
+//
+//	db.RetentionPolicy("audit_log", "created_at", 90*24*time.Hour).ArchiveTable = "audit_log_archive"
+func (db *DB) RetentionPolicy(table, column string, maxAge time.Duration) *Retention {
+	if db.retention == nil {
+		panic("sqlpro.RetentionPolicy: The wrapper must be created using New or Open.")
+	}
+
+	policy := &Retention{Table: table, Column: column, MaxAge: maxAge}
+
+	db.retention.Lock()
+	db.retention.policies = append(db.retention.policies, policy)
+	db.retention.Unlock()
+
+	return policy
+}
+
+// EnforceRetention removes the expired rows of all policies registered
+// with RetentionPolicy, in chunks of ChunkSize rows, oldest first. If
+// the policy has an ArchiveTable, the rows are copied there before.
+// ArchiveTable needs the columns of the table in the same order. With
+// "Open", each chunk runs in its own transaction.
+//
+// EnforceRetention stops at the first error or when ctx is done, and
+// returns the results of the policies processed so far.
+func (db *DB) EnforceRetention(ctx context.Context) ([]RetentionResult, error) {
+	if db.retention == nil {
+		return nil, nil
+	}
+
+	db.retention.Lock()
+	policies := make([]Retention, 0, len(db.retention.policies))
+	for _, p := range db.retention.policies {
+		policies = append(policies, *p)
+	}
+	db.retention.Unlock()
+
+	results := make([]RetentionResult, 0, len(policies))
+	for _, p := range policies {
+		res, err := db.WithContext(ctx).enforceRetention(ctx, p)
+		results = append(results, res)
+		if err != nil {
+			return results, xerrors.Errorf("sqlpro.EnforceRetention: Table %q: %w", p.Table, err)
+		}
+	}
+	return results, nil
+}
+
+func (db *DB) enforceRetention(ctx context.Context, p Retention) (RetentionResult, error) {
+	start := time.Now()
+	res := RetentionResult{Table: p.Table}
+
+	if p.Column == "" || p.MaxAge <= 0 {
+		return res, fmt.Errorf("Need a column and a max age > 0.")
+	}
+	key := p.KeyColumn
+	if key == "" {
+		key = "id"
+	}
+	chunkSize := p.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = retentionChunk
+	}
+	// above MaxPlaceholder the keys would be inlined into the query
+	if db.MaxPlaceholder > 0 && chunkSize > db.MaxPlaceholder {
+		chunkSize = db.MaxPlaceholder
+	}
+	cutoff := db.storeTime(time.Now().Add(-p.MaxAge))
+
+	for {
+		err := ctx.Err()
+		if err != nil {
+			res.Duration = time.Since(start)
+			return res, err
+		}
+
+		var keys []interface{}
+		query := db.Paginate(Fragment("SELECT @ FROM "+db.EscTable(p.Table)+" WHERE @ < ? ORDER BY @, @", key, p.Column, cutoff, p.Column, key), int64(chunkSize), 0)
+		err = db.Query(&keys, query.SQL, query.Args...)
+		if err != nil {
+			res.Duration = time.Since(start)
+			return res, err
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		var archived, deleted int64
+		chunk := func(db *DB) error {
+			var err error
+			archived, deleted = 0, 0
+			if p.ArchiveTable != "" {
+				archived, err = db.exec(-1, "INSERT INTO "+db.EscTable(p.ArchiveTable)+" SELECT * FROM "+db.EscTable(p.Table)+" WHERE @ IN ?", key, keys)
+				if err != nil {
+					return err
+				}
+			}
+			return db.withHistoryWhere(p.Table, " WHERE @ IN ?", []interface{}{key, keys}, func(db *DB) (err error) {
+				deleted, err = db.exec(-1, "DELETE FROM "+db.EscTable(p.Table)+" WHERE @ IN ?", key, keys)
+				return err
+			})
+		}
+
+		if db.sqlTx == nil && db.sqlDB != nil {
+			err = db.RunTx(ctx, func(tx *Tx) error {
+				return chunk(tx.DB)
+			})
+		} else {
+			err = chunk(db)
+		}
+		if err != nil {
+			res.Duration = time.Since(start)
+			return res, err
+		}
+
+		res.Chunks++
+		res.Archived += archived
+		res.Deleted += deleted
+		if len(keys) < chunkSize {
+			break
+		}
+	}
+
+	res.Duration = time.Since(start)
+	return res, nil
+}
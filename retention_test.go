@@ -0,0 +1,111 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.Exec("CREATE TABLE audit(id INTEGER PRIMARY KEY AUTOINCREMENT, msg TEXT, created_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.Exec("CREATE TABLE audit_archive(id INTEGER, msg TEXT, created_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.Exec("CREATE TABLE session(id INTEGER PRIMARY KEY AUTOINCREMENT, seen_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 25; i++ {
+		err = tdb.Exec("INSERT INTO audit(msg, created_at) VALUES (?, ?)", fmt.Sprintf("msg %d", i), now.Add(-time.Duration(i)*24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tdb.Exec("INSERT INTO session(seen_at) VALUES (?)", now.Add(-time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit := tdb.RetentionPolicy("audit", "created_at", 10*24*time.Hour+time.Hour)
+	audit.ArchiveTable = "audit_archive"
+	audit.ChunkSize = 4
+	tdb.RetentionPolicy("session", "seen_at", 20*time.Hour+time.Minute)
+
+	results, err := tdb.EnforceRetention(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got: %v", results)
+	}
+	if results[0].Deleted != 14 || results[0].Archived != 14 || results[0].Chunks != 4 {
+		t.Errorf("Unexpected audit result: %+v", results[0])
+	}
+	if results[1].Deleted != 4 || results[1].Archived != 0 || results[1].Chunks != 1 {
+		t.Errorf("Unexpected session result: %+v", results[1])
+	}
+
+	var count int64
+	err = tdb.Query(&count, "SELECT COUNT(*) FROM audit_archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 14 {
+		t.Errorf("Expected 14 archived rows, got: %d", count)
+	}
+
+	// nothing left to do
+	results, err = tdb.EnforceRetention(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Deleted != 0 || results[1].Deleted != 0 {
+		t.Errorf("Expected nothing to delete, got: %+v", results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tdb.EnforceRetention(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected canceled context, got: %v", err)
+	}
+}
+
+func TestRetentionChunks(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.Exec("CREATE TABLE token(token BLOB PRIMARY KEY, created_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// more expired rows than MaxPlaceholder, with keys scanned as
+	// []byte which cannot be inlined into the query
+	old := time.Now().Add(-48 * time.Hour)
+	for i := 0; i < 150; i++ {
+		err = tdb.Exec("INSERT INTO token(token, created_at) VALUES (?, ?)", []byte(fmt.Sprintf("t%d", i)), old)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tdb.RetentionPolicy("token", "created_at", 24*time.Hour).KeyColumn = "token"
+	results, err := tdb.EnforceRetention(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Deleted != 150 || results[0].Chunks != 2 {
+		t.Errorf("Expected 150 rows deleted in 2 chunks, got: %+v", results[0])
+	}
+}
@@ -0,0 +1,94 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// serializationFailure is the SQLSTATE of serialization failures
+const serializationFailure = "40001"
+
+// txRetryWait is the wait before the first retry of RunTxWithRetry,
+// it is doubled for each retry up to txRetryMaxWait
+var (
+	txRetryWait    = 10 * time.Millisecond
+	txRetryMaxWait = time.Second
+)
+
+// RunTxWithRetry works like RunTx, but runs fn again in a new
+// transaction if the transaction fails with a serialization failure
+// (SQLSTATE 40001). This is needed for CockroachDB, which reports
+// conflicts of concurrent transactions this way, and for Postgres
+// transactions with isolation level SERIALIZABLE. fn needs to be
+// safe to run multiple times.
+//
+// The transaction is tried up to TxMaxRetries + 1 times, waiting with
+// exponential backoff between the tries. The last error is returned.
+// Inside a transaction, fn runs once in a nested transaction, the
+// retry is left to the outermost transaction.
+//
+//	err := db.RunTxWithRetry(ctx, func(tx *sqlpro.Tx) error { ... })
+func (db *DB) RunTxWithRetry(ctx context.Context, fn func(tx *Tx) error) error {
+	return db.RunTxOptionsWithRetry(ctx, nil, fn)
+}
+
+// RunTxOptionsWithRetry is RunTxWithRetry using the given transaction
+// options.
+func (db *DB) RunTxOptionsWithRetry(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	if db.sqlTx != nil {
+		return db.RunTxOptions(ctx, opts, fn)
+	}
+
+	maxRetries := db.TxMaxRetries
+	if maxRetries == 0 {
+		maxRetries = 5
+	}
+
+	wait := txRetryWait
+	for try := 0; ; try++ {
+		err := db.RunTxOptions(ctx, opts, fn)
+		if err == nil || try >= maxRetries || !IsSerializationFailure(err) {
+			return err
+		}
+
+		// jitter avoids that the conflicting transactions retry in lockstep
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait/2 + time.Duration(rand.Int63n(int64(wait)))):
+		}
+		wait *= 2
+		if wait > txRetryMaxWait {
+			wait = txRetryMaxWait
+		}
+	}
+}
+
+// IsSerializationFailure returns true if err reports a serialization
+// failure (SQLSTATE 40001) of the transaction, which can be retried.
+func IsSerializationFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == serializationFailure
+	}
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState() == serializationFailure
+	}
+
+	// errors of statements are reported as text, see sqlError
+	msg := err.Error()
+	return strings.Contains(msg, "SQLSTATE "+serializationFailure) ||
+		strings.Contains(msg, "could not serialize access") ||
+		strings.Contains(msg, "restart transaction")
+}
@@ -0,0 +1,68 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/xerrors"
+)
+
+func TestRunTxWithRetry(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	defer func(wait time.Duration) { txRetryWait = wait }(txRetryWait)
+	txRetryWait = time.Millisecond
+
+	calls := 0
+	err := tdb.RunTxWithRetry(context.Background(), func(tx *Tx) error {
+		calls++
+		err := tx.Exec("INSERT INTO test (b) VALUES (?)", fmt.Sprint("try", calls))
+		if err != nil {
+			return err
+		}
+		if calls < 3 {
+			return &pq.Error{Code: "40001", Message: "restart transaction"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bs []string
+	err = tdb.Query(&bs, "SELECT b FROM test WHERE b LIKE 'try%'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || !reflect.DeepEqual(bs, []string{"try3"}) {
+		t.Errorf("Unexpected calls %d and rows %v", calls, bs)
+	}
+
+	calls = 0
+	tdb.TxMaxRetries = 1
+	err = tdb.RunTxWithRetry(context.Background(), func(tx *Tx) error {
+		calls++
+		return errors.New("pq: could not serialize access due to concurrent update")
+	})
+	if err == nil || calls != 2 {
+		t.Errorf("Expected error after 2 calls, got %d: %v", calls, err)
+	}
+
+	calls = 0
+	err = tdb.RunTxWithRetry(context.Background(), func(tx *Tx) error {
+		calls++
+		return errors.New("other")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected no retry for other errors, got %d calls", calls)
+	}
+
+	if IsSerializationFailure(xerrors.Errorf("wrapped: %w", &pq.Error{Code: "23505"})) {
+		t.Errorf("Unique violation is no serialization failure")
+	}
+}
@@ -0,0 +1,146 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunningQuery is a statement currently executed by sqlpro.
+type RunningQuery struct {
+	ID      int64
+	SQL     string // the statement with whitespace collapsed
+	Started time.Time
+}
+
+// queryRegistry tracks the running statements. It is shared by
+// all copies of a DB.
+type queryRegistry struct {
+	mtx     sync.Mutex
+	nextID  int64
+	running map[int64]*runningQuery
+}
+
+type runningQuery struct {
+	RunningQuery
+	cancel context.CancelFunc
+}
+
+type contextQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// context returns the context set by WithContext or the background
+// context
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
+}
+
+// track registers query as running and returns the context to run
+// it with and the func to call once it finished
+func (db *DB) track(query string) (context.Context, func()) {
+	parent := db.context()
+
+	reg := db.running
+	if reg == nil {
+		return parent, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+
+	reg.mtx.Lock()
+	reg.nextID++
+	id := reg.nextID
+	reg.running[id] = &runningQuery{
+		RunningQuery: RunningQuery{ID: id, SQL: strings.Join(strings.Fields(query), " "), Started: time.Now()},
+		cancel:       cancel,
+	}
+	reg.mtx.Unlock()
+
+	return ctx, func() {
+		reg.mtx.Lock()
+		delete(reg.running, id)
+		reg.mtx.Unlock()
+		cancel()
+	}
+}
+
+// queryContext runs the query using ctx, if supported by the wrapped
+// handle, see retryBusy
+func (db *DB) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.retryBusy(ctx, func() (err error) {
+		if cq, ok := db.DB.(contextQuerier); ok {
+			rows, err = cq.QueryContext(ctx, query, args...)
+			return err
+		}
+		rows, err = db.DB.Query(query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// execContext runs the statement using ctx, if supported by the
+// wrapped handle, see retryBusy
+func (db *DB) execContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.retryBusy(ctx, func() (err error) {
+		if cq, ok := db.DB.(contextQuerier); ok {
+			result, err = cq.ExecContext(ctx, query, args...)
+			return err
+		}
+		result, err = db.DB.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
+
+// RunningQueries returns the statements currently run by all copies
+// of db, the longest running first. Use this together with Cancel
+// to implement an admin endpoint for stuck queries.
+func (db *DB) RunningQueries() []RunningQuery {
+	reg := db.running
+	if reg == nil {
+		return nil
+	}
+
+	reg.mtx.Lock()
+	queries := make([]RunningQuery, 0, len(reg.running))
+	for _, rq := range reg.running {
+		queries = append(queries, rq.RunningQuery)
+	}
+	reg.mtx.Unlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].ID < queries[j].ID
+	})
+	return queries
+}
+
+// Cancel cancels the context of the running statement with the given
+// id, as returned by RunningQueries. The statement returns an error,
+// if the driver supports cancellation. Cancel returns false if the
+// statement is no longer running.
+func (db *DB) Cancel(id int64) bool {
+	reg := db.running
+	if reg == nil {
+		return false
+	}
+
+	reg.mtx.Lock()
+	rq, ok := reg.running[id]
+	reg.mtx.Unlock()
+
+	if !ok {
+		return false
+	}
+	rq.cancel()
+	return true
+}
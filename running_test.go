@@ -0,0 +1,46 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelQuery(t *testing.T) {
+	var count int64
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- db.Query(&count, `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000)
+			SELECT count(*) FROM c`)
+	}()
+
+	var running []RunningQuery
+	for i := 0; i < 100 && len(running) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		running = db.RunningQueries()
+	}
+	if len(running) != 1 {
+		t.Fatalf("Expected 1 running query, got: %v", running)
+	}
+	if !strings.HasPrefix(running[0].SQL, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE") {
+		t.Errorf("Unexpected SQL: %s", running[0].SQL)
+	}
+
+	if !db.Cancel(running[0].ID) {
+		t.Errorf("Expected Cancel to find the query")
+	}
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Errorf("Expected error for canceled query")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Query was not canceled")
+	}
+
+	if len(db.RunningQueries()) != 0 || db.Cancel(running[0].ID) {
+		t.Errorf("Expected no running queries")
+	}
+}
@@ -0,0 +1,400 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// MaskFunc returns the masked version of a value read from the
+// source database.
+type MaskFunc func(value interface{}) interface{}
+
+// SampleOptions configure CopySample.
+type SampleOptions struct {
+	// Tables to sample from. Tables referenced by foreign keys
+	// are copied as far as needed, even if they are not listed.
+	Tables []string
+	// Percent of rows to sample from each listed table, 0 < Percent <= 100.
+	Percent float64
+	// Mask maps "table.column" to a func which masks the value
+	// before it is written into the target database. Masks should
+	// not be used on key columns, as that breaks the references.
+	Mask map[string]MaskFunc
+}
+
+type foreignKey struct {
+	column    string
+	refTable  string
+	refColumn string
+}
+
+type sampleTable struct {
+	name string
+	cols []string
+	rows []map[string]interface{}
+	seen map[string]bool
+	fks  []foreignKey
+}
+
+// CopySample samples rows from the given tables and writes them
+// into target. For every sampled row the rows it references through
+// foreign keys are copied as well, so that the target database is
+// consistent. This is useful to build staging datasets from
+// production data.
+//
+// CopySample needs a wrapper initialized using "Open".
+func (db *DB) CopySample(target *DB, opts SampleOptions) error {
+	var (
+		err    error
+		tables map[string]*sampleTable
+		order  []string
+	)
+
+	if opts.Percent <= 0 || opts.Percent > 100 {
+		return fmt.Errorf("sqlpro.CopySample: Percent needs to be in (0, 100], got: %v", opts.Percent)
+	}
+
+	tables = make(map[string]*sampleTable, 0)
+
+	getTable := func(name string) (*sampleTable, error) {
+		st, ok := tables[name]
+		if ok {
+			return st, nil
+		}
+		fks, err := db.foreignKeys(name)
+		if err != nil {
+			return nil, err
+		}
+		st = &sampleTable{name: name, fks: fks, seen: make(map[string]bool, 0)}
+		tables[name] = st
+		order = append(order, name)
+		return st, nil
+	}
+
+	// queue of rows which need their references resolved
+	type pending struct {
+		table *sampleTable
+		rows  []map[string]interface{}
+	}
+	queue := make([]pending, 0)
+
+	for _, table := range opts.Tables {
+		var count int64
+
+		st, err := getTable(table)
+		if err != nil {
+			return err
+		}
+
+		err = db.Query(&count, "SELECT count(*) FROM "+db.EscTable(table))
+		if err != nil {
+			return err
+		}
+
+		limit := int64(math.Ceil(float64(count) * opts.Percent / 100))
+		if limit == 0 {
+			continue
+		}
+
+		query, err := db.sampleQuery(table, limit)
+		if err != nil {
+			return err
+		}
+		cols, rows, err := db.queryMaps(query.SQL, query.Args...)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, pending{table: st, rows: st.add(cols, rows)})
+	}
+
+	// follow foreign keys until all referenced rows are fetched
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		for _, fk := range p.table.fks {
+			values := make([]interface{}, 0)
+			for _, row := range p.rows {
+				if row[fk.column] != nil {
+					values = append(values, row[fk.column])
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			ref, err := getTable(fk.refTable)
+			if err != nil {
+				return err
+			}
+
+			for len(values) > 0 {
+				n := db.MaxPlaceholder
+				if n > len(values) {
+					n = len(values)
+				}
+				cols, rows, err := db.queryMaps("SELECT * FROM "+db.EscTable(fk.refTable)+" WHERE @ IN ?", fk.refColumn, values[:n])
+				if err != nil {
+					return err
+				}
+				values = values[n:]
+
+				added := ref.add(cols, rows)
+				if len(added) > 0 {
+					queue = append(queue, pending{table: ref, rows: added})
+				}
+			}
+		}
+	}
+
+	order, err = sortTablesByForeignKeys(order, tables)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		st := tables[name]
+		for _, row := range st.rows {
+			for _, col := range st.cols {
+				mask, ok := opts.Mask[name+"."+col]
+				if ok && row[col] != nil {
+					row[col] = mask(row[col])
+				}
+			}
+			err = target.insertRowMap(name, st.cols, row)
+			if err != nil {
+				return xerrors.Errorf("sqlpro.CopySample: Unable to write into %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sampleMinRows is the estimated table size from which on Sample
+// uses TABLESAMPLE on Postgres instead of sorting the whole table
+const sampleMinRows = 10000
+
+// Sample loads n random rows of table into target, which needs
+// to be a pointer to a slice. Use it for debugging or to pick
+// training data, it is not meant for statistically exact samples.
+//
+// err := db.Sample(&events, "events", 100)
+//
+// On Postgres, tables with an estimated size above 10000 rows are
+// pre-filtered using TABLESAMPLE BERNOULLI, so that only a small
+// part of the table needs to be sorted. In rare cases fewer than n
+// rows are returned for these tables.
+func (db *DB) Sample(target interface{}, table string, n int64) error {
+	query, err := db.sampleQuery(table, n)
+	if err != nil {
+		return err
+	}
+	return db.Query(target, query.SQL, query.Args...)
+}
+
+// sampleQuery returns the query selecting n random rows
+// of table, using the syntax of the db's driver
+func (db *DB) sampleQuery(table string, n int64) (SQLFragment, error) {
+	switch db.Driver {
+	case POSTGRES:
+		var estimate float64
+
+		err := db.Query(&estimate, "SELECT reltuples FROM pg_class WHERE oid = ?::regclass", db.EscTable(table))
+		if err != nil {
+			return SQLFragment{}, err
+		}
+		if estimate > sampleMinRows && float64(n) < estimate {
+			// sample twice as many rows as needed, so that
+			// the random spread hardly ever returns too few
+			percent := math.Min(100, float64(n)*200/estimate)
+			return Fragment(fmt.Sprintf("SELECT * FROM %s TABLESAMPLE BERNOULLI (%f) ORDER BY random() LIMIT ?", db.EscTable(table), percent), n), nil
+		}
+		return Fragment("SELECT * FROM "+db.EscTable(table)+" ORDER BY random() LIMIT ?", n), nil
+	case SQLITE3:
+		// sort only the rowids, not the whole rows
+		return Fragment("SELECT * FROM "+db.EscTable(table)+" WHERE rowid IN (SELECT rowid FROM "+db.EscTable(table)+" ORDER BY random() LIMIT ?)", n), nil
+	case MSSQL:
+		return Fragment("SELECT TOP (?) * FROM "+db.EscTable(table)+" ORDER BY NEWID()", n), nil
+	case ORACLE:
+		return Fragment("SELECT * FROM "+db.EscTable(table)+" ORDER BY dbms_random.value FETCH FIRST ? ROWS ONLY", n), nil
+	default:
+		return Fragment("SELECT * FROM "+db.EscTable(table)+" ORDER BY random() LIMIT ?", n), nil
+	}
+}
+
+// add adds the rows which have not been seen yet and returns them
+func (st *sampleTable) add(cols []string, rows []map[string]interface{}) []map[string]interface{} {
+	added := make([]map[string]interface{}, 0, len(rows))
+	st.cols = cols
+	for _, row := range rows {
+		key := make([]string, 0, len(cols))
+		for _, col := range cols {
+			key = append(key, fmt.Sprintf("%v", row[col]))
+		}
+		k := strings.Join(key, "\x00")
+		if st.seen[k] {
+			continue
+		}
+		st.seen[k] = true
+		st.rows = append(st.rows, row)
+		added = append(added, row)
+	}
+	return added
+}
+
+// sortTablesByForeignKeys returns the table names so that referenced
+// tables come before the tables referencing them. Self references
+// are ignored.
+func sortTablesByForeignKeys(names []string, tables map[string]*sampleTable) ([]string, error) {
+	var visit func(name string) error
+
+	ordered := make([]string, 0, len(names))
+	visiting := make(map[string]bool, 0)
+	visited := make(map[string]bool, 0)
+
+	visit = func(name string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("sqlpro.CopySample: Foreign key cycle at table %q.", name)
+		}
+		visiting[name] = true
+		for _, fk := range tables[name].fks {
+			_, ok := tables[fk.refTable]
+			if fk.refTable == name || !ok {
+				continue
+			}
+			err := visit(fk.refTable)
+			if err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		ordered = append(ordered, name)
+		return nil
+	}
+
+	for _, name := range names {
+		err := visit(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// foreignKeys returns the foreign keys of the given table
+func (db *DB) foreignKeys(table string) ([]foreignKey, error) {
+	var (
+		fks []foreignKey
+		err error
+	)
+
+	switch db.Driver {
+	case SQLITE3:
+		var rows []struct {
+			Table string `db:"table"`
+			From  string `db:"from"`
+			To    string `db:"to"`
+		}
+		err = db.Query(&rows, "SELECT * FROM pragma_foreign_key_list(?)", table)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			fks = append(fks, foreignKey{column: row.From, refTable: row.Table, refColumn: row.To})
+		}
+	case POSTGRES:
+		var rows []struct {
+			Column    string `db:"column_name"`
+			RefTable  string `db:"ref_table"`
+			RefColumn string `db:"ref_column"`
+		}
+		err = db.Query(&rows, `
+			SELECT kcu.column_name, ccu.table_name AS ref_table, ccu.column_name AS ref_column
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+				ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+			JOIN information_schema.constraint_column_usage ccu
+				ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_name = ?`, table)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			fks = append(fks, foreignKey{column: row.Column, refTable: row.RefTable, refColumn: row.RefColumn})
+		}
+	default:
+		return nil, fmt.Errorf("sqlpro: Reading foreign keys is not supported for driver '%s'.", db.Driver)
+	}
+
+	return fks, nil
+}
+
+// queryMaps runs the query and returns the column names and
+// all rows as maps from column name to value.
+func (db *DB) queryMaps(query string, args ...interface{}) ([]string, []map[string]interface{}, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	err = db.Query(&rows, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for idx := range values {
+			ptrs[idx] = &values[idx]
+		}
+		err = rows.Scan(ptrs...)
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for idx, col := range cols {
+			row[col] = values[idx]
+		}
+		result = append(result, row)
+	}
+
+	return cols, result, rows.Err()
+}
+
+// insertRowMap inserts one row given as map, using the given
+// column order.
+func (db *DB) insertRowMap(table string, cols []string, row map[string]interface{}) error {
+	escCols := make([]string, 0, len(cols))
+	vs := make([]string, 0, len(cols))
+	args := make([]interface{}, 0, len(cols))
+
+	for _, col := range cols {
+		escCols = append(escCols, db.Esc(col))
+		vs = append(vs, string(db.PlaceholderValue))
+		args = append(args, row[col])
+	}
+
+	_, err := db.exec(1, fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s)",
+		db.EscTable(table),
+		strings.Join(escCols, ","),
+		strings.Join(vs, ","),
+	), args...)
+	return err
+}
@@ -0,0 +1,111 @@
+package sqlpro
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCopySample(t *testing.T) {
+	var (
+		orgCount, userCount int64
+		emails              []string
+	)
+
+	defer os.Remove("./test_sample_src.db")
+	defer os.Remove("./test_sample_dst.db")
+
+	src, err := Open("sqlite3", "./test_sample_src.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := Open("sqlite3", "./test_sample_dst.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	for _, d := range []*DB{src, dst} {
+		err = d.Exec(`CREATE TABLE org(id INTEGER PRIMARY KEY, name TEXT)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.Exec(`CREATE TABLE user(id INTEGER PRIMARY KEY, org_id INTEGER REFERENCES org(id), email TEXT)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 1; i <= 10; i++ {
+		err = src.Exec("INSERT INTO org(id, name) VALUES (?, ?)", i, "org")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = src.Exec("INSERT INTO user(id, org_id, email) VALUES (?, ?, ?)", i, i, "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = src.CopySample(dst, SampleOptions{
+		Tables:  []string{"user"},
+		Percent: 50,
+		Mask: map[string]MaskFunc{
+			"user.email": func(v interface{}) interface{} { return "masked" },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dst.Query(&userCount, "SELECT count(*) FROM user")
+	if err != nil {
+		t.Error(err)
+	}
+	if userCount != 5 {
+		t.Errorf("Expected 5 sampled users, got: %d", userCount)
+	}
+
+	err = dst.Query(&orgCount, "SELECT count(*) FROM org WHERE id IN (SELECT org_id FROM user)")
+	if err != nil {
+		t.Error(err)
+	}
+	if orgCount != userCount {
+		t.Errorf("Expected referenced orgs to be copied, got: %d", orgCount)
+	}
+
+	err = dst.Query(&emails, "SELECT DISTINCT email FROM user")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(emails) != 1 || emails[0] != "masked" {
+		t.Errorf("Expected masked emails, got: %v", emails)
+	}
+}
+
+func TestSample(t *testing.T) {
+	createUUIDTable(t, "test_sample")
+
+	// sqliteDB samples using the rowid
+	for _, d := range []*DB{db, sqliteDB} {
+		var rows []testRowUUID
+
+		err := d.Sample(&rows, "test_sample", 2)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(rows) != 2 || rows[0].ID == rows[1].ID {
+			t.Errorf("Expected 2 distinct rows, got: %v", rows)
+		}
+
+		rows = nil
+		err = d.Sample(&rows, "test_sample", 10)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(rows) != 3 {
+			t.Errorf("Expected all 3 rows, got: %v", rows)
+		}
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"golang.org/x/xerrors"
 )
 
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
 type voidScan struct{}
 
 func (vs *voidScan) Scan(interface{}) error {
@@ -17,8 +20,9 @@ func (vs *voidScan) Scan(interface{}) error {
 	return nil
 }
 
-// scanRow scans one row into the given target
-func scanRow(target reflect.Value, rows *sql.Rows) error {
+// scanRow scans one row into the given target, passing all values
+// through opts.hook if set
+func scanRow(target reflect.Value, rows *sql.Rows, opts scanOptions) error {
 	var (
 		err             error
 		cols            []string
@@ -53,11 +57,13 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 		targetV = target
 	}
 
-	switch targetV.Kind() {
-	case reflect.Struct:
-		info = getStructInfo(reflect.ValueOf(targetV.Interface()).Type())
+	switch {
+	case reflect.PtrTo(targetV.Type()).Implements(scannerType):
+		// scan the first column using the type's Scan method
+	case targetV.Kind() == reflect.Struct:
+		info = structInfoWith(reflect.ValueOf(targetV.Interface()).Type(), opts.mapper)
 		isStruct = true
-	case reflect.Slice:
+	case targetV.Kind() == reflect.Slice:
 		isSlice = true
 
 		var isPointer bool
@@ -90,16 +96,32 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 
 		if isStruct {
 			finfo, ok := info[col]
+			if !ok && opts.foldCase {
+				finfo, ok = info[strings.ToLower(col)]
+			}
 			if !ok {
 				skip = true
 			} else {
-				fieldV = targetV.FieldByName(finfo.name)
+				fieldV = targetV.FieldByIndex(finfo.structField.Index)
 				if finfo.isJson {
 					// log.Printf("Setting field to json: %v idx: %d", finfo.name, idx)
 					data[idx] = &NullJson{}
 					nullValueByIdx[idx] = fieldV
 					continue
 				}
+				if finfo.isIntEnum() {
+					data[idx] = &enumScan{fi: finfo}
+					nullValueByIdx[idx] = fieldV
+					continue
+				}
+				if finfo.isHstore {
+					if fieldV.Type() != reflect.TypeOf(map[string]string{}) {
+						return fmt.Errorf("Unable to scan hstore into %s, need map[string]string.", fieldV.Type())
+					}
+					data[idx] = &nullHstore{}
+					nullValueByIdx[idx] = fieldV
+					continue
+				}
 			}
 		} else if isSlice {
 			fieldV = targetV.Index(idx)
@@ -125,6 +147,9 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 		case *json.RawMessage, json.RawMessage:
 			data[idx] = &NullRawMessage{}
 			nullValueByIdx[idx] = fieldV
+		case *[]byte:
+			data[idx] = &nullBytes{}
+			nullValueByIdx[idx] = fieldV
 		case *string, string:
 			data[idx] = &sql.NullString{}
 			nullValueByIdx[idx] = fieldV
@@ -141,7 +166,11 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 			data[idx] = &NullTime{}
 			nullValueByIdx[idx] = fieldV
 		default:
-			if fieldV.Kind() != reflect.Ptr {
+			if isByteArray(fieldV.Type()) && !reflect.PtrTo(fieldV.Type()).Implements(scannerType) {
+				// database/sql does not scan into arrays
+				data[idx] = &nullBytes{}
+				nullValueByIdx[idx] = fieldV
+			} else if fieldV.Kind() != reflect.Ptr {
 				// Pass a pointer
 				data[idx] = fieldV.Addr().Interface()
 			} else {
@@ -153,7 +182,19 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 		}
 	}
 
-	err = rows.Scan(data...)
+	if opts.hook != nil {
+		dest := make([]interface{}, len(data))
+		for idx, d := range data {
+			if _, ok := d.(*voidScan); ok {
+				dest[idx] = d
+				continue
+			}
+			dest[idx] = &hookScan{col: cols[idx], dest: d, hook: opts.hook}
+		}
+		err = rows.Scan(dest...)
+	} else {
+		err = rows.Scan(data...)
+	}
 	if err != nil {
 		return err
 	}
@@ -174,6 +215,28 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 				fieldV.Set(reflect.Zero(fieldV.Type()))
 			}
 			continue
+		case *nullHstore:
+			fieldV.Set(reflect.ValueOf(v.Map))
+			continue
+		case *enumScan:
+			err = v.setField(fieldV)
+			if err != nil {
+				return err
+			}
+			continue
+		case *nullBytes:
+			if fieldV.Kind() == reflect.Array {
+				if v.Valid && len(v.Data) != fieldV.Len() {
+					return fmt.Errorf("Unable to scan %d bytes into %s.", len(v.Data), fieldV.Type())
+				}
+				fieldV.Set(reflect.Zero(fieldV.Type()))
+				reflect.Copy(fieldV, reflect.ValueOf(v.Data))
+			} else if v.Valid {
+				fieldV.Set(reflect.ValueOf(&v.Data))
+			} else {
+				fieldV.Set(reflect.Zero(fieldV.Type()))
+			}
+			continue
 		case *NullRawMessage:
 
 			if (*v).Valid {
@@ -289,7 +352,35 @@ func scanRow(target reflect.Value, rows *sql.Rows) error {
 // exported fields only. Use "-" as mapping name to ignore the field.
 //
 func Scan(target interface{}, rows *sql.Rows) error {
+	return scanWith(target, rows, scanOptions{})
+}
+
+// scanOptions are the options of Query for scanWith
+type scanOptions struct {
+	// if maxRows > 0 and more than maxRows rows are scanned into
+	// a slice, ErrMaxRowsExceeded is returned
+	maxRows int
+	// with truncate set, the rows after maxRows are ignored instead
+	truncate bool
+	// if set, it is set to whether rows were ignored by truncate
+	truncated *bool
+	// if set, the scanned rows and their approximate size are added
+	stats *QueryStats
+	// if set, scanned times are converted into location
+	location *time.Location
+	// if set, all scanned values are passed through hook
+	hook ScanHookFunc
+	// if set, untagged fields are mapped to columns using mapper
+	mapper func(string) string
+	// if set, columns not found in a struct are looked up in lower
+	// case, for Oracle's upper case column names
+	foldCase bool
+}
+
+// scanWith works like Scan, using the given options
+func scanWith(target interface{}, rows *sql.Rows, opts scanOptions) error {
 	var (
+		n           int
 		targetValue reflect.Value
 		rowMode     bool
 		err         error
@@ -305,30 +396,48 @@ func Scan(target interface{}, rows *sql.Rows) error {
 		panic("Scan: Unable to use unadressable field as target.")
 	}
 
-	if targetValue.Type().Kind() != reflect.Slice {
+	if targetValue.Type().Kind() != reflect.Slice || reflect.PtrTo(targetValue.Type()).Implements(scannerType) {
 		rowMode = true
 	}
 
+	if opts.truncated != nil {
+		*opts.truncated = false
+	}
+
 	for rows.Next() {
 		if rowMode {
-			err = scanRow(targetValue, rows)
+			err = scanRow(targetValue, rows, opts)
 			if err != nil {
 				return err
 			}
+			setLocation(targetValue, opts.location)
+			opts.stats.add(targetValue)
 			// Only one row in row mode
 			return nil
 		}
 
 		// slice mode
+		if opts.maxRows > 0 && n == opts.maxRows {
+			if opts.truncate {
+				if opts.truncated != nil {
+					*opts.truncated = true
+				}
+				return nil
+			}
+			return ErrMaxRowsExceeded
+		}
+		n++
 
 		// create an item suitable for appending to the slice
 		rowValues := reflect.MakeSlice(targetValue.Type(), 1, 1)
 		rowValue := rowValues.Index(0)
 
-		err = scanRow(rowValue, rows)
+		err = scanRow(rowValue, rows, opts)
 		if err != nil {
 			return err
 		}
+		setLocation(rowValue, opts.location)
+		opts.stats.add(rowValue)
 
 		targetValue.Set(reflect.Append(targetValue, rowValue))
 	}
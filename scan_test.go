@@ -0,0 +1,219 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaxRows(t *testing.T) {
+	var (
+		ids []string
+		id  string
+	)
+
+	createUUIDTable(t, "test_max_rows")
+
+	err := db.WithMaxRows(2).Query(&ids, "SELECT id FROM test_max_rows")
+	if err != ErrMaxRowsExceeded {
+		t.Errorf("Expected ErrMaxRowsExceeded, got: %v", err)
+	}
+
+	db2 := db.WithMaxRows(2)
+	db2.TruncateMaxRows = true
+	ids = nil
+	err = db2.Query(&ids, "SELECT id FROM test_max_rows")
+	if err != nil || len(ids) != 2 {
+		t.Errorf("Expected 2 truncated rows, got: %v %v", ids, err)
+	}
+
+	truncated := false
+	ids = nil
+	err = db.WithMaxRows(2).WithTruncation(&truncated).Query(&ids, "SELECT id FROM test_max_rows")
+	if err != nil || len(ids) != 2 || !truncated {
+		t.Errorf("Expected 2 truncated rows reported, got: %v %v %v", ids, truncated, err)
+	}
+	ids = nil
+	err = db.WithMaxRows(10).WithTruncation(&truncated).Query(&ids, "SELECT id FROM test_max_rows")
+	if err != nil || len(ids) != 3 || truncated {
+		t.Errorf("Expected complete result, got: %v %v %v", ids, truncated, err)
+	}
+
+	// single row targets are not limited
+	err = db.WithMaxRows(1).Query(&id, "SELECT id FROM test_max_rows")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// testCSV is a slice stored as comma separated text
+type testCSV []string
+
+func (c testCSV) Value() (driver.Value, error) {
+	return strings.Join(c, ","), nil
+}
+
+func (c *testCSV) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*c = strings.Split(v, ",")
+	case []byte:
+		*c = strings.Split(string(v), ",")
+	default:
+		return fmt.Errorf("Unable to scan %T into testCSV", value)
+	}
+	return nil
+}
+
+// testMoney is a struct stored as integer cents
+type testMoney struct {
+	Cents int64
+}
+
+func (m testMoney) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+func (m *testMoney) Scan(value interface{}) error {
+	cents, ok := value.(int64)
+	if !ok {
+		return fmt.Errorf("Unable to scan %T into testMoney", value)
+	}
+	m.Cents = cents
+	return nil
+}
+
+type testRowValuer struct {
+	ID    int64     `db:"id,pk,omitempty"`
+	Tags  testCSV   `db:"tags"`
+	Price testMoney `db:"price"`
+}
+
+func TestValuerScanner(t *testing.T) {
+	var (
+		row    testRowValuer
+		prices []testMoney
+	)
+
+	err := db.Exec("CREATE TABLE test_valuer(id INTEGER PRIMARY KEY AUTOINCREMENT, tags TEXT, price INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_valuer", []testRowValuer{
+		{Tags: testCSV{"a", "b"}, Price: testMoney{150}},
+		{Tags: testCSV{"c"}, Price: testMoney{99}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a Valuer slice is one value, not a list
+	err = db.Query(&row, "SELECT * FROM test_valuer WHERE tags = ?", testCSV{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row.Tags) != 2 || row.Tags[1] != "b" || row.Price.Cents != 150 {
+		t.Errorf("Unexpected row: %v", row)
+	}
+
+	err = db.Query(&prices, "SELECT price FROM test_valuer WHERE price IN ? ORDER BY price", []testMoney{{99}, {150}, {1}})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(prices) != 2 || prices[0].Cents != 99 {
+		t.Errorf("Unexpected prices: %v", prices)
+	}
+
+	var tags testCSV
+	err = db.Query(&tags, "SELECT tags FROM test_valuer WHERE price = ?", testMoney{150})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tags) != 2 || tags[0] != "a" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
+
+type testRowJsonTypes struct {
+	ID    int64                  `db:"id,pk,omitempty"`
+	Meta  map[string]interface{} `db:"meta,json"`
+	Tags  []string               `db:"tags,json"`
+	Inner *myStruct              `db:"inner,json"`
+}
+
+func TestJsonTag(t *testing.T) {
+	var rows []testRowJsonTypes
+
+	err := db.Exec("CREATE TABLE test_json(id INTEGER PRIMARY KEY AUTOINCREMENT, meta TEXT, tags TEXT, inner TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_json", []testRowJsonTypes{
+		{Meta: map[string]interface{}{"n": 1.5, "s": "x"}, Tags: []string{"a", "b"}, Inner: &myStruct{A: "inner"}},
+		{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_json ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %v", rows)
+	}
+	if rows[0].Meta["n"] != 1.5 || rows[0].Meta["s"] != "x" || len(rows[0].Tags) != 2 || rows[0].Inner.A != "inner" {
+		t.Errorf("Unexpected first row: %v", rows[0])
+	}
+	if rows[1].Meta != nil || rows[1].Tags != nil || rows[1].Inner != nil {
+		t.Errorf("Expected empty second row: %v", rows[1])
+	}
+}
+
+type testRowBlob struct {
+	ID    int64   `db:"id,pk,omitempty"`
+	Data  []byte  `db:"data,omitempty"`
+	DataP *[]byte `db:"data_p"`
+}
+
+func TestBlob(t *testing.T) {
+	var rows []testRowBlob
+
+	err := db.Exec("CREATE TABLE test_blob(id INTEGER PRIMARY KEY AUTOINCREMENT, data BLOB DEFAULT X'FF', data_p BLOB)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binary := []byte{0, 1, '\'', '"', 0xfe, 0}
+	err = db.InsertBulk("test_blob", []testRowBlob{{Data: binary, DataP: &binary}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Insert("test_blob", &testRowBlob{Data: []byte{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_blob ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got: %v", rows)
+	}
+	if string(rows[0].Data) != string(binary) || rows[0].DataP == nil || string(*rows[0].DataP) != string(binary) {
+		t.Errorf("Unexpected binary data: %v", rows[0])
+	}
+	// empty slice is omitted, the default is used
+	if len(rows[1].Data) != 1 || rows[1].Data[0] != 0xff || rows[1].DataP != nil {
+		t.Errorf("Unexpected empty binary data: %v", rows[1])
+	}
+
+	if lit := db.EscValueForInsert(binary, nil); lit != "X'00012722fe00'" {
+		t.Errorf("Unexpected binary literal: %s", lit)
+	}
+}
@@ -0,0 +1,67 @@
+package sqlpro
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// ScanHookFunc transforms the raw value the driver returned for col
+// before it is scanned into the target.
+type ScanHookFunc func(col string, raw interface{}) (interface{}, error)
+
+// ScanHook returns a copy which passes every scanned column through
+// hook. Use this to normalize values centrally, e.g. for drivers which
+// return []byte for all columns:
+//
+//	hook := func(col string, raw interface{}) (interface{}, error) {
+//		if b, ok := raw.([]byte); ok && col != "payload" {
+//			return string(b), nil
+//		}
+//		return raw, nil
+//	}
+//	err := db.ScanHook(hook).Query(&rows, "SELECT * FROM item")
+//
+// The hook is not used for a **sql.Rows target.
+func (db *DB) ScanHook(hook ScanHookFunc) *DB {
+	newDB := *db
+	newDB.scanHook = hook
+	return &newDB
+}
+
+// hookScan passes the value through hook before scanning it into dest
+type hookScan struct {
+	col  string
+	dest interface{}
+	hook ScanHookFunc
+}
+
+func (hs *hookScan) Scan(src interface{}) error {
+	v, err := hs.hook(hs.col, src)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.ScanHook: Column %q: %w", hs.col, err)
+	}
+
+	if scanner, ok := hs.dest.(sql.Scanner); ok {
+		return scanner.Scan(v)
+	}
+
+	destV := reflect.ValueOf(hs.dest).Elem()
+	if v == nil {
+		destV.Set(reflect.Zero(destV.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Type().AssignableTo(destV.Type()):
+		destV.Set(rv)
+	case rv.Type().ConvertibleTo(destV.Type()):
+		destV.Set(rv.Convert(destV.Type()))
+	default:
+		return fmt.Errorf("sqlpro.ScanHook: Unable to scan %T into %s for column %q.", v, destV.Type(), hs.col)
+	}
+	return nil
+}
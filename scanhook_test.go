@@ -0,0 +1,71 @@
+package sqlpro
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScanHook(t *testing.T) {
+	type testRowHook struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+		Note string `db:"note"`
+	}
+
+	var cols []string
+	hook := func(col string, raw interface{}) (interface{}, error) {
+		cols = append(cols, col)
+		if s, ok := raw.(string); ok && col == "name" {
+			return []byte(strings.ToUpper(s)), nil
+		}
+		return raw, nil
+	}
+
+	err := db.Exec("CREATE TABLE test_scanhook(id INTEGER PRIMARY KEY, name TEXT, note TEXT, email TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec("INSERT INTO test_scanhook(name, note, email) VALUES (?, ?, ?), (?, ?, ?)", "henk", "note", "henk@example.com", "tracked", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var row testRowHook
+	err = db.ScanHook(hook).Query(&row, "SELECT id, name, note, email FROM test_scanhook ORDER BY id LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Name != strings.ToUpper(row.Name) || row.Name == "" {
+		t.Errorf("Expected hook to upper case name, got: %q", row.Name)
+	}
+	if len(cols) != 3 {
+		t.Errorf("Expected hook to be called for the 3 mapped columns, got: %v", cols)
+	}
+
+	var names []string
+	err = db.ScanHook(hook).Query(&names, "SELECT name FROM test_scanhook ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 || names[0] != row.Name {
+		t.Errorf("Expected hooked names, got: %v", names)
+	}
+
+	failing := func(col string, raw interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("broken")
+	}
+	err = db.ScanHook(failing).Query(&row, "SELECT id, name FROM test_scanhook LIMIT 1")
+	if err == nil {
+		t.Errorf("Expected error from the hook")
+	}
+
+	// the hook is per call
+	err = db.Query(&row, "SELECT id, name FROM test_scanhook WHERE name = 'tracked' LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Name != "tracked" {
+		t.Errorf("Expected name without hook, got: %q", row.Name)
+	}
+}
@@ -0,0 +1,52 @@
+package sqlpro
+
+import (
+	"database/sql/driver"
+)
+
+// Secret wraps a query argument which must not show up in debug
+// output, logs and errors, where it is printed as "***". The value
+// is passed to the driver as is.
+//
+//	err := db.Exec("UPDATE user SET password = ? WHERE id = ?", sqlpro.Secret{Arg: hash}, id)
+//
+// Values of fields tagged "secret" are wrapped automatically:
+//
+//	Password string `db:"password,secret"`
+type Secret struct {
+	Arg interface{}
+}
+
+// Value implements driver.Valuer.
+func (s Secret) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(s.Arg)
+}
+
+// String implements fmt.Stringer, so that formatting never prints
+// the value.
+func (s Secret) String() string {
+	return "***"
+}
+
+// argValue returns the argument to pass for value of the field fi,
+// see nullValue. Values of "secret" fields are wrapped in Secret.
+func (db *DB) argValue(value interface{}, fi *fieldInfo) interface{} {
+	v := db.nullValue(value, fi)
+	if v != nil && fi != nil && fi.secret {
+		return Secret{Arg: v}
+	}
+	return v
+}
+
+// debugArgs returns the args to print in debug output and errors,
+// with all args redacted if RedactArgs is set
+func (db *DB) debugArgs(args []interface{}) []interface{} {
+	if !db.RedactArgs {
+		return args
+	}
+	redacted := make([]interface{}, len(args))
+	for idx, arg := range args {
+		redacted[idx] = Secret{Arg: arg}
+	}
+	return redacted
+}
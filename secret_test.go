@@ -0,0 +1,61 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+)
+
+type testRowSecret struct {
+	ID       int64  `db:"id,pk,omitempty"`
+	Name     string `db:"name"`
+	Password string `db:"password,secret"`
+}
+
+func TestSecret(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_secret(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, password TEXT NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowSecret{Name: "henk", Password: "hunter2"}
+	err = db.Insert("test_secret", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored string
+	err = db.Query(&stored, "SELECT password FROM test_secret WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != "hunter2" {
+		t.Errorf("Expected secret to be stored as is, got: %q", stored)
+	}
+
+	// insert the same id again to get an error with args
+	row.Password = "s3cret"
+	err = db.Insert("test_secret", &row)
+	if err == nil {
+		t.Fatal("Expected insert to fail")
+	}
+	if strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), "***") {
+		t.Errorf("Expected redacted secret in error, got: %s", err)
+	}
+	if !strings.Contains(err.Error(), "henk") {
+		t.Errorf("Expected other args in error, got: %s", err)
+	}
+
+	redact := *db
+	redact.RedactArgs = true
+	err = redact.Exec("INSERT INTO test_secret (id, name, password) VALUES (?, ?, ?)", row.ID, "visible", Secret{Arg: "x"})
+	if err == nil {
+		t.Fatal("Expected insert to fail")
+	}
+	if strings.Contains(err.Error(), "visible") {
+		t.Errorf("Expected all args redacted, got: %s", err)
+	}
+
+	if s := argsToString(Secret{Arg: "x"}, "y"); strings.Contains(s, "x") || !strings.Contains(s, "y") {
+		t.Errorf("Unexpected args string: %s", s)
+	}
+}
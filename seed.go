@@ -0,0 +1,165 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// SeedTable is the table used to record which seeds have
+// already been applied.
+const SeedTable = "sqlpro_seeds"
+
+// Seeder is a named unit of data which is written into the
+// database exactly once. A Seeder can depend on other seeders
+// by name, these are run before the seeder itself.
+type Seeder struct {
+	Name      string
+	DependsOn []string
+	Run       func(ctx context.Context, tx *Tx) error
+}
+
+type seedRecord struct {
+	Name      string    `db:"name,pk"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Seed runs the given seeders ordered by their dependencies. Each
+// seeder runs in its own transaction, together with the record
+// in SeedTable. Seeders which have already been applied are skipped,
+// so Seed can safely be called on every start of an environment.
+//
+// Seed needs a wrapper initialized using "Open".
+func (db *DB) Seed(ctx context.Context, seeds ...*Seeder) error {
+	var (
+		applied []string
+		err     error
+	)
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS @ (name TEXT PRIMARY KEY, applied_at TIMESTAMP)`, SeedTable)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.Seed: Unable to create seed table: %w", err)
+	}
+
+	err = db.Query(&applied, `SELECT name FROM @`, SeedTable)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.Seed: Unable to read applied seeds: %w", err)
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[name] = true
+	}
+
+	ordered, err := sortSeeds(seeds, done)
+	if err != nil {
+		return err
+	}
+
+	for _, seed := range ordered {
+		if done[seed.Name] {
+			continue
+		}
+
+		err = ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		err = db.runSeed(ctx, seed)
+		if err != nil {
+			return xerrors.Errorf("sqlpro.Seed: Seed %q failed: %w", seed.Name, err)
+		}
+		done[seed.Name] = true
+	}
+
+	return nil
+}
+
+func (db *DB) runSeed(ctx context.Context, seed *Seeder) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = seed.Run(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Insert(SeedTable, &seedRecord{Name: seed.Name, AppliedAt: time.Now()})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sortSeeds returns the seeds in dependency order. The order of the
+// given seeds is kept where the dependencies allow it. Dependencies
+// need to be part of seeds or already applied.
+func sortSeeds(seeds []*Seeder, applied map[string]bool) ([]*Seeder, error) {
+	var (
+		visit func(seed *Seeder) error
+	)
+
+	byName := make(map[string]*Seeder, len(seeds))
+	for _, seed := range seeds {
+		if seed.Name == "" {
+			return nil, fmt.Errorf("sqlpro.Seed: Seeder needs a name.")
+		}
+		if seed.Run == nil {
+			return nil, fmt.Errorf("sqlpro.Seed: Seeder %q has no Run func.", seed.Name)
+		}
+		if _, ok := byName[seed.Name]; ok {
+			return nil, fmt.Errorf("sqlpro.Seed: Seeder %q given twice.", seed.Name)
+		}
+		byName[seed.Name] = seed
+	}
+
+	ordered := make([]*Seeder, 0, len(seeds))
+	visiting := make(map[string]bool, 0)
+	visited := make(map[string]bool, 0)
+
+	visit = func(seed *Seeder) error {
+		if visited[seed.Name] {
+			return nil
+		}
+		if visiting[seed.Name] {
+			return fmt.Errorf("sqlpro.Seed: Dependency cycle at seed %q.", seed.Name)
+		}
+		visiting[seed.Name] = true
+
+		for _, depName := range seed.DependsOn {
+			dep, ok := byName[depName]
+			if !ok {
+				if applied[depName] {
+					continue
+				}
+				return fmt.Errorf("sqlpro.Seed: Seed %q depends on unknown seed %q.", seed.Name, depName)
+			}
+			err := visit(dep)
+			if err != nil {
+				return err
+			}
+		}
+
+		visiting[seed.Name] = false
+		visited[seed.Name] = true
+		ordered = append(ordered, seed)
+		return nil
+	}
+
+	for _, seed := range seeds {
+		err := visit(seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
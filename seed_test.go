@@ -0,0 +1,76 @@
+package sqlpro
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	var (
+		order []string
+		names []string
+	)
+
+	defer os.Remove("./test_seed.db")
+
+	sdb, err := Open("sqlite3", "./test_seed.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdb.Close()
+
+	err = sdb.Exec("CREATE TABLE seed_test(name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := func(name string, deps ...string) *Seeder {
+		return &Seeder{
+			Name:      name,
+			DependsOn: deps,
+			Run: func(ctx context.Context, tx *Tx) error {
+				order = append(order, name)
+				return tx.Exec("INSERT INTO seed_test(name) VALUES (?)", name)
+			},
+		}
+	}
+
+	err = sdb.Seed(context.Background(), seed("c", "b"), seed("a"), seed("b", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Errorf("Expected seeds in order a, b, c, got: %v", order)
+	}
+
+	// Applied seeds must not run again
+	order = nil
+	err = sdb.Seed(context.Background(), seed("d", "c"), seed("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 1 || order[0] != "d" {
+		t.Errorf("Expected only seed d to run, got: %v", order)
+	}
+
+	err = sdb.Query(&names, "SELECT name FROM seed_test ORDER BY name")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(names) != 4 {
+		t.Errorf("Expected 4 seeded rows, got: %v", names)
+	}
+
+	err = sdb.Seed(context.Background(), seed("x", "y"), seed("y", "x"))
+	if err == nil {
+		t.Errorf("Expected error for dependency cycle.")
+	}
+
+	err = sdb.Seed(context.Background(), seed("z", "unknown"))
+	if err == nil {
+		t.Errorf("Expected error for unknown dependency.")
+	}
+}
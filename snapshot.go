@@ -0,0 +1,73 @@
+package sqlpro
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+)
+
+// Snapshot holds the rows of tables, taken by db.Snapshot.
+type Snapshot struct {
+	tables []snapshotTable
+}
+
+type snapshotTable struct {
+	name string
+	rows []map[string]interface{}
+}
+
+// Snapshot reads all rows of the given tables into memory. Use
+// Restore to reset the tables to the snapshot, e.g. between test
+// cases which share expensive fixtures.
+//
+//	snap, err := db.Snapshot("org", "user")
+//	...
+//	defer db.Restore(snap)
+//
+// Tables need to be listed with referenced tables first.
+func (db *DB) Snapshot(tables ...string) (*Snapshot, error) {
+	snap := &Snapshot{tables: make([]snapshotTable, 0, len(tables))}
+	for _, table := range tables {
+		_, rows, err := db.queryMaps("SELECT * FROM " + db.EscTable(table))
+		if err != nil {
+			return nil, xerrors.Errorf("sqlpro.Snapshot: Unable to read %s: %w", table, err)
+		}
+		snap.tables = append(snap.tables, snapshotTable{name: table, rows: rows})
+	}
+	return snap, nil
+}
+
+// Restore deletes all rows of the tables of snap, in reverse order,
+// and inserts the rows of the snapshot, using as few INSERT statements
+// as possible. If not already in a transaction and the wrapper was
+// initialized using "Open", this is done in one transaction.
+func (db *DB) Restore(snap *Snapshot) error {
+	restore := func(db *DB) error {
+		for i := len(snap.tables) - 1; i >= 0; i-- {
+			err := db.Exec("DELETE FROM " + db.EscTable(snap.tables[i].name))
+			if err != nil {
+				return err
+			}
+		}
+		for _, st := range snap.tables {
+			err := db.InsertMaps(st.name, st.rows)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if db.sqlTx == nil && db.sqlDB != nil {
+		err = db.RunTx(context.Background(), func(tx *Tx) error {
+			return restore(tx.DB)
+		})
+	} else {
+		err = restore(db)
+	}
+	if err != nil {
+		return xerrors.Errorf("sqlpro.Restore: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package sqlpro
+
+import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// Unscoped returns a copy which ignores "softdelete" fields: Get,
+// First, Last, GetOrCreate and FindInBatches return soft deleted rows
+// and Delete and DeleteByIDs remove the rows.
+func (db *DB) Unscoped() *DB {
+	newDB := *db
+	newDB.unscoped = true
+	return &newDB
+}
+
+// softDeleteField returns the field tagged "softdelete" or nil
+func (si structInfo) softDeleteField() *fieldInfo {
+	for _, fi := range si {
+		if fi.softDelete {
+			return fi
+		}
+	}
+	return nil
+}
+
+// softDelete returns the field of t tagged "softdelete" for table,
+// nil if t has none or db is unscoped
+func (db *DB) softDelete(t reflect.Type, table string) *fieldInfo {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if db.unscoped || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return db.tableStructInfo(t, table).softDeleteField()
+}
+
+// scopeCondition adds the check for rows which are not soft deleted
+// to condition, if t has a "softdelete" field
+func (db *DB) scopeCondition(t reflect.Type, table string, condition string) string {
+	fi := db.softDelete(t, table)
+	if fi == nil {
+		return condition
+	}
+	if condition == "" {
+		return db.Esc(fi.dbName) + " IS NULL"
+	}
+	return "(" + condition + ") AND " + db.Esc(fi.dbName) + " IS NULL"
+}
+
+// softDeleteRow sets the "softdelete" column of row to the current
+// time. If row is addressable, its field is set as well.
+func (db *DB) softDeleteRow(table string, row reflect.Value, fi *fieldInfo) error {
+	values, info, err := db.tableValuesFromStruct(table, row.Interface())
+	if err != nil {
+		return err
+	}
+
+	where, args, err := db.pkWhere(values, info)
+	if err != nil {
+		return xerrors.Errorf("Unable to build UPDATE clause: %w", err)
+	}
+
+	err = db.checkMutation("Delete")
+	if err != nil {
+		return err
+	}
+
+	now := db.now()
+	_, err = db.exec(1, db.updatePrefix(table)+db.Esc(fi.dbName)+" = ?"+where,
+		append([]interface{}{db.storeTime(now)}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if row.CanAddr() {
+		setTimeField(row.FieldByIndex(fi.structField.Index), now)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package sqlpro
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRowSoftDelete struct {
+	ID        int64      `db:"id,pk,omitempty"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_soft_delete(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, deleted_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []*testRowSoftDelete{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+	err = db.Insert("test_soft_delete", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Delete("test_soft_delete", rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].DeletedAt == nil {
+		t.Errorf("Expected DeletedAt to be set")
+	}
+
+	// the deletion time is taken in TimeLocation, like for Delete
+	ldb := *db
+	ldb.TimeLocation = time.FixedZone("test", 5*3600)
+	n, err := ldb.DeleteByIDs("test_soft_delete", testRowSoftDelete{}, []int64{rows[0].ID, rows[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 row soft deleted by id, got: %d", n)
+	}
+	var deletedAt string
+	err = db.Query(&deletedAt, "SELECT deleted_at FROM test_soft_delete WHERE id = ?", rows[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(deletedAt, "+05:00") {
+		t.Errorf("Expected deletion time in TimeLocation, got: %s", deletedAt)
+	}
+
+	var count int64
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_soft_delete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("Expected rows to be kept, got: %d", count)
+	}
+
+	var found []testRowSoftDelete
+	err = db.Get(&found, "test_soft_delete", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Errorf("Expected 2 rows not deleted, got: %d", len(found))
+	}
+
+	var row testRowSoftDelete
+	err = db.First(&row, "test_soft_delete", "name = ? OR name = ?", "a", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Name != "c" {
+		t.Errorf("Expected first not deleted row, got: %s", row.Name)
+	}
+
+	var all []testRowSoftDelete
+	err = db.Unscoped().Get(&all, "test_soft_delete", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Expected all rows unscoped, got: %d", len(all))
+	}
+
+	err = db.Unscoped().Delete("test_soft_delete", rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_soft_delete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("Expected row to be removed unscoped, got: %d", count)
+	}
+}
@@ -0,0 +1,262 @@
+package sqlpro
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// type tags of the spill codec
+const (
+	spillNil byte = iota
+	spillInt64
+	spillFloat64
+	spillBool
+	spillBytes
+	spillString
+	spillTime
+)
+
+// SpilledResult is a query result stored in a temporary file by
+// Spill. It can be read any number of times, starting at any row.
+// Close removes the file.
+type SpilledResult struct {
+	path    string
+	columns []string
+	offsets []int64 // file offset of each row
+}
+
+// Spill runs query and writes all rows into a temporary file,
+// using a compact binary encoding. Use this for large read-only
+// results which need to be streamed more than once, e.g. exports
+// which are retried from a given row. The caller needs to Close
+// the result.
+//
+//	res, err := db.Spill("SELECT * FROM orders WHERE year = ?", 2020)
+//	defer res.Close()
+//	err = res.Each(0, func(idx int, row []interface{}) error { ... })
+//
+// The rows hold the values as returned by the driver: nil, int64,
+// float64, bool, []byte, string or time.Time.
+func (db *DB) Spill(query string, args ...interface{}) (*SpilledResult, error) {
+	var rows *sql.Rows
+
+	err := db.Query(&rows, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile("", "sqlpro-spill-*")
+	if err != nil {
+		return nil, xerrors.Errorf("sqlpro.Spill: %w", err)
+	}
+
+	sr := &SpilledResult{path: f.Name(), columns: cols, offsets: make([]int64, 0)}
+
+	err = sr.write(f, rows)
+	if err == nil {
+		err = rows.Err()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(sr.path)
+		return nil, xerrors.Errorf("sqlpro.Spill: %w", err)
+	}
+
+	return sr, nil
+}
+
+// write encodes all rows into f
+func (sr *SpilledResult) write(f *os.File, rows *sql.Rows) error {
+	w := bufio.NewWriter(f)
+
+	values := make([]interface{}, len(sr.columns))
+	dest := make([]interface{}, len(sr.columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var (
+		offset int64
+		buf    []byte
+	)
+	for rows.Next() {
+		err := rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		buf = buf[:0]
+		for idx, v := range values {
+			buf, err = appendSpillValue(buf, v)
+			if err != nil {
+				return fmt.Errorf("Column %q: %s", sr.columns[idx], err)
+			}
+		}
+
+		_, err = w.Write(buf)
+		if err != nil {
+			return err
+		}
+		sr.offsets = append(sr.offsets, offset)
+		offset += int64(len(buf))
+	}
+
+	return w.Flush()
+}
+
+// Columns returns the column names of the result.
+func (sr *SpilledResult) Columns() []string {
+	return sr.columns
+}
+
+// Len returns the number of rows of the result.
+func (sr *SpilledResult) Len() int {
+	return len(sr.offsets)
+}
+
+// Each calls fn for each row, starting at row from. fn receives the
+// index of the row and its values. The values slice is reused for
+// the next row. Each stops and returns the error if fn returns an
+// error.
+func (sr *SpilledResult) Each(from int, fn func(idx int, row []interface{}) error) error {
+	if from < 0 || from > len(sr.offsets) {
+		return fmt.Errorf("sqlpro.SpilledResult.Each: Row %d out of range, have %d rows.", from, len(sr.offsets))
+	}
+	if from == len(sr.offsets) {
+		return nil
+	}
+
+	f, err := os.Open(sr.path)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.SpilledResult.Each: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Seek(sr.offsets[from], io.SeekStart)
+	if err != nil {
+		return xerrors.Errorf("sqlpro.SpilledResult.Each: %w", err)
+	}
+
+	r := bufio.NewReader(f)
+	row := make([]interface{}, len(sr.columns))
+	for idx := from; idx < len(sr.offsets); idx++ {
+		for i := range row {
+			row[i], err = readSpillValue(r)
+			if err != nil {
+				return xerrors.Errorf("sqlpro.SpilledResult.Each: Row %d: %w", idx, err)
+			}
+		}
+		err = fn(idx, row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close removes the temporary file of the result.
+func (sr *SpilledResult) Close() error {
+	return os.Remove(sr.path)
+}
+
+// appendSpillValue appends the encoding of v to buf
+func appendSpillValue(buf []byte, v interface{}) ([]byte, error) {
+	var tmp [binary.MaxVarintLen64]byte
+
+	switch v := v.(type) {
+	case nil:
+		return append(buf, spillNil), nil
+	case int64:
+		n := binary.PutVarint(tmp[:], v)
+		return append(append(buf, spillInt64), tmp[:n]...), nil
+	case float64:
+		binary.BigEndian.PutUint64(tmp[:8], math.Float64bits(v))
+		return append(append(buf, spillFloat64), tmp[:8]...), nil
+	case bool:
+		if v {
+			return append(buf, spillBool, 1), nil
+		}
+		return append(buf, spillBool, 0), nil
+	case []byte:
+		n := binary.PutUvarint(tmp[:], uint64(len(v)))
+		return append(append(append(buf, spillBytes), tmp[:n]...), v...), nil
+	case string:
+		n := binary.PutUvarint(tmp[:], uint64(len(v)))
+		return append(append(append(buf, spillString), tmp[:n]...), v...), nil
+	case time.Time:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		n := binary.PutUvarint(tmp[:], uint64(len(data)))
+		return append(append(append(buf, spillTime), tmp[:n]...), data...), nil
+	default:
+		return nil, fmt.Errorf("Unable to spill value of type %T.", v)
+	}
+}
+
+// readSpillValue reads one value written by appendSpillValue
+func readSpillValue(r *bufio.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case spillNil:
+		return nil, nil
+	case spillInt64:
+		return binary.ReadVarint(r)
+	case spillFloat64:
+		var b [8]byte
+		_, err = io.ReadFull(r, b[:])
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b[:])), nil
+	case spillBool:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return b == 1, nil
+	case spillBytes, spillString, spillTime:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, n)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case spillString:
+			return string(data), nil
+		case spillTime:
+			var t time.Time
+			err = t.UnmarshalBinary(data)
+			return t, err
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("Unknown spill tag %d.", tag)
+	}
+}
@@ -0,0 +1,81 @@
+package sqlpro
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSpill(t *testing.T) {
+	var count int64
+
+	err := db.Exec("CREATE TABLE test_spill(id INTEGER PRIMARY KEY, name TEXT, created_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		err = db.Exec("INSERT INTO test_spill(name, created_at) VALUES (?, ?)", name, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := db.Spill("SELECT id, name, created_at, NULL AS empty, 1.5 AS f, X'0102' AS b FROM test_spill ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Len() != int(count) || len(res.Columns()) != 6 {
+		t.Errorf("Expected %d rows with 6 columns, got: %d %v", count, res.Len(), res.Columns())
+	}
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		ids = ids[:0]
+		err = res.Each(0, func(idx int, row []interface{}) error {
+			ids = append(ids, row[0].(int64))
+			if row[3] != nil || row[4] != 1.5 || !reflect.DeepEqual(row[5], []byte{1, 2}) {
+				t.Errorf("Unexpected values in row %d: %v", idx, row)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != int(count) {
+			t.Errorf("Expected %d rows streamed, got: %d", count, len(ids))
+		}
+	}
+
+	var from []int64
+	err = res.Each(1, func(idx int, row []interface{}) error {
+		from = append(from, row[0].(int64))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(from, ids[1:]) {
+		t.Errorf("Expected rows from 1 on, got: %v, want: %v", from, ids[1:])
+	}
+
+	err = res.Each(res.Len()+1, func(int, []interface{}) error { return nil })
+	if err == nil {
+		t.Errorf("Expected error for out of range row")
+	}
+
+	err = res.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(res.path)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected spill file to be removed, got: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLiteBusyTimeout is the busy timeout Open sets for SQLite
+// connections, unless the DSN sets "_busy_timeout" or "_timeout".
+// SQLite waits this long for a lock before returning SQLITE_BUSY.
+var SQLiteBusyTimeout = 5 * time.Second
+
+// busyRetryWait is the wait before the first retry of a statement
+// which failed with SQLITE_BUSY, it is doubled for each retry
+var busyRetryWait = 50 * time.Millisecond
+
+// sqliteDSN returns dsn with the busy timeout added
+func sqliteDSN(dsn string) string {
+	if SQLiteBusyTimeout <= 0 || strings.Contains(dsn, "_busy_timeout=") || strings.Contains(dsn, "_timeout=") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_busy_timeout=" + strconv.FormatInt(SQLiteBusyTimeout.Milliseconds(), 10)
+}
+
+// isBusy returns true if err reports a locked SQLite database
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// retryBusy runs fn and retries it up to BusyRetries times while it
+// fails with SQLITE_BUSY. Statements inside a transaction are not
+// retried, as the transaction needs to be restarted as a whole.
+func (db *DB) retryBusy(ctx context.Context, fn func() error) error {
+	err := fn()
+	if db.BusyRetries <= 0 || db.sqlTx != nil {
+		return err
+	}
+
+	wait := busyRetryWait
+	for i := 0; i < db.BusyRetries && isBusy(err); i++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+		err = fn()
+	}
+	return err
+}
+
+// Replace works like Insert, but replaces rows with the same primary
+// key or unique value using SQLite's "INSERT OR REPLACE". The
+// replaced row is deleted and the new row inserted, so columns not
+// set in data are reset to their defaults. For other drivers, use
+// Upsert.
+//
+//	err := db.Replace("settings", &setting)
+func (db *DB) Replace(table string, data interface{}) error {
+	if db.Driver != SQLITE3 {
+		return fmt.Errorf("sqlpro.Replace: Not supported for driver %q, use Upsert.", db.Driver)
+	}
+
+	newDB := *db
+	newDB.insertOr = "REPLACE"
+	return newDB.Insert(table, data)
+}
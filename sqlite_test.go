@@ -0,0 +1,83 @@
+package sqlpro
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRowReplace struct {
+	ID   int64  `db:"id,pk"`
+	Name string `db:"name"`
+}
+
+func TestSQLite(t *testing.T) {
+	sqlite := *sqliteDB
+
+	// BIGINT PRIMARY KEY is no alias of the rowid
+	err := sqlite.Exec("CREATE TABLE test_replace(id BIGINT PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testRowReplace{ID: 42, Name: "a"}
+	err = sqlite.Insert("test_replace", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID != 42 {
+		t.Errorf("Expected key to be kept, got %d", row.ID)
+	}
+
+	row.Name = "b"
+	err = sqlite.Replace("test_replace", []*testRowReplace{&row, {ID: 43, Name: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	err = sqlite.Query(&names, "SELECT name FROM test_replace ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"b", "c"}) {
+		t.Errorf("Unexpected names: %v", names)
+	}
+
+	pg := *db
+	pg.Driver = POSTGRES
+	if pg.Replace("test_replace", &row) == nil {
+		t.Errorf("Expected error for Replace with postgres")
+	}
+
+	if dsn := sqliteDSN("app.db?_fk=1"); dsn != "app.db?_fk=1&_busy_timeout=5000" {
+		t.Errorf("Unexpected DSN: %s", dsn)
+	}
+	if dsn := sqliteDSN("app.db?_busy_timeout=10"); dsn != "app.db?_busy_timeout=10" {
+		t.Errorf("Unexpected DSN: %s", dsn)
+	}
+
+	defer func(wait time.Duration) { busyRetryWait = wait }(busyRetryWait)
+	busyRetryWait = time.Millisecond
+	sqlite.BusyRetries = 2
+	calls := 0
+	err = sqlite.retryBusy(context.Background(), func() error {
+		calls++
+		return errors.New("database is locked")
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("Expected 3 calls and an error, got %d calls: %v", calls, err)
+	}
+	calls = 0
+	err = sqlite.retryBusy(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("database is locked")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expected success after retry, got %d calls: %v", calls, err)
+	}
+}
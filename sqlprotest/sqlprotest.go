@@ -0,0 +1,38 @@
+// Package sqlprotest contains helpers for tests using sqlpro.
+package sqlprotest
+
+import (
+	"testing"
+
+	"github.com/programmfabrik/sqlpro"
+)
+
+// WithRollback runs fn inside a transaction of db, which is always
+// rolled back after fn returned or panicked. Code run by fn which
+// begins its own transaction using tx.Begin or tx.RunTx gets a nested
+// transaction using a SAVEPOINT. This way tests are isolated from
+// each other without re-creating the database.
+//
+//	sqlprotest.WithRollback(t, db, func(tx *sqlpro.Tx) {
+//		err := service.CreateUser(tx.DB, "henk")
+//		...
+//	})
+//
+// db needs to be created using sqlpro.Open.
+func WithRollback(t testing.TB, db *sqlpro.DB, fn func(tx *sqlpro.Tx)) {
+	t.Helper()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("sqlprotest.WithRollback: Unable to begin transaction: %s", err)
+	}
+
+	defer func() {
+		err := tx.Rollback()
+		if err != nil {
+			t.Errorf("sqlprotest.WithRollback: Unable to roll back: %s", err)
+		}
+	}()
+
+	fn(tx)
+}
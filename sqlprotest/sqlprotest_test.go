@@ -0,0 +1,59 @@
+package sqlprotest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/programmfabrik/sqlpro"
+)
+
+func TestWithRollback(t *testing.T) {
+	var count int64
+
+	os.Remove("./test_rollback.db")
+	defer os.Remove("./test_rollback.db")
+
+	db, err := sqlpro.Open("sqlite3", "./test_rollback.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	WithRollback(t, db, func(tx *sqlpro.Tx) {
+		err := tx.Exec("INSERT INTO test VALUES(1)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// code committing its own transaction
+		err = tx.RunTx(context.Background(), func(tx *sqlpro.Tx) error {
+			return tx.Exec("INSERT INTO test VALUES(2)")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = tx.Query(&count, "SELECT count(*) FROM test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 rows inside the transaction, got: %d", count)
+		}
+	})
+
+	err = db.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 rows after rollback, got: %d", count)
+	}
+}
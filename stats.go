@@ -0,0 +1,72 @@
+package sqlpro
+
+import (
+	"reflect"
+	"time"
+)
+
+// QueryStats reports the work done by one Query, see OnQueryStats.
+// Use it to plan the capacity of endpoints which export many rows.
+// For FindInBatches, each batch is reported separately, so the
+// largest Bytes is the peak memory needed for a batch.
+type QueryStats struct {
+	Query    string
+	Rows     int64
+	Bytes    int64 // approximate size of the scanned values
+	Duration time.Duration
+}
+
+// add counts one scanned row
+func (qs *QueryStats) add(row reflect.Value) {
+	if qs == nil {
+		return
+	}
+	qs.Rows++
+	qs.Bytes += approxSize(row)
+}
+
+// approxSize returns the approximate number of bytes
+// used by the value, including referenced data
+func approxSize(v reflect.Value) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return int64(v.Type().Size())
+		}
+		return int64(v.Type().Size()) + approxSize(v.Elem())
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return size + int64(v.Len())
+		}
+		for i := 0; i < v.Len(); i++ {
+			size += approxSize(v.Index(i))
+		}
+		return size
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		iter := v.MapRange()
+		for iter.Next() {
+			size += approxSize(iter.Key()) + approxSize(iter.Value())
+		}
+		return size
+	case reflect.Struct:
+		size := int64(0)
+		for i := 0; i < v.NumField(); i++ {
+			size += approxSize(v.Field(i))
+		}
+		// padding
+		if int64(v.Type().Size()) > size {
+			return int64(v.Type().Size())
+		}
+		return size
+	default:
+		return int64(v.Type().Size())
+	}
+}
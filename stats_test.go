@@ -0,0 +1,39 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryStats(t *testing.T) {
+	var (
+		stats []QueryStats
+		rows  []testRowLazy
+	)
+
+	err := db.Exec("CREATE TABLE test_stats(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, payload TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Insert("test_stats", &testRowLazy{Name: "doc", Payload: "heavy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db2 := *db
+	db2.OnQueryStats = func(qs QueryStats) {
+		stats = append(stats, qs)
+	}
+
+	err = db2.Query(&rows, "SELECT * FROM test_stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Rows != 1 || stats[0].Query != "SELECT * FROM test_stats" {
+		t.Fatalf("Unexpected stats: %v", stats)
+	}
+	// the strings "doc" and "heavy" need at least 8 bytes
+	if stats[0].Bytes < int64(reflect.TypeOf(rows[0]).Size())+8 {
+		t.Errorf("Expected bytes to include strings: %v", stats[0])
+	}
+}
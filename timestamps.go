@@ -0,0 +1,76 @@
+package sqlpro
+
+import (
+	"reflect"
+	"time"
+)
+
+// now returns the current time, in TimeLocation if set
+func (db *DB) now() time.Time {
+	now := time.Now()
+	if db.TimeLocation != nil {
+		now = now.In(db.TimeLocation)
+	}
+	return now
+}
+
+// stampTimes sets the fields of row tagged "autoupdate" to the
+// current time. With insert set, the zero fields tagged "autocreate"
+// are set as well. If row is not addressable, a stamped copy is
+// returned.
+//
+//	CreatedAt time.Time  `db:"created_at,autocreate"`
+//	UpdatedAt *time.Time `db:"updated_at,autoupdate"`
+func (db *DB) stampTimes(row reflect.Value, insert bool) reflect.Value {
+	if row.Kind() != reflect.Struct {
+		return row
+	}
+
+	var now time.Time
+	for _, fi := range db.structInfo(row.Type()) {
+		if !fi.autoUpdate && !(insert && fi.autoCreate) {
+			continue
+		}
+		if fi.autoCreate && !fi.autoUpdate && !isZero(row.FieldByIndex(fi.structField.Index).Interface()) {
+			continue
+		}
+		if !row.CanAddr() {
+			cp := reflect.New(row.Type()).Elem()
+			cp.Set(row)
+			row = cp
+		}
+		if now.IsZero() {
+			now = db.now()
+		}
+		setTimeField(row.FieldByIndex(fi.structField.Index), now)
+	}
+	return row
+}
+
+// autoUpdateColumns returns the columns of t tagged "autoupdate",
+// named as in table
+func (db *DB) autoUpdateColumns(t reflect.Type, table string) []string {
+	cols := make([]string, 0)
+	for _, fi := range db.tableStructInfo(t, table) {
+		if fi.autoUpdate {
+			cols = append(cols, fi.dbName)
+		}
+	}
+	return cols
+}
+
+// setTimeField sets fieldV, a time.Time, *time.Time or NullTime, to t.
+// It returns false for other types.
+func setTimeField(fieldV reflect.Value, t time.Time) bool {
+	switch fieldV.Interface().(type) {
+	case time.Time:
+		fieldV.Set(reflect.ValueOf(t))
+	case *time.Time:
+		fieldV.Set(reflect.ValueOf(&t))
+	case NullTime:
+		fieldV.Set(reflect.ValueOf(NullTime{Time: &t, Valid: true}))
+	default:
+		return false
+	}
+	return true
+}
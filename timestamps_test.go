@@ -0,0 +1,72 @@
+package sqlpro
+
+import (
+	"testing"
+	"time"
+)
+
+type testRowTimestamps struct {
+	ID        int64      `db:"id,pk,omitempty"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at,autocreate"`
+	UpdatedAt *time.Time `db:"updated_at,autoupdate"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_timestamps(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, created_at DATETIME, updated_at DATETIME)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	row := testRowTimestamps{Name: "a"}
+	err = db.Insert("test_timestamps", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.CreatedAt.Before(start) || row.UpdatedAt == nil || row.UpdatedAt.Before(start) {
+		t.Errorf("Expected timestamps to be set on insert, got: %v %v", row.CreatedAt, row.UpdatedAt)
+	}
+	created := row.CreatedAt
+
+	imported := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []testRowTimestamps{{Name: "b", CreatedAt: imported}}
+	err = db.InsertBulk("test_timestamps", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded testRowTimestamps
+	err = db.Query(&loaded, "SELECT * FROM test_timestamps WHERE name = 'b'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.CreatedAt.Equal(imported) || loaded.UpdatedAt == nil {
+		t.Errorf("Expected given created_at to be kept, got: %v %v", loaded.CreatedAt, loaded.UpdatedAt)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	row.Name = "c"
+	err = db.UpdateColumns("test_timestamps", &row, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&loaded, "SELECT * FROM test_timestamps WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.UpdatedAt.After(created) || !loaded.CreatedAt.Equal(created) {
+		t.Errorf("Expected only updated_at to change, got: %v %v", loaded.CreatedAt, loaded.UpdatedAt)
+	}
+
+	loc := time.FixedZone("test", 3600)
+	local := *db
+	local.TimeLocation = loc
+	row2 := testRowTimestamps{Name: "d"}
+	err = local.Save("test_timestamps", &row2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row2.CreatedAt.Location() != loc {
+		t.Errorf("Expected created_at in the configured location, got: %v", row2.CreatedAt)
+	}
+}
@@ -0,0 +1,203 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// tracker holds the values of tracked structs, it is shared by all
+// copies of a DB
+type tracker struct {
+	sync.Mutex
+	m map[interface{}]map[string]interface{}
+}
+
+// Track records the current values of rows, which need to be pointers
+// to structs or a pointer to a slice of structs. A later Update of a
+// tracked struct only sets the columns which changed since, and does
+// nothing if no column changed. This avoids overwriting columns
+// changed by other writers.
+//
+//	err := db.Query(&user, "SELECT * FROM user WHERE id = ?", id)
+//	err = db.Track(&user)
+//	user.Name = "Henk"
+//	err = db.Update("user", &user) // UPDATE "user" SET "name"=? WHERE "id"=?
+//
+// After Update the new values are tracked. The tracked values are
+// kept until Untrack is called, so call Untrack when done with the
+// struct.
+func (db *DB) Track(rows ...interface{}) error {
+	if db.tracked == nil {
+		return fmt.Errorf("sqlpro.Track: The wrapper must be created using New or Open.")
+	}
+
+	ptrs, err := trackPointers(rows)
+	if err != nil {
+		return err
+	}
+
+	for _, ptr := range ptrs {
+		values, _, err := db.valuesFromStruct(reflect.ValueOf(ptr).Elem().Interface())
+		if err != nil {
+			return err
+		}
+		// the values share pointers, slices and maps with the struct,
+		// which would see changes made in place
+		for col, v := range values {
+			values[col] = deepCopy(v)
+		}
+		db.tracked.Lock()
+		db.tracked.m[ptr] = values
+		db.tracked.Unlock()
+	}
+	return nil
+}
+
+// Untrack forgets the values of rows recorded by Track.
+func (db *DB) Untrack(rows ...interface{}) {
+	if db.tracked == nil {
+		return
+	}
+
+	ptrs, _ := trackPointers(rows)
+
+	db.tracked.Lock()
+	for _, ptr := range ptrs {
+		delete(db.tracked.m, ptr)
+	}
+	db.tracked.Unlock()
+}
+
+// trackPointers returns the pointers to all structs in rows
+func trackPointers(rows []interface{}) ([]interface{}, error) {
+	ptrs := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		rv := reflect.ValueOf(row)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return nil, fmt.Errorf("sqlpro.Track: Need a pointer to a struct or slice, have: %T", row)
+		}
+		switch rv.Elem().Kind() {
+		case reflect.Struct:
+			ptrs = append(ptrs, row)
+		case reflect.Slice:
+			for i := 0; i < rv.Elem().Len(); i++ {
+				el := rv.Elem().Index(i)
+				if el.Kind() == reflect.Ptr {
+					ptrs = append(ptrs, el.Interface())
+				} else {
+					ptrs = append(ptrs, el.Addr().Interface())
+				}
+			}
+		default:
+			return nil, fmt.Errorf("sqlpro.Track: Need a pointer to a struct or slice, have: %T", row)
+		}
+	}
+	return ptrs, nil
+}
+
+// changedColumns returns the columns of the struct ptr points to which
+// changed since Track, named as in table. It returns false if ptr is
+// not tracked.
+func (db *DB) changedColumns(table string, ptr interface{}) ([]string, bool, error) {
+	if db.tracked == nil {
+		return nil, false, nil
+	}
+
+	db.tracked.Lock()
+	old, ok := db.tracked.m[ptr]
+	db.tracked.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	rv := reflect.ValueOf(ptr).Elem()
+	values, info, err := db.valuesFromStruct(rv.Interface())
+	if err != nil {
+		return nil, true, err
+	}
+	mapping := db.columnMapping(rv.Type(), table)
+
+	changed := make([]string, 0)
+	for col, fi := range info {
+		if fi.primaryKey || fi.readOnly {
+			continue
+		}
+		oldV, oldOk := old[col]
+		newV, newOk := values[col]
+		if oldOk == newOk && reflect.DeepEqual(oldV, newV) {
+			continue
+		}
+		if to, ok := mapping[col]; ok {
+			col = to
+		}
+		changed = append(changed, col)
+	}
+	sort.Strings(changed)
+
+	return changed, true, nil
+}
+
+// deepCopy returns a copy of v which shares no pointers, slices or
+// maps with v. Unexported struct fields are copied as is.
+func deepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return deepCopyValue(reflect.ValueOf(v)).Interface()
+}
+
+func deepCopyValue(rv reflect.Value) reflect.Value {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.New(rv.Type().Elem())
+		cp.Elem().Set(deepCopyValue(rv.Elem()))
+		return cp
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(deepCopyValue(rv.Elem()))
+		return cp
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(deepCopyValue(rv.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(rv.Type()).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(deepCopyValue(rv.Index(i)))
+		}
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		for i := 0; i < rv.NumField(); i++ {
+			if cp.Field(i).CanSet() {
+				cp.Field(i).Set(deepCopyValue(rv.Field(i)))
+			}
+		}
+		return cp
+	}
+	return rv
+}
@@ -0,0 +1,135 @@
+package sqlpro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrack(t *testing.T) {
+	var (
+		row  testRowColumns
+		rows []testRowColumns
+	)
+
+	err := db.Exec("CREATE TABLE test_track_columns(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, email TEXT, note TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Insert("test_track_columns", &testRowColumns{Name: "henk", Email: "henk@example.com", Note: "changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&row, "SELECT * FROM test_track_columns LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Track(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Untrack(&row)
+
+	// concurrent change of note
+	err = db.Exec("UPDATE test_track_columns SET note = 'concurrent' WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing changed, no statement runs
+	err = db.Update("test_track_columns", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row.Name = "tracked"
+	err = db.Update("test_track_columns", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_track_columns WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].Name != "tracked" || rows[0].Note != "concurrent" {
+		t.Errorf("Expected only name to be updated, got: %v", rows[0])
+	}
+
+	changed, tracked, err := db.changedColumns("test_track_columns", &row)
+	if err != nil || !tracked || len(changed) != 0 {
+		t.Errorf("Expected no changes after update, got: %v %v %v", changed, tracked, err)
+	}
+
+	db.Untrack(&row)
+	_, tracked, _ = db.changedColumns("test_track_columns", &row)
+	if tracked {
+		t.Errorf("Expected row to be untracked")
+	}
+
+	// untracked rows update all columns
+	err = db.Update("test_track_columns", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&row, "SELECT * FROM test_track_columns WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Note != "changed" {
+		t.Errorf("Expected note to be overwritten, got: %s", row.Note)
+	}
+}
+
+func TestTrackInPlace(t *testing.T) {
+	type testRowTrack struct {
+		ID   int64             `db:"id,pk,omitempty"`
+		Name *string           `db:"name"`
+		Data []byte            `db:"data"`
+		Meta map[string]string `db:"meta,json"`
+	}
+
+	err := db.Exec("CREATE TABLE test_track(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, data BLOB, meta TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "one"
+	row := testRowTrack{Name: &name, Data: []byte("abc"), Meta: map[string]string{"a": "1"}}
+	err = db.Insert("test_track", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Track(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Untrack(&row)
+
+	// changes in place must be detected
+	*row.Name = "two"
+	row.Data[0] = 'x'
+	row.Meta["a"] = "2"
+
+	changed, _, err := db.changedColumns("test_track", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []string{"data", "meta", "name"}) {
+		t.Errorf("Expected all columns to be changed, got: %v", changed)
+	}
+
+	err = db.Update("test_track", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []testRowTrack
+	err = db.Query(&rows, "SELECT * FROM test_track WHERE id = ?", row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rows[0].Name != "two" || string(rows[0].Data) != "xbc" || rows[0].Meta["a"] != "2" {
+		t.Errorf("Expected in place changes to be updated, got: %s %s %v", *rows[0].Name, rows[0].Data, rows[0].Meta)
+	}
+}
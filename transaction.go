@@ -1,39 +1,187 @@
 package sqlpro
 
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Tx is a transaction started with Begin. Tx provides the same
+// methods as DB, all running inside the transaction.
+type Tx struct {
+	*DB
+	savepoint string          // set for nested transactions
+	mark      afterCommitMark // after commit hooks at the start of the savepoint
+}
+
 // Begin starts a new transaction, this panics if
-// the wrapper was not initialized using "Open"
-func (db *DB) Begin() (*DB, error) {
+// the wrapper was not initialized using "Open" or "NewFromDB". Calling Begin on
+// a Tx starts a nested transaction using a SAVEPOINT, which is
+// released on Commit and rolled back to on Rollback. MSSQL uses
+// "SAVE TRANSACTION". MSSQL and Oracle keep savepoints until the
+// transaction ends.
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a new transaction like Begin, using the given
+// options to set the isolation level or request a read-only
+// transaction. opts can be nil. Nested transactions cannot
+// have options.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	var (
 		err error
 	)
 
+	if db.sqlTx != nil {
+		if opts != nil && (opts.Isolation != sql.LevelDefault || opts.ReadOnly) {
+			return nil, fmt.Errorf("sqlpro.DB.BeginTx: Unable to use options for a nested transaction.")
+		}
+		return db.beginSavepoint()
+	}
 	if db.sqlDB == nil {
 		panic("sqlpro.DB.Begin: The wrapper must be created using Open. The wrapper does not have access to the underlying sql.DB handle.")
 	}
-	if db.sqlTx != nil {
-		panic("sqlpro.DB.Begin: Unable to call Begin on a Transaction.")
-	}
 
 	db2 := *db
-	db2.sqlTx, err = db.sqlDB.Begin()
+	db2.sqlTx, err = db.sqlDB.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 	db2.DB = db2.sqlTx
+	db2.afterCommit = &afterCommit{}
 
-	return &db2, nil
+	return &Tx{DB: &db2}, nil
 }
 
-func (db *DB) Commit() error {
-	if db.sqlTx == nil {
-		panic("sqlpro.DB.Commit: Unable to call Commit without Transaction.")
+func (db *DB) beginSavepoint() (*Tx, error) {
+	db2 := *db
+	db2.txDepth++
+
+	savepoint := fmt.Sprintf("sqlpro_sp_%d", db2.txDepth)
+	err := db.Exec(db.savepointSQL("SAVEPOINT", savepoint))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{DB: &db2, savepoint: savepoint, mark: db2.afterCommit.mark()}, nil
+}
+
+// savepointSQL returns the statement to run op ("SAVEPOINT",
+// "RELEASE SAVEPOINT" or "ROLLBACK TO SAVEPOINT") on savepoint, using
+// the syntax of the db's driver. It returns "" if the driver releases
+// savepoints only with the transaction.
+func (db *DB) savepointSQL(op, savepoint string) string {
+	if db.Driver == MSSQL {
+		switch op {
+		case "SAVEPOINT":
+			return "SAVE TRANSACTION " + savepoint
+		case "ROLLBACK TO SAVEPOINT":
+			return "ROLLBACK TRANSACTION " + savepoint
+		default:
+			return ""
+		}
+	}
+	if db.Driver == ORACLE && op == "RELEASE SAVEPOINT" {
+		return ""
 	}
-	return db.sqlTx.Commit()
+	return op + " " + savepoint
+}
+
+// releaseSavepoint releases the savepoint of tx, if the driver
+// supports it
+func (tx *Tx) releaseSavepoint() error {
+	release := tx.savepointSQL("RELEASE SAVEPOINT", tx.savepoint)
+	if release == "" {
+		return nil
+	}
+	return tx.Exec(release)
+}
+
+// Commit commits the transaction. After a successful commit of the
+// outermost transaction, the events passed to Emit are delivered and
+// the hooks registered with AfterCommit are run.
+func (tx *Tx) Commit() error {
+	if tx.savepoint != "" {
+		return tx.releaseSavepoint()
+	}
+	err := tx.sqlTx.Commit()
+	if err != nil {
+		tx.afterCommit.reset(afterCommitMark{})
+		return err
+	}
+	tx.runAfterCommit()
+	return nil
+}
+
+// Rollback aborts the transaction and discards the after commit
+// hooks and events registered in it
+func (tx *Tx) Rollback() error {
+	if tx.savepoint != "" {
+		tx.afterCommit.reset(tx.mark)
+		err := tx.Exec(tx.savepointSQL("ROLLBACK TO SAVEPOINT", tx.savepoint))
+		if err != nil {
+			return err
+		}
+		return tx.releaseSavepoint()
+	}
+	tx.afterCommit.reset(afterCommitMark{})
+	return tx.sqlTx.Rollback()
+}
+
+// RunTx runs fn inside a new transaction. The transaction is
+// committed if fn returns nil, and rolled back if fn returns an
+// error or panics. A panic is passed on after the rollback.
+func (db *DB) RunTx(ctx context.Context, fn func(tx *Tx) error) error {
+	return db.RunTxOptions(ctx, nil, fn)
+}
+
+// RunTxOptions is RunTx using the given transaction options.
+func (db *DB) RunTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type txContextKey struct{}
+
+// ContextWithTx returns a copy of ctx with tx bound to it. Use
+// FromContext to retrieve the transaction.
+func ContextWithTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction bound to ctx using
+// ContextWithTx, or nil.
+func TxFromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(txContextKey{}).(*Tx)
+	return tx
 }
 
-func (db *DB) Rollback() error {
-	if db.sqlTx == nil {
-		panic("sqlpro.DB.Rollback: Unable to call Rollback without Transaction.")
+// FromContext returns the handle of the transaction bound to ctx,
+// or db if ctx has no transaction. This way code can join a
+// transaction started by the caller without knowing about it.
+func (db *DB) FromContext(ctx context.Context) *DB {
+	tx := TxFromContext(ctx)
+	if tx == nil {
+		return db
 	}
-	return db.sqlTx.Rollback()
+	return tx.DB
 }
@@ -0,0 +1,576 @@
+package sqlpro
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func openTxTestDB(t *testing.T) *DB {
+	os.Remove("./test_tx.db")
+
+	tdb, err := Open("sqlite3", "./test_tx.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Exec(`CREATE TABLE test(a INTEGER PRIMARY KEY AUTOINCREMENT, b TEXT, c TEXT, d REAL, e DATETIME, f TEXT)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tdb
+}
+
+func closeTxTestDB(tdb *DB) {
+	tdb.Close()
+	os.Remove("./test_tx.db")
+}
+
+func TestTxCommitRollback(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	tx, err := tdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := testRow{B: "tx"}
+	err = tx.Insert("test", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+	tr.C = "updated"
+	err = tx.Update("test", &tr)
+	if err != nil {
+		t.Error(err)
+	}
+	err = tx.Rollback()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected rollback to remove row, got count: %d", count)
+	}
+
+	tx, err = tdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.InsertBulk("test", []testRow{{B: "tx1"}, {B: "tx2"}})
+	if err != nil {
+		t.Error(err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 committed rows, got count: %d", count)
+	}
+}
+
+func TestRunTx(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.RunTx(context.Background(), func(tx *Tx) error {
+		return tx.Insert("test", &testRow{B: "committed"})
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	errRollback := errors.New("rollback")
+	err = tdb.RunTx(context.Background(), func(tx *Tx) error {
+		err := tx.Insert("test", &testRow{B: "rolled back"})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Errorf("Expected error from fn, got: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected RunTx to pass on the panic.")
+			}
+		}()
+		tdb.RunTx(context.Background(), func(tx *Tx) error {
+			tx.Insert("test", &testRow{B: "panic"})
+			panic("fn failed")
+		})
+	}()
+
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the committed row, got count: %d", count)
+	}
+}
+
+func TestNestedTx(t *testing.T) {
+	var names []string
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.RunTx(context.Background(), func(tx *Tx) error {
+		err := tx.Insert("test", &testRow{B: "outer"})
+		if err != nil {
+			return err
+		}
+
+		inner, err := tx.Begin()
+		if err != nil {
+			return err
+		}
+		err = inner.Insert("test", &testRow{B: "inner rolled back"})
+		if err != nil {
+			return err
+		}
+		err = inner.Rollback()
+		if err != nil {
+			return err
+		}
+
+		// nested RunTx uses a savepoint, too
+		return tx.RunTx(context.Background(), func(tx2 *Tx) error {
+			return tx2.Insert("test", &testRow{B: "inner committed"})
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Query(&names, "SELECT b FROM test ORDER BY a")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(names) != 2 || names[0] != "outer" || names[1] != "inner committed" {
+		t.Errorf("Unexpected rows after nested transactions: %v", names)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	insert := func(ctx context.Context, b string) error {
+		return tdb.FromContext(ctx).Insert("test", &testRow{B: b})
+	}
+
+	if tdb.FromContext(context.Background()) != tdb {
+		t.Errorf("Expected db without transaction in context.")
+	}
+
+	tx, err := tdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithTx(context.Background(), tx)
+	if TxFromContext(ctx) != tx {
+		t.Errorf("Expected tx from context.")
+	}
+
+	err = insert(ctx, "in tx")
+	if err != nil {
+		t.Error(err)
+	}
+	err = tx.Rollback()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = insert(context.Background(), "no tx")
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected insert inside the context transaction to be rolled back, got count: %d", count)
+	}
+}
+
+func TestBeginTxOptions(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.RunTxOptions(context.Background(), &sql.TxOptions{ReadOnly: true}, func(tx *Tx) error {
+		_, err := tx.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+		if err == nil {
+			t.Errorf("Expected error for options on nested transaction.")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInsertBulkPartialTx(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	tx, err := tdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := tx.InsertBulkPartial("test", []testRow{{A: 1, B: "one"}, {A: 1, B: "duplicate"}, {A: 3, B: "three"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].Index != 1 {
+		t.Errorf("Expected row 1 to fail, got: %v", report)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got: %d", count)
+	}
+}
+
+func TestTxAfterCommit(t *testing.T) {
+	var (
+		delivered []interface{}
+		hooks     int
+	)
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	tdb.OnCommit = func(events []interface{}) {
+		delivered = append(delivered, events...)
+	}
+
+	err := tdb.RunTx(context.Background(), func(tx *Tx) error {
+		tx.Emit("first")
+		tx.AfterCommit(func() { hooks++ })
+
+		nested, err := tx.Begin()
+		if err != nil {
+			return err
+		}
+		nested.Emit("rolled back")
+		nested.AfterCommit(func() { hooks += 10 })
+		err = nested.Rollback()
+		if err != nil {
+			return err
+		}
+
+		nested, err = tx.Begin()
+		if err != nil {
+			return err
+		}
+		nested.Emit("second")
+		err = nested.Commit()
+		if err != nil {
+			return err
+		}
+
+		if len(delivered) != 0 || hooks != 0 {
+			t.Errorf("Expected no delivery before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(delivered, []interface{}{"first", "second"}) || hooks != 1 {
+		t.Errorf("Unexpected delivery after commit: %v, hooks: %d", delivered, hooks)
+	}
+
+	delivered = nil
+	err = tdb.RunTx(context.Background(), func(tx *Tx) error {
+		tx.Emit("failed")
+		return fmt.Errorf("abort")
+	})
+	if err == nil || len(delivered) != 0 {
+		t.Errorf("Expected no delivery after rollback, got: %v", delivered)
+	}
+
+	tdb.Emit("direct")
+	if !reflect.DeepEqual(delivered, []interface{}{"direct"}) {
+		t.Errorf("Expected immediate delivery outside transaction, got: %v", delivered)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	var rows []testRow
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	err := tdb.InsertBulk("test", []testRow{{B: "one"}, {B: "two"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := tdb.Snapshot("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Exec("DELETE FROM test WHERE b = 'one'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.Insert("test", &testRow{B: "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Restore(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tdb.Query(&rows, "SELECT * FROM test ORDER BY a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].A != 1 || rows[0].B != "one" || rows[1].B != "two" {
+		t.Errorf("Unexpected rows after restore: %v", rows)
+	}
+}
+
+func TestNewFromDB(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	wdb := NewFromDB(tdb.SqlDB())
+	if wdb.SqlDB() != tdb.SqlDB() {
+		t.Errorf("Expected SqlDB to return the wrapped handle.")
+	}
+	if wdb.Driver != SQLITE3 || wdb.PlaceholderMode != QUESTION || wdb.BusyRetries != 3 {
+		t.Errorf("Expected sqlite3 dialect, have: %q %d", wdb.Driver, wdb.PlaceholderMode)
+	}
+
+	err := wdb.RunTx(context.Background(), func(tx *Tx) error {
+		return tx.Insert("test", &testRow{B: "from db"})
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the handle is owned by the caller
+	err = wdb.Close()
+	if err != nil {
+		t.Error(err)
+	}
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row, got count: %d", count)
+	}
+}
+
+func TestNewFromTx(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	sqlTx, err := tdb.SqlDB().Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wdb := NewFromTx(sqlTx)
+	if wdb.SqlDB() != nil {
+		t.Errorf("Expected no *sql.DB for a wrapped transaction.")
+	}
+	err = wdb.Insert("test", &testRow{B: "kept"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// nested transactions use savepoints of sqlTx
+	tx, err := wdb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.Insert("test", &testRow{B: "rolled back"})
+	if err != nil {
+		t.Error(err)
+	}
+	err = tx.Rollback()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = wdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row inside the transaction, got count: %d", count)
+	}
+
+	err = sqlTx.Rollback()
+	if err != nil {
+		t.Error(err)
+	}
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected rollback of the caller to remove rows, got count: %d", count)
+	}
+}
+
+func TestWrapTx(t *testing.T) {
+	var count int64
+
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+	tdb.MinimalEscape = true
+
+	sqlTx, err := tdb.SqlDB().Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wdb := tdb.WrapTx(sqlTx)
+	if wdb.Driver != SQLITE3 || wdb.PlaceholderMode != tdb.PlaceholderMode || !wdb.MinimalEscape {
+		t.Errorf("Expected the settings of the parent, got driver %q.", wdb.Driver)
+	}
+	if wdb.SqlDB() != nil {
+		t.Errorf("Expected no *sql.DB for a wrapped transaction.")
+	}
+	err = wdb.Insert("test", &testRow{B: "kept"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = sqlTx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tdb.Query(&count, "SELECT count(*) FROM test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row after commit, got count: %d", count)
+	}
+}
+
+// execRecorder records the statements passed to Exec
+type execRecorder struct {
+	statements []string
+}
+
+func (er *execRecorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, fmt.Errorf("execRecorder: Query not supported")
+}
+
+func (er *execRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	er.statements = append(er.statements, query)
+	return driver.RowsAffected(0), nil
+}
+
+func TestSavepointSQL(t *testing.T) {
+	tdb := openTxTestDB(t)
+	defer closeTxTestDB(tdb)
+
+	for driver, expected := range map[dbDriver][]string{
+		SQLITE3: {
+			"SAVEPOINT sqlpro_sp_1", "RELEASE SAVEPOINT sqlpro_sp_1",
+			"SAVEPOINT sqlpro_sp_1", "ROLLBACK TO SAVEPOINT sqlpro_sp_1", "RELEASE SAVEPOINT sqlpro_sp_1",
+		},
+		MSSQL: {
+			"SAVE TRANSACTION sqlpro_sp_1",
+			"SAVE TRANSACTION sqlpro_sp_1", "ROLLBACK TRANSACTION sqlpro_sp_1",
+		},
+		ORACLE: {
+			"SAVEPOINT sqlpro_sp_1",
+			"SAVEPOINT sqlpro_sp_1", "ROLLBACK TO SAVEPOINT sqlpro_sp_1",
+		},
+	} {
+		tx, err := tdb.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := &execRecorder{}
+		rtx := *tx.DB
+		rtx.DB = rec
+		rtx.Driver = driver
+
+		nested, err := rtx.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = nested.Commit()
+		if err != nil {
+			t.Error(err)
+		}
+		nested, err = rtx.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = nested.Rollback()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(rec.statements, expected) {
+			t.Errorf("%s: Unexpected statements: %q", driver, rec.statements)
+		}
+
+		err = tx.Rollback()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
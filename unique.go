@@ -0,0 +1,127 @@
+package sqlpro
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// GetByUnique loads the row of table with column equal to value into
+// target, which needs to be a pointer to a struct. column needs to be
+// tagged "unique" or "pk" in the struct, so that at most one row can
+// match. If no row matches, ErrQueryReturnedZeroRows is returned.
+//
+//	err := db.GetByUnique(&user, "user", "email", email)
+func (db *DB) GetByUnique(target interface{}, table string, column string, value interface{}) error {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.GetByUnique: Target needs to be a pointer to a struct, have: %T", target)
+	}
+
+	fi, ok := db.tableStructInfo(targetV.Elem().Type(), table)[column]
+	if !ok || !(fi.unique || fi.primaryKey) {
+		return fmt.Errorf("sqlpro.GetByUnique: Column %q is not tagged \"unique\" or \"pk\" in %T.", column, target)
+	}
+
+	return db.Get(target, table, db.Esc(column)+" = ?", value)
+}
+
+// conflictTarget returns the columns identifying a row for an upsert:
+// the column tagged "unique" or, without one, the "pk" columns
+func (si structInfo) conflictTarget() ([]string, error) {
+	uniques := make([]string, 0)
+	for _, fi := range si {
+		if fi.unique && !fi.primaryKey {
+			uniques = append(uniques, fi.dbName)
+		}
+	}
+	sort.Strings(uniques)
+
+	switch len(uniques) {
+	case 0:
+		pks := si.primaryKeys()
+		if len(pks) == 0 {
+			return nil, fmt.Errorf("Need a \"unique\" or \"pk\" column as conflict target.")
+		}
+		cols := make([]string, 0, len(pks))
+		for _, pk := range pks {
+			cols = append(cols, pk.dbName)
+		}
+		return cols, nil
+	case 1:
+		return uniques, nil
+	default:
+		return nil, fmt.Errorf("Unable to choose the conflict target from the unique columns %s.", strings.Join(uniques, ", "))
+	}
+}
+
+// Upsert inserts data, a struct or a pointer to a struct, into table.
+// If a row with the same natural key exists, it is updated instead.
+// The natural key is the column tagged "unique" or, if there is none,
+// the "pk" columns. The key columns need a unique index.
+//
+//	err := db.Upsert("user", &User{Email: "henk@example.com", Name: "Henk"})
+//
+// The primary key of data is not set. Columns tagged "autocreate"
+// are kept for existing rows. Upsert uses "ON CONFLICT" and is
+// supported for Postgres and SQLite.
+func (db *DB) Upsert(table string, data interface{}) error {
+	switch db.Driver {
+	case POSTGRES, SQLITE3:
+	default:
+		return fmt.Errorf("sqlpro.Upsert: Not supported for driver '%s'.", db.Driver)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(data))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlpro.Upsert: Data needs to be a struct, have: %T", data)
+	}
+
+	err := db.validate(rv)
+	if err != nil {
+		return err
+	}
+
+	values, info, err := db.tableValuesFromStruct(table, db.stampTimes(rv, true).Interface())
+	if err != nil {
+		return err
+	}
+
+	target, err := info.conflictTarget()
+	if err != nil {
+		return fmt.Errorf("sqlpro.Upsert: %s", err)
+	}
+
+	isTarget := make(map[string]bool, len(target))
+	escTarget := make([]string, 0, len(target))
+	for _, col := range target {
+		if _, ok := values[col]; !ok {
+			return fmt.Errorf("sqlpro.Upsert: Conflict target %q has no value.", col)
+		}
+		isTarget[col] = true
+		escTarget = append(escTarget, db.Esc(col))
+	}
+
+	sets := make([]string, 0, len(values))
+	for col := range values {
+		if isTarget[col] || info.primaryKey(col) || info[col].autoCreate {
+			continue
+		}
+		sets = append(sets, db.Esc(col)+" = EXCLUDED."+db.Esc(col))
+	}
+	sort.Strings(sets)
+
+	insert, args, err := db.insertClauseFromValues(table, values, info)
+	if err != nil {
+		return err
+	}
+
+	conflict := " ON CONFLICT (" + strings.Join(escTarget, ", ") + ") DO NOTHING"
+	if len(sets) > 0 {
+		conflict = " ON CONFLICT (" + strings.Join(escTarget, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", ")
+	}
+
+	_, err = db.exec(-1, insert+conflict, args...)
+	return err
+}
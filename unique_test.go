@@ -0,0 +1,68 @@
+package sqlpro
+
+import (
+	"errors"
+	"testing"
+)
+
+type testRowNaturalKey struct {
+	ID    int64  `db:"id,pk,omitempty"`
+	Email string `db:"email,unique"`
+	Name  string `db:"name"`
+}
+
+func TestGetByUniqueUpsert(t *testing.T) {
+	var (
+		row  testRowNaturalKey
+		rows []testRowNaturalKey
+	)
+
+	sqlite := *sqliteDB
+
+	err := sqlite.Exec("CREATE TABLE test_natural_key(id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Henk", "Henk 2"} {
+		err = sqlite.Upsert("test_natural_key", &testRowNaturalKey{Email: "henk@example.com", Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = sqlite.Upsert("test_natural_key", testRowNaturalKey{Email: "jan@example.com", Name: "Jan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sqlite.Query(&rows, "SELECT * FROM test_natural_key ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Name != "Henk 2" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	err = sqlite.GetByUnique(&row, "test_natural_key", "email", "jan@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Name != "Jan" || row.ID != rows[1].ID {
+		t.Errorf("Unexpected row: %v", row)
+	}
+
+	err = sqlite.GetByUnique(&row, "test_natural_key", "email", "nobody@example.com")
+	if !errors.Is(err, ErrQueryReturnedZeroRows) {
+		t.Errorf("Expected ErrQueryReturnedZeroRows, got: %v", err)
+	}
+	err = sqlite.GetByUnique(&row, "test_natural_key", "name", "Jan")
+	if err == nil {
+		t.Errorf("Expected error for column not tagged unique")
+	}
+
+	mssql := *db
+	mssql.Driver = MSSQL
+	if mssql.Upsert("test_natural_key", &row) == nil {
+		t.Errorf("Expected error for unsupported driver")
+	}
+}
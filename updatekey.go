@@ -0,0 +1,144 @@
+package sqlpro
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// updateByKeyChunk is the number of rows updated per transaction
+const updateByKeyChunk = 500
+
+// UpdateByKey updates the rows of table matching the key columns of
+// each row of data, a slice of structs. Use this for imports which
+// reference rows by business keys instead of their primary key. All
+// columns of a row but the key, "pk" and "autocreate" columns are set,
+// "autoupdate" columns are set to the current time. UpdateByKey
+// returns the number of updated rows, rows of data without match are
+// ignored.
+//
+//	n, err := db.UpdateByKey("product", imported, "sku")
+//
+// If not already in a transaction and the wrapper was initialized
+// using "Open", the rows are updated in chunks of 500 rows, each
+// in its own transaction. If a chunk fails, the chunks before stay
+// committed and their rows are counted.
+func (db *DB) UpdateByKey(table string, data interface{}, keyCols ...string) (int64, error) {
+	var total int64
+
+	if len(keyCols) == 0 {
+		return 0, fmt.Errorf("sqlpro.UpdateByKey: Need at least one key column.")
+	}
+	err := db.checkMutation("UpdateByKey")
+	if err != nil {
+		return 0, err
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("sqlpro.UpdateByKey: Data needs to be a slice, have: %T", data)
+	}
+
+	statements := make([]SQLFragment, 0, rv.Len())
+	wheres := make([]SQLFragment, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		err := db.validate(reflect.Indirect(rv.Index(i)))
+		if err != nil {
+			return 0, xerrors.Errorf("sqlpro.UpdateByKey: Row %d: %w", i, err)
+		}
+		stmt, where, err := db.updateByKeyStatement(table, db.stampTimes(reflect.Indirect(rv.Index(i)), false).Interface(), keyCols)
+		if err != nil {
+			return 0, xerrors.Errorf("sqlpro.UpdateByKey: Row %d: %w", i, err)
+		}
+		statements = append(statements, stmt)
+		wheres = append(wheres, where)
+	}
+
+	for start := 0; start < len(statements); start += updateByKeyChunk {
+		end := start + updateByKeyChunk
+		if end > len(statements) {
+			end = len(statements)
+		}
+
+		var chunkTotal int64
+		update := func(db *DB) error {
+			chunkTotal = 0
+			for idx, stmt := range statements[start:end] {
+				where := wheres[start+idx]
+				err := db.withHistoryWhere(table, " WHERE "+where.SQL, where.Args, func(db *DB) error {
+					n, err := db.exec(-1, stmt.SQL, stmt.Args...)
+					chunkTotal += n
+					return err
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var err error
+		if db.sqlTx == nil && db.sqlDB != nil {
+			err = db.RunTx(context.Background(), func(tx *Tx) error {
+				return update(tx.DB)
+			})
+		} else {
+			err = update(db)
+		}
+		if err != nil {
+			return total, xerrors.Errorf("sqlpro.UpdateByKey: %w", err)
+		}
+		total += chunkTotal
+	}
+
+	return total, nil
+}
+
+// updateByKeyStatement returns the UPDATE for row matched by keyCols,
+// together with its WHERE condition
+func (db *DB) updateByKeyStatement(table string, row interface{}, keyCols []string) (SQLFragment, SQLFragment, error) {
+	if reflect.ValueOf(row).Kind() != reflect.Struct {
+		return SQLFragment{}, SQLFragment{}, fmt.Errorf("Need a struct, have: %T", row)
+	}
+
+	values, info, err := db.tableValuesFromStruct(table, row)
+	if err != nil {
+		return SQLFragment{}, SQLFragment{}, err
+	}
+
+	isKey := make(map[string]bool, len(keyCols))
+	for _, key := range keyCols {
+		if _, ok := info[key]; !ok {
+			return SQLFragment{}, SQLFragment{}, fmt.Errorf("Key column %q is not mapped in %T.", key, row)
+		}
+		if _, ok := values[key]; !ok {
+			return SQLFragment{}, SQLFragment{}, fmt.Errorf("Key column %q has no value.", key)
+		}
+		isKey[key] = true
+	}
+
+	cols := make([]string, 0, len(values))
+	for col := range values {
+		if !isKey[col] && !info.primaryKey(col) && !info[col].autoCreate {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return SQLFragment{}, SQLFragment{}, fmt.Errorf("No columns to update.")
+	}
+	sort.Strings(cols)
+
+	sets := make([]string, 0, len(cols))
+	args := make([]interface{}, 0, len(cols)+len(keyCols))
+	for _, col := range cols {
+		sets = append(sets, db.Esc(col)+"=?")
+		args = append(args, db.argValue(values[col], info[col]))
+	}
+
+	where := db.syncWhere(values, keyCols)
+	return Fragment(db.updatePrefix(table)+strings.Join(sets, ",")+" WHERE "+where.SQL, append(args, where.Args...)...), where, nil
+}
@@ -0,0 +1,45 @@
+package sqlpro
+
+import (
+	"testing"
+)
+
+type testRowProduct struct {
+	ID    int64   `db:"id,pk,omitempty"`
+	SKU   string  `db:"sku"`
+	Price float64 `db:"price"`
+}
+
+func TestUpdateByKey(t *testing.T) {
+	var rows []testRowProduct
+
+	err := db.Exec("CREATE TABLE test_product(id INTEGER PRIMARY KEY AUTOINCREMENT, sku TEXT, price REAL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.InsertBulk("test_product", []testRowProduct{{SKU: "a", Price: 1}, {SKU: "b", Price: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.UpdateByKey("test_product", []*testRowProduct{{SKU: "b", Price: 20}, {SKU: "c", Price: 30}}, "sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 updated row, got: %d", n)
+	}
+
+	err = db.Query(&rows, "SELECT * FROM test_product ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Price != 1 || rows[1].Price != 20 || rows[1].ID != 2 {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+
+	_, err = db.UpdateByKey("test_product", []testRowProduct{{SKU: "a"}}, "unknown")
+	if err == nil {
+		t.Errorf("Expected error for unmapped key column")
+	}
+}
@@ -3,10 +3,12 @@ package sqlpro
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +18,10 @@ import (
 
 var ErrQueryReturnedZeroRows error = errors.New("Query returned 0 rows.")
 
+// ErrMaxRowsExceeded is returned by Query if more than MaxRows
+// rows are returned for a slice target.
+var ErrMaxRowsExceeded error = errors.New("Query returned more than MaxRows rows.")
+
 // structInfo is a map to fieldInfo by db_name
 type structInfo map[string]*fieldInfo
 
@@ -50,6 +56,20 @@ func (si structInfo) onlyPrimaryKey() *fieldInfo {
 	return fi
 }
 
+// primaryKeys returns all "pk" fields in the order of the struct
+func (si structInfo) primaryKeys() []*fieldInfo {
+	pks := make([]*fieldInfo, 0)
+	for _, info := range si {
+		if info.primaryKey {
+			pks = append(pks, info)
+		}
+	}
+	sort.Slice(pks, func(i, j int) bool {
+		return pks[i].before(pks[j])
+	})
+	return pks
+}
+
 type NullTime struct {
 	Time  *time.Time
 	Valid bool
@@ -74,6 +94,14 @@ func (ni *NullTime) Scan(value interface{}) error {
 
 }
 
+// Value implements the driver Valuer interface.
+func (ni NullTime) Value() (driver.Value, error) {
+	if !ni.Valid || ni.Time == nil {
+		return nil, nil
+	}
+	return *ni.Time, nil
+}
+
 type NullJson struct {
 	Data  []byte
 	Valid bool
@@ -102,6 +130,28 @@ func (nj *NullJson) Scan(value interface{}) error {
 	}
 }
 
+// nullBytes scans binary columns into *[]byte fields
+type nullBytes struct {
+	Data  []byte
+	Valid bool
+}
+
+// Scan implements the Scanner interface.
+func (nb *nullBytes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		nb.Data, nb.Valid = nil, false
+	case []byte:
+		// the driver may reuse v
+		nb.Data, nb.Valid = append([]byte{}, v...), true
+	case string:
+		nb.Data, nb.Valid = []byte(v), true
+	default:
+		return xerrors.Errorf("sqlpro.nullBytes.Scan: Unable to scan type %T", value)
+	}
+	return nil
+}
+
 type NullRawMessage struct {
 	Data  json.RawMessage
 	Valid bool
@@ -140,6 +190,18 @@ type fieldInfo struct {
 	readOnly    bool
 	notNull     bool
 	isJson      bool
+	uuid        bool
+	sequence    string
+	lazy        bool // not selected unless asked for
+	isHstore    bool
+	unique      bool
+	softDelete  bool // set to the time of deletion by Delete
+	secret      bool // value is redacted in debug output
+	autoCreate  bool // set to the current time by Insert, if zero
+	autoUpdate  bool // set to the current time by Insert and Update
+	normalizers []string
+	mappedFrom  string // the column of the "db" tag, if renamed by MapStruct
+	enum        []string
 	emptyValue  string
 	ptr         bool // set true if the field is a pointer
 }
@@ -158,17 +220,82 @@ func (fi *fieldInfo) allowNull() bool {
 	return false
 }
 
-// getStructInfo returns a per dbName to fieldInfo map
+// integerKey returns true if the field can receive the id
+// generated by the database, which is the case for integer fields
+func (fi *fieldInfo) integerKey() bool {
+	switch fi.structField.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// before returns true if fi comes before other in the struct,
+// fields of embedded structs are ordered at the position of the
+// embedded struct
+func (fi *fieldInfo) before(other *fieldInfo) bool {
+	a, b := fi.structField.Index, other.structField.Index
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// merge adds the fields of the struct at field index idx to si, with
+// prefix added to their column names. Existing fields are kept.
+func (si structInfo) merge(inner structInfo, idx int, prefix string) {
+	for dbName, info := range inner {
+		fi := *info
+		fi.dbName = prefix + dbName
+		fi.structField.Index = append([]int{idx}, info.structField.Index...)
+		if _, ok := si[fi.dbName]; !ok {
+			si[fi.dbName] = &fi
+		}
+	}
+}
+
+// getStructInfo returns a per dbName to fieldInfo map. The fields of
+// embedded structs without "db" tag are merged into the map, with
+// the fields of the outer struct taking precedence. For embedded or
+// nested structs tagged with a prefix ending in "_" or ".", e.g.
+// `db:"address_"`, the fields are merged with the prefix added to
+// their column names. Use "." prefixes to scan JOIN results into
+// one struct per table, with the columns selected as "u.id" etc.
 func getStructInfo(t reflect.Type) structInfo {
+	return structInfoWith(t, nil)
+}
+
+// structInfoWith works like getStructInfo. If mapper is set, exported
+// fields without "db" tag and fields tagged without a column name,
+// e.g. `db:",pk"`, are mapped to the column mapper returns for the
+// field name. Untagged struct fields are only mapped if they are
+// time.Time or implement sql.Scanner or driver.Valuer.
+func structInfoWith(t reflect.Type, mapper func(string) string) structInfo {
 	si := make(structInfo, 0)
+	embedded := make(structInfo, 0)
 
 	// log.Printf("name: %s %d", t, t.NumField())
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 
-		dbTag := field.Tag.Get("db")
+		dbTag, tagged := field.Tag.Lookup("db")
 		if dbTag == "" {
-			// ignore field
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				embedded.merge(structInfoWith(field.Type, mapper), i, "")
+				continue
+			}
+			if mapper == nil || tagged || !mapUntagged(field) {
+				// ignore field
+				continue
+			}
+		}
+
+		if field.Type.Kind() == reflect.Struct && (strings.HasSuffix(dbTag, "_") || strings.HasSuffix(dbTag, ".")) && !strings.Contains(dbTag, ",") {
+			// column prefix for all fields of the inner struct
+			embedded.merge(structInfoWith(field.Type, mapper), i, dbTag)
 			continue
 		}
 
@@ -209,7 +336,11 @@ func getStructInfo(t reflect.Type) structInfo {
 		}
 
 		if info.dbName == "" {
-			info.dbName = field.Name
+			if mapper != nil {
+				info.dbName = mapper(field.Name)
+			} else {
+				info.dbName = field.Name
+			}
 		}
 
 		for idx, p := range path {
@@ -229,7 +360,34 @@ func getStructInfo(t reflect.Type) structInfo {
 				info.isJson = true
 			case "readonly":
 				info.readOnly = true
+			case "uuid":
+				info.uuid = true
+			case "lazy":
+				info.lazy = true
+			case "hstore":
+				info.isHstore = true
+			case "unique":
+				info.unique = true
+			case "softdelete":
+				info.softDelete = true
+			case "secret":
+				info.secret = true
+			case "autocreate":
+				info.autoCreate = true
+			case "autoupdate":
+				info.autoUpdate = true
+			case "trim", "lower", "upper":
+				info.normalizers = append(info.normalizers, p)
 			default:
+				if strings.HasPrefix(p, "seq=") {
+					info.sequence = p[4:]
+				}
+				if strings.HasPrefix(p, "normalize=") {
+					info.normalizers = append(info.normalizers, p[10:])
+				}
+				if strings.HasPrefix(p, "enum=") {
+					info.enum = strings.Split(p[5:], "|")
+				}
 				// ignore unrecognized
 			}
 		}
@@ -240,11 +398,19 @@ func getStructInfo(t reflect.Type) structInfo {
 
 		si[info.dbName] = &info
 	}
+
+	for dbName, info := range embedded {
+		if _, ok := si[dbName]; !ok {
+			si[dbName] = info
+		}
+	}
 	return si
 }
 
 // replaceArgs rewrites the string sqlS to embed the slice args given
 // it returns the new placeholder string and the reduced list of arguments.
+// SQLFragment args are embedded as subquery in parentheses, with their
+// args merged.
 func (db *DB) replaceArgs(sqlS string, args ...interface{}) (string, []interface{}, error) {
 	var (
 		nthArg, lenRunes   int
@@ -252,6 +418,7 @@ func (db *DB) replaceArgs(sqlS string, args ...interface{}) (string, []interface
 		sb                 strings.Builder
 		runes              []rune
 		currRune, nextRune rune
+		err                error
 	)
 
 	// pretty.Println(args)
@@ -304,9 +471,38 @@ func (db *DB) replaceArgs(sqlS string, args ...interface{}) (string, []interface
 			continue
 		}
 
+		var sub *SQLFragment
+		switch v := arg.(type) {
+		case SQLFragment:
+			sub = &v
+		case *SQLFragment:
+			sub = v
+		}
+		if sub != nil {
+			// subquery, merge its SQL and args
+			subS, subArgs, err := db.replaceArgs(sub.SQL, sub.Args...)
+			if err != nil {
+				return "", nil, xerrors.Errorf("replaceArgs: Subquery: %w", err)
+			}
+			switch db.PlaceholderMode {
+			case DOLLAR:
+				subS = shiftPlaceholders(subS, "$", len(newArgs))
+			case AT:
+				subS = shiftPlaceholders(subS, "@p", len(newArgs))
+			case COLON:
+				subS = shiftPlaceholders(subS, ":", len(newArgs))
+			}
+			sb.WriteRune('(')
+			sb.WriteString(subS)
+			sb.WriteRune(')')
+			newArgs = append(newArgs, subArgs...)
+			continue
+		}
+
 		isValue := false
 		switch arg.(type) {
-		case json.RawMessage:
+		case json.RawMessage, driver.Valuer:
+			// Valuers are passed as is, even if they are slices
 			isValue = true
 		}
 
@@ -333,6 +529,12 @@ func (db *DB) replaceArgs(sqlS string, args ...interface{}) (string, []interface
 				item := rv.Index(i).Interface()
 				if l > db.MaxPlaceholder {
 					// append literals
+					if vr, ok := item.(driver.Valuer); ok {
+						item, err = vr.Value()
+						if err != nil {
+							return "", nil, xerrors.Errorf("Unable to get value of %T in slice placeholder: %w", vr, err)
+						}
+					}
 					switch v := item.(type) {
 					case string:
 						sb.WriteString(db.EscValue(v))
@@ -351,7 +553,7 @@ func (db *DB) replaceArgs(sqlS string, args ...interface{}) (string, []interface
 			continue
 		}
 
-		newArgs = append(newArgs, arg)
+		newArgs = append(newArgs, byteArray(arg))
 		db.appendPlaceholder(&sb, len(newArgs)-1)
 
 	}
@@ -374,6 +576,12 @@ func (db *DB) appendPlaceholder(sb *strings.Builder, numArg int) {
 	case DOLLAR:
 		sb.WriteRune('$')
 		sb.WriteString(strconv.Itoa(numArg + 1))
+	case AT:
+		sb.WriteString("@p")
+		sb.WriteString(strconv.Itoa(numArg + 1))
+	case COLON:
+		sb.WriteRune(':')
+		sb.WriteString(strconv.Itoa(numArg + 1))
 	}
 }
 
@@ -412,7 +620,9 @@ func (db *DB) EscValueForInsert(value interface{}, fi *fieldInfo) string {
 			return "TRUE"
 		}
 	case []uint8:
-		s = string(v)
+		return db.bytesLiteral(v)
+	case *[]uint8:
+		return db.bytesLiteral(*v)
 	case json.RawMessage:
 		s = string(v)
 	case string:
@@ -423,6 +633,8 @@ func (db *DB) EscValueForInsert(value interface{}, fi *fieldInfo) string {
 		s = v.Format(time.RFC3339Nano)
 	case *time.Time:
 		s = v.Format(time.RFC3339Nano)
+	case Decimal:
+		return db.decimalLiteral(v)
 	default:
 		if vr, ok := v.(driver.Valuer); ok {
 			v2, _ := vr.Value()
@@ -441,9 +653,30 @@ func (db *DB) EscValueForInsert(value interface{}, fi *fieldInfo) string {
 	return db.EscValue(s)
 }
 
-// nullValue returns the escaped value suitable for UPDATE & INSERT
+// bytesLiteral returns b as binary literal, using the
+// syntax of the db's driver
+func (db *DB) bytesLiteral(b []byte) string {
+	switch db.Driver {
+	case POSTGRES:
+		return `'\x` + hex.EncodeToString(b) + `'::bytea`
+	case MSSQL:
+		return "0x" + hex.EncodeToString(b)
+	case ORACLE:
+		return "HEXTORAW('" + hex.EncodeToString(b) + "')"
+	default:
+		return "X'" + hex.EncodeToString(b) + "'"
+	}
+}
+
+// nullValue returns the escaped value suitable for UPDATE & INSERT.
+// Without fieldInfo (e.g. for values from maps), the value is returned
+// as is.
 func (db *DB) nullValue(value interface{}, fi *fieldInfo) interface{} {
 
+	if fi == nil {
+		return db.storeTime(value)
+	}
+
 	if isZero(value) {
 		if fi.allowNull() {
 			return nil
@@ -454,9 +687,91 @@ func (db *DB) nullValue(value interface{}, fi *fieldInfo) interface{} {
 		}
 	}
 
+	return db.storeTime(byteArray(value))
+}
+
+// byteArray returns byte arrays like [16]byte as []byte, which
+// database/sql does not convert
+func byteArray(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || !isByteArray(rv.Type()) {
+		return value
+	}
+	if _, ok := value.(driver.Valuer); ok {
+		return value
+	}
+	b := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(b), rv)
+	return b
+}
+
+// isByteArray returns true for byte arrays like [16]byte
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// storeTime converts times to UTC, if StoreTimesUTC is set
+func (db *DB) storeTime(value interface{}) interface{} {
+	if !db.StoreTimesUTC {
+		return value
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC()
+	case *time.Time:
+		if v != nil {
+			utc := v.UTC()
+			return &utc
+		}
+	case NullTime:
+		if v.Valid && v.Time != nil {
+			utc := v.Time.UTC()
+			return NullTime{Time: &utc, Valid: true}
+		}
+	}
 	return value
 }
 
+// setLocation converts the scanned times in v into loc, v can be a
+// time or a struct with time fields
+func setLocation(v reflect.Value, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch t := v.Interface().(type) {
+	case time.Time:
+		v.Set(reflect.ValueOf(t.In(loc)))
+		return
+	case NullTime:
+		if t.Valid && t.Time != nil {
+			inLoc := t.Time.In(loc)
+			v.Set(reflect.ValueOf(NullTime{Time: &inLoc, Valid: true}))
+		}
+		return
+	}
+
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch field.Interface().(type) {
+		case time.Time, *time.Time, NullTime:
+			setLocation(field, loc)
+		}
+	}
+}
+
 // argsToString builds a debug string from given args
 func argsToString(args ...interface{}) string {
 	var (
@@ -475,6 +790,11 @@ func argsToString(args ...interface{}) string {
 			continue
 		}
 
+		if _, ok := arg.(Secret); ok {
+			sb.WriteString(fmt.Sprintf(" #%d secret ***\n", idx))
+			continue
+		}
+
 		switch arg.(type) {
 		case bool, *bool:
 			s = "%v"
@@ -500,6 +820,10 @@ func (db *DB) Close() error {
 	if db.sqlDB == nil {
 		panic("sqlpro.DB.Close: Unable to close, use Open to initialize the wrapper.")
 	}
+	if !db.ownsDB {
+		// passed to NewFromDB, the caller closes it
+		return nil
+	}
 	// log.Printf("sqlpro.Close: %p %s %s", db.DB, db.Driver, db.DSN)
 	return db.sqlDB.Close()
 }
@@ -516,9 +840,20 @@ func Open(driverS, dsn string) (*DB, error) {
 		driver = SQLITE3
 	case "postgres":
 		driver = POSTGRES
+	case "sqlserver":
+		driver = MSSQL
+	case "godror":
+		driver = ORACLE
+	case "clickhouse":
+		driver = CLICKHOUSE
 	}
 
-	conn, err := sql.Open(string(driver), dsn)
+	connDSN := dsn
+	if driver == SQLITE3 {
+		connDSN = sqliteDSN(dsn)
+	}
+
+	conn, err := sql.Open(string(driver), connDSN)
 	if err != nil {
 		return nil, err
 	}
@@ -534,24 +869,52 @@ func Open(driverS, dsn string) (*DB, error) {
 	wrapper := New(conn)
 
 	wrapper.sqlDB = conn
-	wrapper.Driver = driver
+	wrapper.ownsDB = true
 
 	// wrapper.Debug = true
 
 	wrapper.DSN = dsn
 
+	err = wrapper.setDriver(driver)
+	if err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("sqlpro.Open: %w", err)
+	}
+
+	// log.Printf("sqlpro.Open: %p %s %s", wrapper.DB, driver, dsn)
+	return wrapper, nil
+}
+
+// setDriver sets driver and its dialect
+func (db *DB) setDriver(driver dbDriver) error {
+	db.Driver = driver
+
 	switch driver {
 	case POSTGRES:
-		wrapper.PlaceholderMode = DOLLAR
-		wrapper.UseReturningForLastId = true
-		wrapper.SupportsLastInsertId = false
+		db.PlaceholderMode = DOLLAR
+		db.UseReturningForLastId = true
+		db.SupportsLastInsertId = false
+		db.MaxBulkParams = 65535
+	case MSSQL:
+		db.PlaceholderMode = AT
+		db.UseReturningForLastId = true
+		db.SupportsLastInsertId = false
+		db.MaxBulkParams = 2100
+	case ORACLE:
+		db.PlaceholderMode = COLON
+		db.UseReturningForLastId = true
+		db.SupportsLastInsertId = false
+	case CLICKHOUSE:
+		db.UseReturningForLastId = false
+		db.SupportsLastInsertId = false
 	case SQLITE3:
+		db.PlaceholderMode = QUESTION
+		db.BusyRetries = 3
+		db.MaxBulkParams = 999
 	default:
-		return nil, xerrors.Errorf("sqlpro.Open: Unsupported driver '%s'.", driver)
+		return fmt.Errorf("Unsupported driver '%s'.", driver)
 	}
-
-	// log.Printf("sqlpro.Open: %p %s %s", wrapper.DB, driver, dsn)
-	return wrapper, nil
+	return nil
 }
 
 // Open -> handle
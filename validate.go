@@ -0,0 +1,45 @@
+package sqlpro
+
+import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// Validator is implemented by structs which check their values
+// before they are written. Insert, InsertBulk, Update, Save, Upsert,
+// UpdateByKey, GetOrCreate and SyncReferenceTable call Validate for
+// each row and abort if it returns an error, before anything is
+// written for the row. Use SkipValidation to write without checks.
+type Validator interface {
+	Validate() error
+}
+
+// SkipValidation returns a copy which does not call Validate before
+// writing rows, e.g. for trusted bulk imports.
+func (db *DB) SkipValidation() *DB {
+	newDB := *db
+	newDB.skipValidation = true
+	return &newDB
+}
+
+// validate calls Validate of row, if implemented. For addressable
+// rows, Validate is looked up on the pointer to row.
+func (db *DB) validate(row reflect.Value) error {
+	if db.skipValidation {
+		return nil
+	}
+	v := row.Interface()
+	if row.CanAddr() {
+		v = row.Addr().Interface()
+	}
+	vr, ok := v.(Validator)
+	if !ok {
+		return nil
+	}
+	err := vr.Validate()
+	if err != nil {
+		return xerrors.Errorf("sqlpro: Validation of %T failed: %w", v, err)
+	}
+	return nil
+}
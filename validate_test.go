@@ -0,0 +1,68 @@
+package sqlpro
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testRowValidate struct {
+	ID   int64  `db:"id,pk,omitempty"`
+	Name string `db:"name"`
+}
+
+func (r testRowValidate) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	err := db.Exec("CREATE TABLE test_validate(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Insert("test_validate", &testRowValidate{})
+	if err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("Expected validation error, got: %v", err)
+	}
+
+	err = db.InsertBulk("test_validate", []testRowValidate{{Name: "a"}, {}})
+	if err == nil {
+		t.Errorf("Expected validation error for bulk insert")
+	}
+
+	var count int64
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no rows written, got: %d", count)
+	}
+
+	row := testRowValidate{Name: "a"}
+	err = db.Insert("test_validate", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.Name = ""
+	err = db.Update("test_validate", &row)
+	if err == nil {
+		t.Errorf("Expected validation error for update")
+	}
+
+	err = db.SkipValidation().Update("test_validate", &row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Query(&count, "SELECT COUNT(*) FROM test_validate WHERE name = ''")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected row updated without validation, got: %d", count)
+	}
+}
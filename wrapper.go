@@ -1,13 +1,16 @@
 package sqlpro
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"golang.org/x/xerrors"
@@ -18,21 +21,90 @@ type dbDriver string
 // The driver strings must match the driver from the stdlib
 const POSTGRES = "postgres"
 const SQLITE3 = "sqlite3"
+const MSSQL = "sqlserver"
+const ORACLE = "godror"
+const CLICKHOUSE = "clickhouse"
 
 type DB struct {
 	DB                    dbWrappable
-	sqlDB                 *sql.DB // this can be <nil>
-	sqlTx                 *sql.Tx // this can be <nil>
+	sqlDB                 *sql.DB  // this can be <nil>
+	sqlTx                 *sql.Tx  // this can be <nil>
+	ownsDB                bool     // set by Open, Close only closes sqlDB if set
+	txDepth               int      // nesting level of savepoints inside sqlTx
+	fields                []string // columns to select, set by Fields
+	mappings              *structMappings
 	Debug                 bool
 	PlaceholderMode       PlaceholderMode
 	PlaceholderEscape     rune
 	PlaceholderValue      rune
 	PlaceholderKey        rune
 	MaxPlaceholder        int
+	MaxBulkParams         int  // max placeholders per InsertBulk statement, 0 = unlimited, set per driver
+	BulkTransaction       bool // run split InsertBulk statements in one transaction
+	BulkDuplicates        DuplicateMode
+	MinimalEscape         bool // only quote identifiers which need quoting
+	MaxRows               int  // max rows Query scans into a slice, 0 = unlimited
+	TruncateMaxRows       bool // ignore rows after MaxRows instead of returning ErrMaxRowsExceeded
 	UseReturningForLastId bool
 	SupportsLastInsertId  bool
 	Driver                dbDriver
 	DSN                   string
+
+	StoreTimesUTC bool           // convert times to UTC before writing
+	TimeLocation  *time.Location // convert scanned times into this location, if set
+
+	// OnQueryStats is called after each successful Query, if set
+	OnQueryStats func(stats QueryStats)
+
+	// OnCommit receives the events passed to Emit, after the
+	// transaction they were emitted in has been committed
+	OnCommit    func(events []interface{})
+	afterCommit *afterCommit // set inside a transaction
+
+	async    *asyncWriter // set by StartAsync
+	hints    *Hints       // set by WithHints
+	scanHook ScanHookFunc // set by ScanHook
+	unscoped bool         // set by Unscoped
+
+	skipValidation bool       // set by SkipValidation
+	asOf           *time.Time // set by AsOf
+
+	// History configures the history tables read by AsOf, see HistoryTables
+	History *HistoryTables
+
+	ctx context.Context // context of statements and hooks, set by WithContext
+
+	running *queryRegistry // running statements, shared by all copies
+	tracked *tracker       // values recorded by Track, shared by all copies
+
+	historyTables *historyRegistry   // tables registered by RegisterHistory, shared by all copies
+	retention     *retentionRegistry // policies registered by RetentionPolicy, shared by all copies
+
+	MigrationLockTimeout time.Duration // max wait for the lock in Migrate, 0 = 1 minute
+	MigrationLockTTL     time.Duration // age of a stale lock in Migrate, 0 = 15 minutes
+
+	// ValidateGroupBy makes Query check that all selected columns
+	// which are not aggregated are part of GROUP BY, see checkGroupBy
+	ValidateGroupBy bool
+
+	RedactArgs bool // print all args as "***" in debug output and errors, see Secret
+
+	DefaultSchema string // schema added to table names without schema, see EscTable
+
+	BusyRetries int    // retries of statements failing with SQLITE_BUSY, see retryBusy
+	insertOr    string // conflict resolution of INSERT, set by Replace
+	onConflict  string // appended to INSERT, set by GetOrCreate
+
+	AllowMutations bool // run updates as "ALTER TABLE ... UPDATE" for ClickHouse, see checkMutation
+
+	TxMaxRetries int // max retries of RunTxWithRetry, 0 = 5
+
+	// MapUntaggedFields maps exported struct fields without "db" tag
+	// to columns named by NameMapper, see SnakeCase
+	MapUntaggedFields bool
+	NameMapper        func(string) string // nil = SnakeCase
+
+	truncated *bool // reports truncation by MaxRows, set by WithTruncation
 }
 
 type DebugLevel int
@@ -52,6 +124,8 @@ type PlaceholderMode int
 const (
 	DOLLAR   PlaceholderMode = 1
 	QUESTION                 = 2
+	AT       PlaceholderMode = 3 // "@p1", used by MSSQL
+	COLON    PlaceholderMode = 4 // ":1", used by Oracle
 )
 
 type dbWrappable interface {
@@ -61,6 +135,17 @@ type dbWrappable interface {
 
 // NewSqlPro returns a wrapped database handle providing
 // access to the sql pro functions.
+//
+// If dbWrap is a *sql.DB using a Postgres driver (lib/pq, pgx), the
+// Postgres dialect is set: "$1" placeholders, and Insert appends
+// "RETURNING <pk>" to read back the primary key, as these drivers do
+// not support LastInsertId.
+//
+// A pgx connection pool is used through pgx's database/sql adapter,
+// the values are still sent using pgx's binary protocol:
+//
+//	pool, err := pgxpool.New(ctx, dsn)
+//	db := sqlpro.New(stdlib.OpenDBFromPool(pool))
 func New(dbWrap dbWrappable) *DB {
 	var (
 		db *DB
@@ -74,20 +159,212 @@ func New(dbWrap dbWrappable) *DB {
 	db.PlaceholderEscape = '\\'
 	db.PlaceholderKey = '@'
 	db.MaxPlaceholder = 100
+	db.MaxBulkParams = 999
+	db.mappings = &structMappings{}
+	db.running = &queryRegistry{running: map[int64]*runningQuery{}}
+	db.tracked = &tracker{m: map[interface{}]map[string]interface{}{}}
+	db.historyTables = &historyRegistry{tables: map[string]bool{}}
+	db.retention = &retentionRegistry{}
 	db.SupportsLastInsertId = true
 	db.UseReturningForLastId = false
 
+	if conn, ok := dbWrap.(*sql.DB); ok && conn != nil && detectDriver(conn.Driver()) == POSTGRES {
+		_ = db.setDriver(POSTGRES)
+	}
+
 	return db
 }
 
+// NewFromDB returns a wrapped database handle for conn, like Open does
+// for a connection it opens itself. Use this to adopt sqlpro in an
+// application which manages its own connection pool. The dialect is
+// set for the drivers of the supported databases, for other drivers
+// Driver and PlaceholderMode need to be set by the caller.
+//
+// conn stays owned by the caller: Close does not close it.
+func NewFromDB(conn *sql.DB) *DB {
+	wrapper := New(conn)
+	wrapper.sqlDB = conn
+
+	if driver := detectDriver(conn.Driver()); driver != "" {
+		// all detected drivers are supported by setDriver
+		_ = wrapper.setDriver(driver)
+	}
+	return wrapper
+}
+
+// NewFromTx returns a wrapped database handle running all statements
+// inside tx. Use this to run sqlpro inside transactions started by
+// the application's transaction manager, which stays responsible for
+// committing or rolling back tx. Begin starts nested transactions
+// using savepoints.
+//
+// A *sql.Tx does not expose its driver, Driver and PlaceholderMode
+// need to be set for databases other than SQLite, or use WrapTx to
+// inherit them from the wrapper of the *sql.DB. As sqlpro does not
+// see the commit of tx, hooks passed to AfterCommit and events passed
+// to Emit run immediately.
+//
+//	db := sqlpro.NewFromTx(tx)
+//	db.Driver = sqlpro.POSTGRES
+//	db.PlaceholderMode = sqlpro.DOLLAR
+func NewFromTx(tx *sql.Tx) *DB {
+	wrapper := New(tx)
+	wrapper.sqlTx = tx
+	return wrapper
+}
+
+// WrapTx works like NewFromTx, but the returned wrapper inherits the
+// dialect and all other settings of db. Use it to wrap transactions
+// started outside of sqlpro on the *sql.DB of db.
+//
+//	tx, _ := sqlDB.BeginTx(ctx, nil)
+//	txDB := db.WrapTx(tx)
+func (db *DB) WrapTx(tx *sql.Tx) *DB {
+	newDB := *db
+	newDB.DB = tx
+	newDB.sqlDB = nil
+	newDB.sqlTx = tx
+	newDB.ownsDB = false
+	newDB.txDepth = 0
+	newDB.afterCommit = nil
+	return &newDB
+}
+
+// SqlDB returns the *sql.DB handle of db, or nil if the wrapper was
+// created using New or NewFromTx.
+func (db *DB) SqlDB() *sql.DB {
+	return db.sqlDB
+}
+
+// driverPackages maps the package paths of known database/sql drivers
+// to the driver
+var driverPackages = []struct {
+	pkg    string
+	driver dbDriver
+}{
+	{"github.com/lib/pq", POSTGRES},
+	{"github.com/jackc/pgx", POSTGRES},
+	{"github.com/mattn/go-sqlite3", SQLITE3},
+	{"github.com/denisenkom/go-mssqldb", MSSQL},
+	{"github.com/microsoft/go-mssqldb", MSSQL},
+	{"github.com/godror/godror", ORACLE},
+	{"github.com/ClickHouse/clickhouse-go", CLICKHOUSE},
+}
+
+// detectDriver returns the driver of drv by its package, or "" for
+// unknown drivers
+func detectDriver(drv driver.Driver) dbDriver {
+	pkgPath := reflect.Indirect(reflect.ValueOf(drv)).Type().PkgPath()
+	for _, dp := range driverPackages {
+		if strings.HasPrefix(pkgPath, dp.pkg) {
+			return dp.driver
+		}
+	}
+	return ""
+}
+
+// Esc quotes the given identifier. Qualified names like
+// "analytics.events" are quoted per part, as "analytics"."events".
+// With MinimalEscape set, only identifiers which need quoting get
+// quoted, reserved words of the driver always do.
 func (db *DB) Esc(s string) string {
+	parts := splitIdent(s)
+	for idx, part := range parts {
+		parts[idx] = db.escPart(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// EscTable works like Esc, with DefaultSchema added to table names
+// without schema.
+func (db *DB) EscTable(table string) string {
+	if db.DefaultSchema != "" && len(splitIdent(table)) == 1 {
+		return db.Esc(db.DefaultSchema) + "." + db.escPart(table)
+	}
+	return db.Esc(table)
+}
+
+// escPart quotes a single identifier, in brackets for MSSQL. For
+// Oracle, lower case identifiers are quoted in upper case, which is
+// how Oracle stores identifiers created without quotes.
+func (db *DB) escPart(s string) string {
+	if db.MinimalEscape && !db.needsQuoting(s) {
+		return s
+	}
+	switch db.Driver {
+	case MSSQL:
+		return "[" + strings.ReplaceAll(s, "]", "]]") + "]"
+	case ORACLE:
+		if isLowerIdent(s) {
+			s = strings.ToUpper(s)
+		}
+	}
 	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 }
 
+// splitIdent splits a qualified name at its dots. Parts given in
+// double quotes or brackets may contain dots, the quotes are removed.
+func splitIdent(s string) []string {
+	var (
+		parts []string
+		sb    strings.Builder
+		quote byte // the closing quote, if inside a quoted part
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == quote && i+1 < len(s) && s[i+1] == quote:
+			sb.WriteByte(c)
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && c == '"' && sb.Len() == 0:
+			quote = '"'
+		case quote == 0 && c == '[' && sb.Len() == 0:
+			quote = ']'
+		case c == '.' && quote == 0:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// unqualified returns the last part of a qualified name
+func unqualified(s string) string {
+	parts := splitIdent(s)
+	return parts[len(parts)-1]
+}
+
 func (db *DB) EscValue(s string) string {
 	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
 }
 
+// WithMaxRows returns a copy which scans at most n rows
+// into a slice, see MaxRows.
+func (db *DB) WithMaxRows(n int) *DB {
+	newDB := *db
+	newDB.MaxRows = n
+	return &newDB
+}
+
+// WithTruncation returns a copy which ignores the rows after MaxRows
+// instead of returning ErrMaxRowsExceeded, like TruncateMaxRows. Each
+// Query of the copy sets truncated to whether rows were ignored, so
+// that a capped result can be told from a complete one.
+//
+//	var truncated bool
+//	err := db.WithMaxRows(1000).WithTruncation(&truncated).Query(&rows, "SELECT * FROM event")
+func (db *DB) WithTruncation(truncated *bool) *DB {
+	newDB := *db
+	newDB.TruncateMaxRows = true
+	newDB.truncated = truncated
+	return &newDB
+}
+
 // Log returns a copy with debug enabled
 func (db *DB) Log() *DB {
 	newDB := *db
@@ -106,31 +383,76 @@ func (db *DB) Query(target interface{}, query string, args ...interface{}) error
 		newArgs []interface{}
 	)
 
+	if db.hints != nil {
+		if _, ok := target.(**sql.Rows); ok && db.sqlTx == nil && db.hints.needsTx(db.Driver) {
+			return fmt.Errorf("sqlpro.Query: Unable to return *sql.Rows for hints with settings outside of a transaction.")
+		}
+		return db.runHinted(query, func(hdb *DB, query string) error {
+			return hdb.Query(target, query, args...)
+		})
+	}
+
+	start := time.Now()
+
 	query0, newArgs, err = db.replaceArgs(query, args...)
 	if err != nil {
 		return err
 	}
 
-	// log.Printf("RowMode: %s %v", targetValue.Type().Kind(), rowMode)
-
-	rows, err = db.DB.Query(query0, newArgs...)
-	if err != nil {
-		return debugError(sqlError(err, query0, newArgs))
+	if db.ValidateGroupBy {
+		err = checkGroupBy(query0)
+		if err != nil {
+			return err
+		}
 	}
 
+	// log.Printf("RowMode: %s %v", targetValue.Type().Kind(), rowMode)
+
 	switch target.(type) {
 	case **sql.Rows:
+		// the caller reads the rows, so the query cannot be tracked
+		rows, err = db.DB.Query(query0, newArgs...)
+		if err != nil {
+			return debugError(sqlError(err, query0, db.debugArgs(newArgs)))
+		}
 		reflect.ValueOf(target).Elem().Set(reflect.ValueOf(rows))
 		return nil
 	}
 
+	ctx, done := db.track(query0)
+	defer done()
+
+	rows, err = db.queryContext(ctx, query0, newArgs...)
+	if err != nil {
+		return debugError(sqlError(err, query0, db.debugArgs(newArgs)))
+	}
+
 	defer rows.Close()
 
-	err = Scan(target, rows)
+	var stats *QueryStats
+	if db.OnQueryStats != nil {
+		stats = &QueryStats{Query: query}
+	}
+
+	err = scanWith(target, rows, scanOptions{
+		maxRows:   db.MaxRows,
+		truncate:  db.TruncateMaxRows,
+		truncated: db.truncated,
+		stats:     stats,
+		location:  db.TimeLocation,
+		hook:      db.scanHook,
+		mapper:    db.fieldMapper(),
+		foldCase:  db.Driver == ORACLE,
+	})
 	if err != nil {
 		return debugError(err)
 	}
 
+	if stats != nil {
+		stats.Duration = time.Since(start)
+		db.OnQueryStats(*stats)
+	}
+
 	if db.Debug && !strings.HasPrefix(query, "INSERT INTO") {
 		// log.Printf("Query: %s Args: %v", query, args)
 		err = db.PrintQuery(query, args...)
@@ -161,7 +483,7 @@ func (db *DB) PrintQuery(query string, args ...interface{}) error {
 
 	rows, err = db.DB.Query(query0, newArgs...)
 	if err != nil {
-		return sqlError(err, query0, newArgs)
+		return sqlError(err, query0, db.debugArgs(newArgs))
 	}
 	cols, _ := rows.Columns()
 	defer rows.Close()
@@ -172,7 +494,7 @@ func (db *DB) PrintQuery(query string, args ...interface{}) error {
 		return err
 	}
 
-	fmt.Fprint(os.Stdout, sqlDebug(query0, newArgs))
+	fmt.Fprint(os.Stdout, sqlDebug(query0, db.debugArgs(newArgs)))
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader(cols)
 	table.AppendBulk(data)
@@ -208,24 +530,36 @@ func (db *DB) exec(expRows int64, execSql string, args ...interface{}) (int64, e
 		newArgs  []interface{}
 	)
 
+	if db.hints != nil {
+		var n int64
+		err = db.runHinted(execSql, func(hdb *DB, execSql string) error {
+			n, err = hdb.exec(expRows, execSql, args...)
+			return err
+		})
+		return n, err
+	}
+
 	if db.Debug {
-		log.Printf("SQL: %s\nARGS:\n%s", execSql, argsToString(args...))
+		log.Printf("SQL: %s\nARGS:\n%s", execSql, argsToString(db.debugArgs(args)...))
 	}
 
 	execSql0, newArgs, err = db.replaceArgs(execSql, args...)
 	if err != nil {
 		return 0, err
 	}
-	result, err := db.DB.Exec(execSql0, newArgs...)
+	ctx, done := db.track(execSql0)
+	result, err := db.execContext(ctx, execSql0, newArgs...)
+	done()
 	if err != nil {
-		return 0, debugError(sqlError(err, execSql0, newArgs))
+		return 0, debugError(sqlError(err, execSql0, db.debugArgs(newArgs)))
 	}
 	row_count, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	if expRows == -1 {
+	if expRows == -1 || db.Driver == CLICKHOUSE {
+		// ClickHouse does not report the affected rows
 		return row_count, nil
 	}
 